@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+const (
+	// maxUploadSize limits a single attachment, the project quota is checked by the service.
+	maxUploadSize = 50 << 20
+	// multipartMemory is the part of an upload kept in memory, the rest goes to a temporary file.
+	multipartMemory = 1 << 20
+)
+
+type AttachmentService interface {
+	Upload(ctx context.Context, taskID int64, name string, r io.Reader, size int64) (entity.Attachment, error)
+	TaskAttachments(ctx context.Context, taskID int64) ([]entity.Attachment, error)
+	Attachment(ctx context.Context, taskID int64, id int64) (entity.Attachment, error)
+	DeleteAttachment(ctx context.Context, taskID int64, id int64) error
+	Download(ctx context.Context, id int64, expires int64, signature string) (entity.Attachment, io.ReadCloser, error)
+}
+
+type AttachmentHandler struct {
+	attachment AttachmentService
+}
+
+func NewAttachmentHandler(attachment AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachment: attachment}
+}
+
+// Upload expects a multipart/form-data body with the file in the "file" field.
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	// leaves room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize+multipartMemory)
+
+	err = r.ParseMultipartForm(multipartMemory)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			sendError(w, fmt.Errorf("%w: file is larger than %d bytes", entity.ErrTooLarge, maxUploadSize))
+			return
+		}
+
+		sendError(w, fmt.Errorf("%w: %w", entity.ErrBadRequest, err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		sendError(w, fmt.Errorf("%w: 'file' is required", entity.ErrBadRequest))
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxUploadSize {
+		sendError(w, fmt.Errorf("%w: file is larger than %d bytes", entity.ErrTooLarge, maxUploadSize))
+		return
+	}
+
+	attachment, err := h.attachment.Upload(ctx, taskID, header.Filename, file, header.Size)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, attachment)
+}
+
+func (h *AttachmentHandler) TaskAttachments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	attachments, err := h.attachment.TaskAttachments(ctx, taskID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, attachments)
+}
+
+// Attachment returns attachment with a short-lived download_url.
+func (h *AttachmentHandler) Attachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, attachmentID, err := attachmentPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	attachment, err := h.attachment.Attachment(ctx, taskID, attachmentID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, attachment)
+}
+
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, attachmentID, err := attachmentPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.attachment.DeleteAttachment(ctx, taskID, attachmentID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Download serves attachment contents, it is authorized by the signature of the link instead of a session.
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		sendError(w, fmt.Errorf("%w: invalid download link", entity.ErrForbidden))
+		return
+	}
+
+	attachment, body, err := h.attachment.Download(ctx, id, expires, r.URL.Query().Get("signature"))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	_, err = io.Copy(w, body)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func attachmentPathValues(r *http.Request) (taskID int64, attachmentID int64, err error) {
+	taskID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	attachmentID, err = strconv.ParseInt(r.PathValue("attachment_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'attachment_id' must be an integer")
+	}
+
+	return taskID, attachmentID, nil
+}
@@ -4,18 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/google/uuid"
+	"net"
 	"net/http"
 	"restAPI/entity"
 	"time"
 )
 
 type AuthService interface {
-	RegisterUser(ctx context.Context, user entity.User) (entity.User, error)
-	Login(ctx context.Context, email string, password string) (uuid.UUID, error)
+	RegisterUser(ctx context.Context, user entity.User, inviteToken string) (entity.User, error)
+	Login(ctx context.Context, email string, password string, ip string, userAgent string) (entity.Session, error)
+	Logout(ctx context.Context) error
 	Verify(ctx context.Context, code string) error
-	UserBySessionID(ctx context.Context, sessionID string) (entity.User, error)
+	ResendVerification(ctx context.Context, email string) error
+	UserBySessionID(ctx context.Context, sessionID string) (entity.User, entity.Session, error)
+	Sessions(ctx context.Context) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeOtherSessions(ctx context.Context) error
 	SendVerificationLink(ctx context.Context, code string, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, password string) error
 }
 
 type AuthHandler struct {
@@ -26,18 +33,24 @@ func NewAuthHandler(auth AuthService) *AuthHandler {
 	return &AuthHandler{auth: auth}
 }
 
+type RegistrationRequest struct {
+	entity.User
+	// InviteToken optionally accepts a project invitation sent to the user's email.
+	InviteToken string `json:"invite_token"`
+}
+
 func (h *AuthHandler) Registration(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	var user entity.User
+	var request RegistrationRequest
 
-	err := json.NewDecoder(r.Body).Decode(&user)
+	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		sendError(w, err)
 		return
 	}
 
-	user, err = h.auth.RegisterUser(ctx, user)
+	user, err := h.auth.RegisterUser(ctx, request.User, request.InviteToken)
 	if err != nil {
 		sendError(w, err)
 		return
@@ -57,18 +70,78 @@ func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sessionID, err := h.auth.Login(ctx, user.Email, user.Password)
+	session, err := h.auth.Login(ctx, user.Email, user.Password, clientIP(r), r.UserAgent())
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	setSessionCookie(w, session)
+}
+
+func (h *AuthHandler) SignOut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.auth.Logout(ctx)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session_id",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+	})
+}
+
+func (h *AuthHandler) Sessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sessions, err := h.auth.Sessions(ctx)
 	if err != nil {
 		sendError(w, err)
 		return
 	}
 
+	sendResponse(w, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.auth.RevokeSession(ctx, r.PathValue("id"))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RevokeOtherSessions signs out every session of the user except the current one.
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := h.auth.RevokeOtherSessions(ctx)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func setSessionCookie(w http.ResponseWriter, session entity.Session) {
 	cookie := &http.Cookie{
 		Name:     "session_id",
-		Value:    sessionID.String(),
+		Value:    session.ID.String(),
 		Path:     "/",
-		Expires:  time.Now().Add(time.Hour * 24),
-		MaxAge:   24 * 60 * 60,
+		Expires:  session.ExpiresAt,
+		MaxAge:   int(time.Until(session.ExpiresAt).Seconds()),
 		Secure:   true,
 		HttpOnly: true,
 	}
@@ -76,6 +149,15 @@ func (h *AuthHandler) SignIn(w http.ResponseWriter, r *http.Request) {
 	http.SetCookie(w, cookie)
 }
 
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
 func (h *AuthHandler) Verify(w http.ResponseWriter, r *http.Request) {
 	code := r.URL.Query().Get("code")
 
@@ -89,3 +171,76 @@ func (h *AuthHandler) Verify(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Fprint(w, "Verification Completed")
 }
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request ResendVerificationRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.auth.ResendVerification(ctx, request.Email)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request ForgotPasswordRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.auth.ForgotPassword(ctx, request.Email)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request ResetPasswordRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.auth.ResetPassword(ctx, request.Token, request.Password)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
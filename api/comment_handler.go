@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+type CommentService interface {
+	CreateComment(ctx context.Context, taskID int64, body string) (entity.Comment, error)
+	TaskComments(ctx context.Context, taskID int64, lq entity.ListQuery) (entity.Page[entity.Comment], error)
+	UpdateComment(ctx context.Context, taskID int64, id int64, body string) (entity.Comment, error)
+	DeleteComment(ctx context.Context, taskID int64, id int64) error
+	CommentVersions(ctx context.Context, taskID int64, id int64) ([]entity.CommentVersion, error)
+}
+
+type CommentHandler struct {
+	comment CommentService
+}
+
+func NewCommentHandler(comment CommentService) *CommentHandler {
+	return &CommentHandler{comment: comment}
+}
+
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request CommentRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	comment, err := h.comment.CreateComment(ctx, taskID, request.Body)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, comment)
+}
+
+// TaskComments returns comments oldest first and accepts the parameters of listQueryParams.
+func (h *CommentHandler) TaskComments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	lq, err := listQueryParams(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	comments, err := h.comment.TaskComments(ctx, taskID, lq)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, comments)
+}
+
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, commentID, err := commentPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var request CommentRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	comment, err := h.comment.UpdateComment(ctx, taskID, commentID, request.Body)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, comment)
+}
+
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, commentID, err := commentPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.comment.DeleteComment(ctx, taskID, commentID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *CommentHandler) CommentVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, commentID, err := commentPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	versions, err := h.comment.CommentVersions(ctx, taskID, commentID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, versions)
+}
+
+func commentPathValues(r *http.Request) (taskID int64, commentID int64, err error) {
+	taskID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	commentID, err = strconv.ParseInt(r.PathValue("comment_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'comment_id' must be an integer")
+	}
+
+	return taskID, commentID, nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+type InvitationService interface {
+	Invite(ctx context.Context, projectID int64, email string, role entity.Role) (entity.Invitation, error)
+	Invitations(ctx context.Context, projectID int64) ([]entity.Invitation, error)
+	ResendInvitation(ctx context.Context, projectID int64, invitationID int64) error
+	RevokeInvitation(ctx context.Context, projectID int64, invitationID int64) error
+	AcceptInvitation(ctx context.Context, token string) error
+}
+
+type InvitationHandler struct {
+	invitation InvitationService
+}
+
+func NewInvitationHandler(invitation InvitationService) *InvitationHandler {
+	return &InvitationHandler{invitation: invitation}
+}
+
+type InviteRequest struct {
+	Email string      `json:"email"`
+	Role  entity.Role `json:"role"`
+}
+
+func (h *InvitationHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request InviteRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	if request.Role == "" {
+		request.Role = entity.RoleMember
+	}
+
+	invitation, err := h.invitation.Invite(ctx, projectID, request.Email, request.Role)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, invitation)
+}
+
+func (h *InvitationHandler) Invitations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	invitations, err := h.invitation.Invitations(ctx, projectID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, invitations)
+}
+
+func (h *InvitationHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, invitationID, err := invitationPath(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.invitation.ResendInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, invitationID, err := invitationPath(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.invitation.RevokeInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// AcceptInvitation accepts the invitation by 'token' query parameter, as in the emailed link,
+// or by the token in request body.
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	request := AcceptInvitationRequest{Token: r.URL.Query().Get("token")}
+
+	if request.Token == "" {
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			sendError(w, err)
+			return
+		}
+	}
+
+	err := h.invitation.AcceptInvitation(ctx, request.Token)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func invitationPath(r *http.Request) (projectID int64, invitationID int64, err error) {
+	projectID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	invitationID, err = strconv.ParseInt(r.PathValue("invitation_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'invitation_id' must be an integer")
+	}
+
+	return projectID, invitationID, nil
+}
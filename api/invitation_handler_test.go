@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"restAPI/entity"
+	"strings"
+	"testing"
+)
+
+type sessionAuth struct {
+	AuthService
+}
+
+func (a sessionAuth) UserBySessionID(ctx context.Context, sessionID string) (entity.User, entity.Session, error) {
+	return entity.User{ID: 1}, entity.Session{}, nil
+}
+
+type acceptedInvitations struct {
+	InvitationService
+	tokens []string
+}
+
+func (s *acceptedInvitations) AcceptInvitation(ctx context.Context, token string) error {
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+func TestInvitationHandler_AcceptInvitation(t *testing.T) {
+	invitations := &acceptedInvitations{}
+
+	s := NewServer(nil, nil, nil, nil, nil, NewInvitationHandler(invitations), nil, nil, nil, nil, nil, nil, "0", NewMiddleware(sessionAuth{}, nil))
+	s.setRoutes()
+
+	accept := func(r *http.Request) int {
+		r.AddCookie(&http.Cookie{Name: "session_id", Value: "session"})
+
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	// the link sent by email
+	require.Equal(t, http.StatusOK, accept(httptest.NewRequest(http.MethodGet, "/invitations/accept?token=7.1700000000.abc", nil)))
+	require.Equal(t, http.StatusOK, accept(httptest.NewRequest(http.MethodPost, "/invitations/accept", strings.NewReader(`{"token":"8.1700000000.def"}`))))
+	require.Equal(t, []string{"7.1700000000.abc", "8.1700000000.def"}, invitations.tokens)
+}
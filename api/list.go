@@ -0,0 +1,60 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+	"time"
+)
+
+// listQueryParams reads paging, sorting and common filters of list endpoints:
+// 'limit', 'after' (the 'next_cursor' of the previous page), 'sort' (a field name,
+// '-' prefix sorts descending), 'created_after' and 'created_before' (RFC 3339)
+// and 'name' which matches a part of the name.
+func listQueryParams(r *http.Request) (entity.ListQuery, error) {
+	query := r.URL.Query()
+
+	lq := entity.ListQuery{
+		After:        query.Get("after"),
+		Sort:         query.Get("sort"),
+		NameContains: query.Get("name"),
+	}
+
+	if q := query.Get("limit"); q != "" {
+		limit, err := strconv.Atoi(q)
+		if err != nil || limit <= 0 {
+			return entity.ListQuery{}, fmt.Errorf("%w: 'limit' must be a positive integer", entity.ErrBadRequest)
+		}
+
+		lq.Limit = limit
+	}
+
+	var err error
+
+	lq.CreatedAfter, err = timeParam(r, "created_after")
+	if err != nil {
+		return entity.ListQuery{}, err
+	}
+
+	lq.CreatedBefore, err = timeParam(r, "created_before")
+	if err != nil {
+		return entity.ListQuery{}, err
+	}
+
+	return lq, nil
+}
+
+func timeParam(r *http.Request, name string) (*time.Time, error) {
+	q := r.URL.Query().Get(name)
+	if q == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, q)
+	if err != nil {
+		return nil, fmt.Errorf("%w: '%s' must be an RFC 3339 time", entity.ErrBadRequest, name)
+	}
+
+	return &t, nil
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"restAPI/entity"
+	"strings"
 )
 
 type Middleware struct {
-	auth AuthService
+	auth  AuthService
+	token TokenService
 }
 
-func NewMiddleware(auth AuthService) *Middleware {
+func NewMiddleware(auth AuthService, token TokenService) *Middleware {
 	return &Middleware{
-		auth: auth,
+		auth:  auth,
+		token: token,
 	}
 }
 
@@ -24,24 +28,71 @@ func (mw *Middleware) Log(next http.Handler) http.Handler {
 	})
 }
 
+// Auth authenticates request either by "Authorization: Bearer" personal access token
+// or by session cookie.
 func (mw *Middleware) Auth(next http.HandlerFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			user, err := mw.token.UserByAccessToken(r.Context(), token, requiredScope(r))
+			if err != nil {
+				sendError(w, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), "user", user)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		cookie, err := r.Cookie("session_id")
 		if err != nil {
 			sendError(w, err)
 			return
 		}
 
-		user, err := mw.auth.UserBySessionID(context.Background(), cookie.Value)
+		ctx := r.Context()
+
+		user, session, err := mw.auth.UserBySessionID(ctx, cookie.Value)
 		if err != nil {
 			sendError(w, err)
 			return
 		}
 
-		ctx := r.Context()
+		// session expiry slides with every request, so does the cookie
+		setSessionCookie(w, session)
+
 		ctx = context.WithValue(ctx, "user", user)
+		ctx = context.WithValue(ctx, "session_id", session.ID.String())
 
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
 }
+
+// scopeResources maps first path segments without scopes of their own onto the resource they act on.
+var scopeResources = map[string]string{
+	"timer": "tasks",
+}
+
+// requiredScope returns access token scope needed for request: resource is taken from
+// the first path segment and access is read-only for safe methods.
+func requiredScope(r *http.Request) string {
+	// the emailed link accepts an invitation with GET, which still joins a project
+	if r.URL.Path == "/invitations/accept" {
+		return entity.ScopeProjectsWrite
+	}
+
+	resource, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	if mapped, ok := scopeResources[resource]; ok {
+		resource = mapped
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return resource + ":read"
+	}
+
+	return resource + ":write"
+}
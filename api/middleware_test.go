@@ -0,0 +1,30 @@
+package api
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"restAPI/entity"
+	"testing"
+)
+
+func TestRequiredScope(t *testing.T) {
+	for _, tc := range []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{http.MethodGet, "/tasks/1", entity.ScopeTasksRead},
+		{http.MethodPatch, "/projects/1", entity.ScopeProjectsWrite},
+		{http.MethodGet, "/timer", entity.ScopeTasksRead},
+		{http.MethodPost, "/timer/stop", entity.ScopeTasksWrite},
+		{http.MethodGet, "/invitations/accept", entity.ScopeProjectsWrite},
+		{http.MethodPost, "/invitations/accept", entity.ScopeProjectsWrite},
+	} {
+		scope := requiredScope(httptest.NewRequest(tc.method, tc.path, nil))
+		require.Equal(t, tc.scope, scope, tc.path)
+
+		// a token without scopes grants every scope a route requires
+		require.True(t, entity.PersonalAccessToken{}.Allows(scope), tc.path)
+	}
+}
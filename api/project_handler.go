@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"restAPI/entity"
 	"strconv"
@@ -12,9 +13,28 @@ import (
 type ProjectService interface {
 	CreateProject(ctx context.Context, project entity.Project) (entity.Project, error)
 	ProjectByID(ctx context.Context, id int64) (entity.Project, error)
-	UserProjects(ctx context.Context) ([]entity.Project, error)
+	UserProjects(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.Project], error)
+	UpdateProject(ctx context.Context, projectID int64, upd entity.ProjectToUpdate) (entity.Project, error)
+	TransferProject(ctx context.Context, projectID int64, userID int64) error
 	DeleteProject(ctx context.Context, projectID int64) error
-	AddProjectMember(ctx context.Context, projectID int64, userID int64) error
+	AddProjectMember(ctx context.Context, projectID int64, userID int64, role entity.Role) error
+	SetMemberRole(ctx context.Context, projectID int64, userID int64, role entity.Role) error
+	RemoveProjectMember(ctx context.Context, projectID int64, userID int64, reassignTo int64) error
+	LeaveProject(ctx context.Context, projectID int64, reassignTo int64) error
+	Workflow(ctx context.Context, projectID int64) (entity.Workflow, error)
+	CreateStatus(ctx context.Context, s entity.Status) (entity.Status, error)
+	UpdateStatus(ctx context.Context, projectID int64, id int64, upd entity.StatusToUpdate) (entity.Status, error)
+	DeleteStatus(ctx context.Context, projectID int64, id int64) error
+	SetTransitions(ctx context.Context, projectID int64, transitions []entity.StatusTransition) error
+	ProjectLabels(ctx context.Context, projectID int64) ([]entity.Label, error)
+	CreateLabel(ctx context.Context, l entity.Label) (entity.Label, error)
+	UpdateLabel(ctx context.Context, projectID int64, id int64, upd entity.LabelToUpdate) (entity.Label, error)
+	DeleteLabel(ctx context.Context, projectID int64, id int64) error
+	ProjectCustomFields(ctx context.Context, projectID int64) ([]entity.CustomField, error)
+	CreateCustomField(ctx context.Context, f entity.CustomField) (entity.CustomField, error)
+	UpdateCustomField(ctx context.Context, projectID int64, id int64, upd entity.CustomFieldToUpdate) (entity.CustomField, error)
+	DeleteCustomField(ctx context.Context, projectID int64, id int64) error
+	Board(ctx context.Context, projectID int64) (entity.Board, error)
 }
 
 type ProjectHandler struct {
@@ -48,7 +68,13 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 func (h *ProjectHandler) UserProjects(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	projects, err := h.project.UserProjects(ctx)
+	lq, err := listQueryParams(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	projects, err := h.project.UserProjects(ctx, lq)
 	if err != nil {
 		sendError(w, err)
 		return
@@ -76,6 +102,64 @@ func (h *ProjectHandler) ProjectByID(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, project)
 }
 
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var upd entity.ProjectToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	project, err := h.project.UpdateProject(ctx, projectID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, project)
+}
+
+type TransferProjectRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+func (h *ProjectHandler) TransferProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request TransferProjectRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.TransferProject(ctx, projectID, request.UserID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -96,8 +180,9 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 }
 
 type AddProjectUserRequest struct {
-	ProjectID int64 `json:"project_id"`
-	UserID    int64 `json:"user_id"`
+	ProjectID int64       `json:"project_id"`
+	UserID    int64       `json:"user_id"`
+	Role      entity.Role `json:"role"`
 }
 
 func (h *ProjectHandler) AddProjectUser(w http.ResponseWriter, r *http.Request) {
@@ -108,9 +193,13 @@ func (h *ProjectHandler) AddProjectUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if request.Role == "" {
+		request.Role = entity.RoleMember
+	}
+
 	ctx := r.Context()
 
-	err = h.project.AddProjectMember(ctx, request.ProjectID, request.UserID)
+	err = h.project.AddProjectMember(ctx, request.ProjectID, request.UserID, request.Role)
 	if err != nil {
 		sendError(w, err)
 		return
@@ -118,3 +207,485 @@ func (h *ProjectHandler) AddProjectUser(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+type SetMemberRoleRequest struct {
+	Role entity.Role `json:"role"`
+}
+
+func (h *ProjectHandler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	qUserID := r.PathValue("user_id")
+	userID, err := strconv.ParseInt(qUserID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'user_id' must be an integer"))
+		return
+	}
+
+	var request SetMemberRoleRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.SetMemberRole(ctx, projectID, userID, request.Role)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveProjectUser removes member from project. Optional 'reassign_to' query parameter
+// names the member who gets tasks assigned to removed member, by default they are left unassigned.
+func (h *ProjectHandler) RemoveProjectUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	qUserID := r.PathValue("user_id")
+	userID, err := strconv.ParseInt(qUserID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'user_id' must be an integer"))
+		return
+	}
+
+	reassignTo, err := reassignToParam(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.RemoveProjectMember(ctx, projectID, userID, reassignTo)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// LeaveProject accepts the same 'reassign_to' query parameter as RemoveProjectUser.
+func (h *ProjectHandler) LeaveProject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	reassignTo, err := reassignToParam(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.LeaveProject(ctx, projectID, reassignTo)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func reassignToParam(r *http.Request) (int64, error) {
+	q := r.URL.Query().Get("reassign_to")
+	if q == "" {
+		return 0, nil
+	}
+
+	reassignTo, err := strconv.ParseInt(q, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: 'reassign_to' must be an integer", entity.ErrBadRequest)
+	}
+
+	return reassignTo, nil
+}
+
+func (h *ProjectHandler) Workflow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	workflow, err := h.project.Workflow(ctx, projectID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, workflow)
+}
+
+func (h *ProjectHandler) CreateStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var status entity.Status
+
+	err = json.NewDecoder(r.Body).Decode(&status)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	status.ProjectID = projectID
+
+	status, err = h.project.CreateStatus(ctx, status)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, status)
+}
+
+func (h *ProjectHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	qStatusID := r.PathValue("status_id")
+	statusID, err := strconv.ParseInt(qStatusID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'status_id' must be an integer"))
+		return
+	}
+
+	var upd entity.StatusToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	status, err := h.project.UpdateStatus(ctx, projectID, statusID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, status)
+}
+
+func (h *ProjectHandler) DeleteStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	qStatusID := r.PathValue("status_id")
+	statusID, err := strconv.ParseInt(qStatusID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'status_id' must be an integer"))
+		return
+	}
+
+	err = h.project.DeleteStatus(ctx, projectID, statusID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type SetTransitionsRequest struct {
+	Transitions []entity.StatusTransition `json:"transitions"`
+}
+
+func (h *ProjectHandler) SetTransitions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request SetTransitionsRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.SetTransitions(ctx, projectID, request.Transitions)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *ProjectHandler) ProjectLabels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	labels, err := h.project.ProjectLabels(ctx, projectID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, labels)
+}
+
+func (h *ProjectHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var label entity.Label
+
+	err = json.NewDecoder(r.Body).Decode(&label)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	label.ProjectID = projectID
+
+	label, err = h.project.CreateLabel(ctx, label)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, label)
+}
+
+func (h *ProjectHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, labelID, err := labelPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var upd entity.LabelToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	label, err := h.project.UpdateLabel(ctx, projectID, labelID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, label)
+}
+
+func (h *ProjectHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, labelID, err := labelPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.DeleteLabel(ctx, projectID, labelID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func labelPathValues(r *http.Request) (projectID int64, labelID int64, err error) {
+	projectID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	labelID, err = strconv.ParseInt(r.PathValue("label_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'label_id' must be an integer")
+	}
+
+	return projectID, labelID, nil
+}
+
+func (h *ProjectHandler) ProjectCustomFields(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	fields, err := h.project.ProjectCustomFields(ctx, projectID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, fields)
+}
+
+func (h *ProjectHandler) CreateCustomField(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var field entity.CustomField
+
+	err = json.NewDecoder(r.Body).Decode(&field)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	field.ProjectID = projectID
+
+	field, err = h.project.CreateCustomField(ctx, field)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, field)
+}
+
+func (h *ProjectHandler) UpdateCustomField(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, fieldID, err := customFieldPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var upd entity.CustomFieldToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	field, err := h.project.UpdateCustomField(ctx, projectID, fieldID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, field)
+}
+
+func (h *ProjectHandler) DeleteCustomField(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, fieldID, err := customFieldPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.project.DeleteCustomField(ctx, projectID, fieldID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func customFieldPathValues(r *http.Request) (projectID int64, fieldID int64, err error) {
+	projectID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	fieldID, err = strconv.ParseInt(r.PathValue("field_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'field_id' must be an integer")
+	}
+
+	return projectID, fieldID, nil
+}
+
+func (h *ProjectHandler) Board(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	board, err := h.project.Board(ctx, projectID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, board)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+type RecurrenceService interface {
+	SetRecurrence(ctx context.Context, taskID int64, set entity.RecurrenceToSet) (entity.Recurrence, error)
+	Recurrence(ctx context.Context, taskID int64) (entity.Recurrence, error)
+	DeleteRecurrence(ctx context.Context, taskID int64) error
+}
+
+type RecurrenceHandler struct {
+	recurrence RecurrenceService
+}
+
+func NewRecurrenceHandler(recurrence RecurrenceService) *RecurrenceHandler {
+	return &RecurrenceHandler{recurrence: recurrence}
+}
+
+// SetRecurrence makes the task a template of recurring tasks, see entity.Recurrence for the rule.
+func (h *RecurrenceHandler) SetRecurrence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var set entity.RecurrenceToSet
+
+	err = json.NewDecoder(r.Body).Decode(&set)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	rec, err := h.recurrence.SetRecurrence(ctx, taskID, set)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, rec)
+}
+
+func (h *RecurrenceHandler) Recurrence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	rec, err := h.recurrence.Recurrence(ctx, taskID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, rec)
+}
+
+func (h *RecurrenceHandler) DeleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	err = h.recurrence.DeleteRecurrence(ctx, taskID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -27,6 +27,16 @@ func sendError(w http.ResponseWriter, err error) {
 		statusCode = http.StatusUnauthorized
 	case errors.Is(err, entity.ErrForbidden):
 		statusCode = http.StatusForbidden
+	case errors.Is(err, entity.ErrBadRequest):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, entity.ErrConflict):
+		statusCode = http.StatusConflict
+	case errors.Is(err, entity.ErrExpired):
+		statusCode = http.StatusGone
+	case errors.Is(err, entity.ErrTooManyRequests):
+		statusCode = http.StatusTooManyRequests
+	case errors.Is(err, entity.ErrTooLarge):
+		statusCode = http.StatusRequestEntityTooLarge
 	}
 
 	w.WriteHeader(statusCode)
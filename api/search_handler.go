@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+type SearchService interface {
+	Search(ctx context.Context, sq entity.SearchQuery) ([]entity.SearchResult, error)
+}
+
+type SearchHandler struct {
+	search SearchService
+}
+
+func NewSearchHandler(search SearchService) *SearchHandler {
+	return &SearchHandler{search: search}
+}
+
+// Search accepts 'q' in web search syntax (quoted phrases, 'or', '-word'),
+// optional 'type' (task or project) and 'limit'.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+
+	sq := entity.SearchQuery{
+		Text: query.Get("q"),
+		Type: query.Get("type"),
+	}
+
+	if q := query.Get("limit"); q != "" {
+		limit, err := strconv.Atoi(q)
+		if err != nil {
+			sendError(w, fmt.Errorf("%w: 'limit' must be an integer", entity.ErrBadRequest))
+			return
+		}
+
+		sq.Limit = limit
+	}
+
+	results, err := h.search.Search(ctx, sq)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, results)
+}
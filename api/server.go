@@ -12,11 +12,19 @@ type Server struct {
 	projHdr *ProjectHandler
 	userHdr *UserHandler
 	authHdr *AuthHandler
+	tokHdr  *TokenHandler
+	invHdr  *InvitationHandler
+	srchHdr *SearchHandler
+	cmtHdr  *CommentHandler
+	attHdr  *AttachmentHandler
+	sprHdr  *SprintHandler
+	timeHdr *TimeHandler
+	recHdr  *RecurrenceHandler
 	mw      *Middleware
 }
 
 // NewServer returns http router to work with.
-func NewServer(t *TaskHandler, p *ProjectHandler, u *UserHandler, a *AuthHandler, port string, mw *Middleware) *Server {
+func NewServer(t *TaskHandler, p *ProjectHandler, u *UserHandler, a *AuthHandler, tok *TokenHandler, inv *InvitationHandler, srch *SearchHandler, cmt *CommentHandler, att *AttachmentHandler, spr *SprintHandler, tm *TimeHandler, rec *RecurrenceHandler, port string, mw *Middleware) *Server {
 	return &Server{
 		port:    port,
 		router:  http.NewServeMux(),
@@ -24,6 +32,14 @@ func NewServer(t *TaskHandler, p *ProjectHandler, u *UserHandler, a *AuthHandler
 		projHdr: p,
 		userHdr: u,
 		authHdr: a,
+		tokHdr:  tok,
+		invHdr:  inv,
+		srchHdr: srch,
+		cmtHdr:  cmt,
+		attHdr:  att,
+		sprHdr:  spr,
+		timeHdr: tm,
+		recHdr:  rec,
 		mw:      mw,
 	}
 }
@@ -35,26 +51,128 @@ func (s *Server) setRoutes() {
 	//s.router.HandleFunc("DELETE /users/{id}", s.h.EditUser)
 	s.router.HandleFunc("GET /users/{id}", s.userHdr.UserByID)
 	s.router.HandleFunc("GET /users", s.userHdr.Users)
+	s.router.Handle("PATCH /users/me", s.mw.Auth(s.userHdr.UpdateMe))
 	s.router.Handle("GET /projects/{project_id}/users", s.mw.Auth(s.userHdr.ProjectUsers))
 
 	// auth routes
 	s.router.HandleFunc("POST /users", s.authHdr.Registration)
 	s.router.HandleFunc("GET /users/verify", s.authHdr.Verify)
+	s.router.HandleFunc("POST /users/verify/resend", s.authHdr.ResendVerification)
 	s.router.HandleFunc("POST /signin", s.authHdr.SignIn)
+	s.router.Handle("POST /signout", s.mw.Auth(s.authHdr.SignOut))
+	s.router.Handle("GET /sessions", s.mw.Auth(s.authHdr.Sessions))
+	s.router.Handle("DELETE /sessions/{id}", s.mw.Auth(s.authHdr.RevokeSession))
+	s.router.Handle("DELETE /sessions", s.mw.Auth(s.authHdr.RevokeOtherSessions))
+	s.router.HandleFunc("POST /password/forgot", s.authHdr.ForgotPassword)
+	s.router.HandleFunc("POST /password/reset", s.authHdr.ResetPassword)
+
+	// personal access token routes
+	s.router.Handle("POST /tokens", s.mw.Auth(s.tokHdr.CreateAccessToken))
+	s.router.Handle("GET /tokens", s.mw.Auth(s.tokHdr.AccessTokens))
+	s.router.Handle("DELETE /tokens/{id}", s.mw.Auth(s.tokHdr.RevokeAccessToken))
 
 	// project routes
 	s.router.Handle("POST /projects", s.mw.Auth(s.projHdr.CreateProject))
 	s.router.Handle("DELETE /projects/{id}", s.mw.Auth(s.projHdr.DeleteProject))
 	s.router.Handle("GET /projects", s.mw.Auth(s.projHdr.UserProjects))
 	s.router.Handle("GET /projects/{id}", s.mw.Auth(s.projHdr.ProjectByID))
-	//s.router.HandleFunc("POST /projects", s.h.EditProject)
+	s.router.Handle("PATCH /projects/{id}", s.mw.Auth(s.projHdr.UpdateProject))
+	s.router.Handle("POST /projects/{id}/transfer", s.mw.Auth(s.projHdr.TransferProject))
 	s.router.Handle("POST /projects/users", s.mw.Auth(s.projHdr.AddProjectUser))
+	s.router.Handle("PATCH /projects/{id}/users/{user_id}", s.mw.Auth(s.projHdr.SetMemberRole))
+	s.router.Handle("DELETE /projects/{id}/users/{user_id}", s.mw.Auth(s.projHdr.RemoveProjectUser))
+	s.router.Handle("POST /projects/{id}/leave", s.mw.Auth(s.projHdr.LeaveProject))
+	s.router.Handle("GET /projects/{id}/statuses", s.mw.Auth(s.projHdr.Workflow))
+	s.router.Handle("POST /projects/{id}/statuses", s.mw.Auth(s.projHdr.CreateStatus))
+	s.router.Handle("PATCH /projects/{id}/statuses/{status_id}", s.mw.Auth(s.projHdr.UpdateStatus))
+	s.router.Handle("DELETE /projects/{id}/statuses/{status_id}", s.mw.Auth(s.projHdr.DeleteStatus))
+	s.router.Handle("PUT /projects/{id}/transitions", s.mw.Auth(s.projHdr.SetTransitions))
+	s.router.Handle("GET /projects/{id}/board", s.mw.Auth(s.projHdr.Board))
+	s.router.Handle("GET /projects/{id}/labels", s.mw.Auth(s.projHdr.ProjectLabels))
+	s.router.Handle("POST /projects/{id}/labels", s.mw.Auth(s.projHdr.CreateLabel))
+	s.router.Handle("PATCH /projects/{id}/labels/{label_id}", s.mw.Auth(s.projHdr.UpdateLabel))
+	s.router.Handle("DELETE /projects/{id}/labels/{label_id}", s.mw.Auth(s.projHdr.DeleteLabel))
+	s.router.Handle("GET /projects/{id}/custom-fields", s.mw.Auth(s.projHdr.ProjectCustomFields))
+	s.router.Handle("POST /projects/{id}/custom-fields", s.mw.Auth(s.projHdr.CreateCustomField))
+	s.router.Handle("PATCH /projects/{id}/custom-fields/{field_id}", s.mw.Auth(s.projHdr.UpdateCustomField))
+	s.router.Handle("DELETE /projects/{id}/custom-fields/{field_id}", s.mw.Auth(s.projHdr.DeleteCustomField))
+
+	// invitation routes
+	s.router.Handle("POST /projects/{id}/invitations", s.mw.Auth(s.invHdr.Invite))
+	s.router.Handle("GET /projects/{id}/invitations", s.mw.Auth(s.invHdr.Invitations))
+	s.router.Handle("POST /projects/{id}/invitations/{invitation_id}/resend", s.mw.Auth(s.invHdr.ResendInvitation))
+	s.router.Handle("DELETE /projects/{id}/invitations/{invitation_id}", s.mw.Auth(s.invHdr.RevokeInvitation))
+	s.router.Handle("GET /invitations/accept", s.mw.Auth(s.invHdr.AcceptInvitation))
+	s.router.Handle("POST /invitations/accept", s.mw.Auth(s.invHdr.AcceptInvitation))
 
 	// task routes
 	s.router.Handle("POST /tasks", s.mw.Auth(s.taskHdr.CreateTask))
 	s.router.Handle("GET /tasks/{id}", s.mw.Auth(s.taskHdr.TaskByID))
+	s.router.Handle("PATCH /tasks/{id}", s.mw.Auth(s.taskHdr.UpdateTask))
+	s.router.Handle("DELETE /tasks/{id}", s.mw.Auth(s.taskHdr.DeleteTask))
+	s.router.Handle("POST /tasks/{id}/transition", s.mw.Auth(s.taskHdr.TransitionTask))
+	s.router.Handle("POST /tasks/{id}/move", s.mw.Auth(s.taskHdr.MoveTask))
+	s.router.Handle("PUT /tasks/{id}/assignee", s.mw.Auth(s.taskHdr.AssignTask))
+	s.router.Handle("DELETE /tasks/{id}/assignee", s.mw.Auth(s.taskHdr.UnassignTask))
+	s.router.Handle("PUT /tasks/{id}/parent", s.mw.Auth(s.taskHdr.SetTaskParent))
+	s.router.Handle("DELETE /tasks/{id}/parent", s.mw.Auth(s.taskHdr.DetachTask))
+	s.router.Handle("GET /tasks/{id}/subtasks", s.mw.Auth(s.taskHdr.Subtasks))
+	s.router.Handle("GET /tasks/{id}/dependencies", s.mw.Auth(s.taskHdr.TaskDependencies))
+	s.router.Handle("PUT /tasks/{id}/blocks/{blocked_id}", s.mw.Auth(s.taskHdr.AddDependency))
+	s.router.Handle("DELETE /tasks/{id}/blocks/{blocked_id}", s.mw.Auth(s.taskHdr.RemoveDependency))
+	s.router.Handle("PUT /tasks/{id}/labels/{label_id}", s.mw.Auth(s.taskHdr.TagTask))
+	s.router.Handle("DELETE /tasks/{id}/labels/{label_id}", s.mw.Auth(s.taskHdr.UntagTask))
+	s.router.Handle("POST /tasks/{id}/checklist", s.mw.Auth(s.taskHdr.AddChecklistItem))
+	s.router.Handle("PATCH /tasks/{id}/checklist/{item_id}", s.mw.Auth(s.taskHdr.UpdateChecklistItem))
+	s.router.Handle("DELETE /tasks/{id}/checklist/{item_id}", s.mw.Auth(s.taskHdr.DeleteChecklistItem))
 	s.router.Handle("GET /projects/{project_id}/tasks", s.mw.Auth(s.taskHdr.ProjectTasks))
 	s.router.Handle("GET /tasks", s.mw.Auth(s.taskHdr.UserTasks))
+
+	// comment routes
+	s.router.Handle("POST /tasks/{id}/comments", s.mw.Auth(s.cmtHdr.CreateComment))
+	s.router.Handle("GET /tasks/{id}/comments", s.mw.Auth(s.cmtHdr.TaskComments))
+	s.router.Handle("PATCH /tasks/{id}/comments/{comment_id}", s.mw.Auth(s.cmtHdr.UpdateComment))
+	s.router.Handle("DELETE /tasks/{id}/comments/{comment_id}", s.mw.Auth(s.cmtHdr.DeleteComment))
+	s.router.Handle("GET /tasks/{id}/comments/{comment_id}/versions", s.mw.Auth(s.cmtHdr.CommentVersions))
+
+	// attachment routes
+	s.router.Handle("POST /tasks/{id}/attachments", s.mw.Auth(s.attHdr.Upload))
+	s.router.Handle("GET /tasks/{id}/attachments", s.mw.Auth(s.attHdr.TaskAttachments))
+	s.router.Handle("GET /tasks/{id}/attachments/{attachment_id}", s.mw.Auth(s.attHdr.Attachment))
+	s.router.Handle("DELETE /tasks/{id}/attachments/{attachment_id}", s.mw.Auth(s.attHdr.DeleteAttachment))
+	s.router.HandleFunc("GET /attachments/{id}/download", s.attHdr.Download)
+
+	// sprint routes
+	s.router.Handle("GET /projects/{id}/sprints", s.mw.Auth(s.sprHdr.ProjectSprints))
+	s.router.Handle("POST /projects/{id}/sprints", s.mw.Auth(s.sprHdr.CreateSprint))
+	s.router.Handle("GET /projects/{id}/sprints/{sprint_id}", s.mw.Auth(s.sprHdr.Sprint))
+	s.router.Handle("PATCH /projects/{id}/sprints/{sprint_id}", s.mw.Auth(s.sprHdr.UpdateSprint))
+	s.router.Handle("DELETE /projects/{id}/sprints/{sprint_id}", s.mw.Auth(s.sprHdr.DeleteSprint))
+	s.router.Handle("POST /projects/{id}/sprints/{sprint_id}/close", s.mw.Auth(s.sprHdr.CloseSprint))
+	s.router.Handle("GET /projects/{id}/sprints/{sprint_id}/report", s.mw.Auth(s.sprHdr.SprintReport))
+	s.router.Handle("PUT /tasks/{id}/sprint", s.mw.Auth(s.sprHdr.SetTaskSprint))
+	s.router.Handle("DELETE /tasks/{id}/sprint", s.mw.Auth(s.sprHdr.RemoveTaskSprint))
+
+	// time tracking routes
+	s.router.Handle("POST /tasks/{id}/time-entries", s.mw.Auth(s.timeHdr.LogTime))
+	s.router.Handle("GET /tasks/{id}/time-entries", s.mw.Auth(s.timeHdr.TaskTimeEntries))
+	s.router.Handle("PATCH /tasks/{id}/time-entries/{entry_id}", s.mw.Auth(s.timeHdr.UpdateTimeEntry))
+	s.router.Handle("DELETE /tasks/{id}/time-entries/{entry_id}", s.mw.Auth(s.timeHdr.DeleteTimeEntry))
+	s.router.Handle("GET /tasks/{id}/time-summary", s.mw.Auth(s.timeHdr.TaskTimeSummary))
+	s.router.Handle("POST /tasks/{id}/timer", s.mw.Auth(s.timeHdr.StartTimer))
+	s.router.Handle("GET /timer", s.mw.Auth(s.timeHdr.RunningTimer))
+	s.router.Handle("POST /timer/stop", s.mw.Auth(s.timeHdr.StopTimer))
+	s.router.Handle("GET /projects/{id}/time-summary", s.mw.Auth(s.timeHdr.ProjectTimeSummary))
+	s.router.Handle("GET /projects/{id}/time-entries/export", s.mw.Auth(s.timeHdr.ExportTimeEntries))
+	s.router.Handle("GET /users/me/time-summary", s.mw.Auth(s.timeHdr.UserTimeSummary))
+
+	// recurrence routes
+	s.router.Handle("GET /tasks/{id}/recurrence", s.mw.Auth(s.recHdr.Recurrence))
+	s.router.Handle("PUT /tasks/{id}/recurrence", s.mw.Auth(s.recHdr.SetRecurrence))
+	s.router.Handle("DELETE /tasks/{id}/recurrence", s.mw.Auth(s.recHdr.DeleteRecurrence))
+
+	// search routes
+	s.router.Handle("GET /search", s.mw.Auth(s.srchHdr.Search))
 }
 
 func (s *Server) Start() error {
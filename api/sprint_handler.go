@@ -0,0 +1,256 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+type SprintService interface {
+	CreateSprint(ctx context.Context, s entity.Sprint) (entity.Sprint, error)
+	ProjectSprints(ctx context.Context, projectID int64) ([]entity.Sprint, error)
+	Sprint(ctx context.Context, projectID int64, id int64) (entity.Sprint, error)
+	UpdateSprint(ctx context.Context, projectID int64, id int64, upd entity.SprintToUpdate) (entity.Sprint, error)
+	DeleteSprint(ctx context.Context, projectID int64, id int64) error
+	CloseSprint(ctx context.Context, projectID int64, id int64, nextSprintID *int64) (entity.CloseSprintResult, error)
+	SprintReport(ctx context.Context, projectID int64, id int64, tz string) (entity.SprintReport, error)
+	SetTaskSprint(ctx context.Context, taskID int64, sprintID *int64) (entity.Task, error)
+}
+
+type SprintHandler struct {
+	sprint SprintService
+}
+
+func NewSprintHandler(sprint SprintService) *SprintHandler {
+	return &SprintHandler{sprint: sprint}
+}
+
+func (h *SprintHandler) CreateSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var sprint entity.Sprint
+
+	err = json.NewDecoder(r.Body).Decode(&sprint)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sprint.ProjectID = projectID
+
+	sprint, err = h.sprint.CreateSprint(ctx, sprint)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, sprint)
+}
+
+// ProjectSprints returns sprints of the project ordered by start.
+func (h *SprintHandler) ProjectSprints(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	sprints, err := h.sprint.ProjectSprints(ctx, projectID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, sprints)
+}
+
+func (h *SprintHandler) Sprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, sprintID, err := sprintPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sprint, err := h.sprint.Sprint(ctx, projectID, sprintID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, sprint)
+}
+
+func (h *SprintHandler) UpdateSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, sprintID, err := sprintPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var upd entity.SprintToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sprint, err := h.sprint.UpdateSprint(ctx, projectID, sprintID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, sprint)
+}
+
+func (h *SprintHandler) DeleteSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, sprintID, err := sprintPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.sprint.DeleteSprint(ctx, projectID, sprintID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type CloseSprintRequest struct {
+	NextSprintID *int64 `json:"next_sprint_id"`
+}
+
+// CloseSprint closes the sprint, the body is optional.
+func (h *SprintHandler) CloseSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, sprintID, err := sprintPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var request CloseSprintRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		sendError(w, err)
+		return
+	}
+
+	result, err := h.sprint.CloseSprint(ctx, projectID, sprintID, request.NextSprintID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, result)
+}
+
+// SprintReport returns the sprint burndown, 'tz' overrides the user's time zone for its days.
+func (h *SprintHandler) SprintReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID, sprintID, err := sprintPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	report, err := h.sprint.SprintReport(ctx, projectID, sprintID, r.URL.Query().Get("tz"))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, report)
+}
+
+type SetTaskSprintRequest struct {
+	SprintID int64 `json:"sprint_id"`
+}
+
+func (h *SprintHandler) SetTaskSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request SetTaskSprintRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	task, err := h.sprint.SetTaskSprint(ctx, taskID, &request.SprintID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+// RemoveTaskSprint moves the task back to the backlog.
+func (h *SprintHandler) RemoveTaskSprint(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	task, err := h.sprint.SetTaskSprint(ctx, taskID, nil)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+func sprintPathValues(r *http.Request) (projectID int64, sprintID int64, err error) {
+	projectID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	sprintID, err = strconv.ParseInt(r.PathValue("sprint_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'sprint_id' must be an integer")
+	}
+
+	return projectID, sprintID, nil
+}
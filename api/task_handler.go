@@ -4,16 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"restAPI/entity"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 type TaskService interface {
 	CreateTask(ctx context.Context, cTask entity.TaskToCreate) (entity.Task, error)
 	TaskByID(ctx context.Context, id int64) (entity.Task, error)
-	ProjectTasks(ctx context.Context, projectID int64) ([]entity.Task, error)
-	UserTasks(ctx context.Context) ([]entity.Task, error)
+	ProjectTasks(ctx context.Context, projectID int64, f entity.TaskFilter) (entity.Page[entity.Task], error)
+	UserTasks(ctx context.Context, f entity.TaskFilter) (entity.Page[entity.Task], error)
+	AssignTask(ctx context.Context, id int64, assigneeID *int64) (entity.Task, error)
+	UpdateTask(ctx context.Context, id int64, upd entity.TaskToUpdate) (entity.Task, error)
+	DeleteTask(ctx context.Context, id int64, children entity.SubtaskAction) error
+	TransitionTask(ctx context.Context, taskID int64, statusID int64, override bool) (entity.Task, error)
+	MoveTask(ctx context.Context, taskID int64, statusID int64, afterID *int64, override bool) (entity.Task, error)
+	AddDependency(ctx context.Context, blockerID int64, blockedID int64) error
+	RemoveDependency(ctx context.Context, blockerID int64, blockedID int64) error
+	TaskDependencies(ctx context.Context, id int64) (entity.TaskDependencies, error)
+	TagTask(ctx context.Context, taskID int64, labelID int64) error
+	UntagTask(ctx context.Context, taskID int64, labelID int64) error
+	SetTaskParent(ctx context.Context, id int64, parentID *int64) (entity.Task, error)
+	Subtasks(ctx context.Context, id int64) ([]entity.Task, error)
+	AddChecklistItem(ctx context.Context, taskID int64, text string) (entity.ChecklistItem, error)
+	UpdateChecklistItem(ctx context.Context, taskID int64, id int64, upd entity.ChecklistItemToUpdate) (entity.ChecklistItem, error)
+	DeleteChecklistItem(ctx context.Context, taskID int64, id int64) error
 }
 
 type TaskHandler struct {
@@ -63,6 +81,450 @@ func (h *TaskHandler) TaskByID(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, task)
 }
 
+func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var upd entity.TaskToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	task, err := h.task.UpdateTask(ctx, id, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	err = h.task.DeleteTask(ctx, id, entity.SubtaskAction(r.URL.Query().Get("children")))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type SetTaskParentRequest struct {
+	ParentTaskID int64 `json:"parent_task_id"`
+}
+
+func (h *TaskHandler) SetTaskParent(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request SetTaskParentRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	task, err := h.task.SetTaskParent(ctx, id, &request.ParentTaskID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+// DetachTask makes a subtask a top-level task.
+func (h *TaskHandler) DetachTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	task, err := h.task.SetTaskParent(ctx, id, nil)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+func (h *TaskHandler) Subtasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	tasks, err := h.task.Subtasks(ctx, id)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, tasks)
+}
+
+type ChecklistItemRequest struct {
+	Text string `json:"text"`
+}
+
+func (h *TaskHandler) AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request ChecklistItemRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	item, err := h.task.AddChecklistItem(ctx, taskID, request.Text)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, item)
+}
+
+// UpdateChecklistItem accepts text and done, e.g. {"done": true} ticks the item off.
+func (h *TaskHandler) UpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, itemID, err := checklistPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var upd entity.ChecklistItemToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	item, err := h.task.UpdateChecklistItem(ctx, taskID, itemID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, item)
+}
+
+func (h *TaskHandler) DeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, itemID, err := checklistPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.task.DeleteChecklistItem(ctx, taskID, itemID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func checklistPathValues(r *http.Request) (taskID int64, itemID int64, err error) {
+	taskID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	itemID, err = strconv.ParseInt(r.PathValue("item_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'item_id' must be an integer")
+	}
+
+	return taskID, itemID, nil
+}
+
+type AssignTaskRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+func (h *TaskHandler) AssignTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request AssignTaskRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	task, err := h.task.AssignTask(ctx, id, &request.UserID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+func (h *TaskHandler) UnassignTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	task, err := h.task.AssignTask(ctx, id, nil)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+type TransitionTaskRequest struct {
+	StatusID int64 `json:"status_id"`
+	// Override allows completing a task which is still blocked by open tasks.
+	Override bool `json:"override"`
+}
+
+func (h *TaskHandler) TransitionTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request TransitionTaskRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	task, err := h.task.TransitionTask(ctx, id, request.StatusID, request.Override)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+// MoveTaskRequest puts a task into the StatusID column right after task AfterID,
+// null AfterID puts it on top and zero StatusID keeps the current column.
+type MoveTaskRequest struct {
+	StatusID int64  `json:"status_id"`
+	AfterID  *int64 `json:"after_id"`
+	Override bool   `json:"override"`
+}
+
+func (h *TaskHandler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request MoveTaskRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	task, err := h.task.MoveTask(ctx, id, request.StatusID, request.AfterID, request.Override)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, task)
+}
+
+func (h *TaskHandler) TaskDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	deps, err := h.task.TaskDependencies(ctx, id)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, deps)
+}
+
+// AddDependency declares that task {id} blocks task {blocked_id}.
+func (h *TaskHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	blockerID, blockedID, err := dependencyPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.task.AddDependency(ctx, blockerID, blockedID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *TaskHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	blockerID, blockedID, err := dependencyPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.task.RemoveDependency(ctx, blockerID, blockedID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *TaskHandler) TagTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, labelID, err := taskLabelPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.task.TagTask(ctx, taskID, labelID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *TaskHandler) UntagTask(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, labelID, err := taskLabelPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.task.UntagTask(ctx, taskID, labelID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func taskLabelPathValues(r *http.Request) (taskID int64, labelID int64, err error) {
+	taskID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	labelID, err = strconv.ParseInt(r.PathValue("label_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'label_id' must be an integer")
+	}
+
+	return taskID, labelID, nil
+}
+
+func dependencyPathValues(r *http.Request) (blockerID int64, blockedID int64, err error) {
+	blockerID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	blockedID, err = strconv.ParseInt(r.PathValue("blocked_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'blocked_id' must be an integer")
+	}
+
+	return blockerID, blockedID, nil
+}
+
 func (h *TaskHandler) ProjectTasks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	qID := r.PathValue("project_id")
@@ -73,7 +535,13 @@ func (h *TaskHandler) ProjectTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.task.ProjectTasks(ctx, projectID)
+	f, err := taskFilterParams(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	tasks, err := h.task.ProjectTasks(ctx, projectID, f)
 	if err != nil {
 		sendError(w, err)
 		return
@@ -82,10 +550,17 @@ func (h *TaskHandler) ProjectTasks(w http.ResponseWriter, r *http.Request) {
 	sendResponse(w, tasks)
 }
 
+// UserTasks returns tasks created by authenticated user, or assigned to them with 'assignee=me'.
 func (h *TaskHandler) UserTasks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	tasks, err := h.task.UserTasks(ctx)
+	f, err := taskFilterParams(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	tasks, err := h.task.UserTasks(ctx, f)
 	if err != nil {
 		sendError(w, err)
 		return
@@ -93,3 +568,112 @@ func (h *TaskHandler) UserTasks(w http.ResponseWriter, r *http.Request) {
 
 	sendResponse(w, tasks)
 }
+
+// taskFilterParams reads task list filters from query parameters in addition to listQueryParams:
+// 'due' is one of overdue, today or week, 'priority' is a comma separated list,
+// 'priority_min' keeps the priority or higher (e.g. 'high'),
+// 'tz' overrides the user's time zone for 'due', 'status' is a status name
+// 'assignee' is a user id, 'me' or 'none', 'labels' is a comma separated list of label names
+// 'labels_match' tells whether tasks need any (default) or all of them, 'sprint' is a sprint id
+// or 'none' for the backlog and 'cf.<name>' filters by a custom field (e.g. 'cf.Points=>=3').
+func taskFilterParams(r *http.Request) (entity.TaskFilter, error) {
+	query := r.URL.Query()
+
+	lq, err := listQueryParams(r)
+	if err != nil {
+		return entity.TaskFilter{}, err
+	}
+
+	f := entity.TaskFilter{
+		ListQuery: lq,
+		Due:       entity.DueWindow(query.Get("due")),
+		TimeZone:  query.Get("tz"),
+		Status:    query.Get("status"),
+	}
+
+	switch q := query.Get("assignee"); q {
+	case "":
+	case "me":
+		f.AssignedToMe = true
+	case "none":
+		f.Unassigned = true
+	default:
+		assigneeID, err := strconv.ParseInt(q, 10, 64)
+		if err != nil {
+			return entity.TaskFilter{}, fmt.Errorf("%w: 'assignee' must be a user id, 'me' or 'none'", entity.ErrBadRequest)
+		}
+
+		f.AssigneeID = &assigneeID
+	}
+
+	switch q := query.Get("sprint"); q {
+	case "":
+	case "none":
+		f.Backlog = true
+	default:
+		sprintID, err := strconv.ParseInt(q, 10, 64)
+		if err != nil {
+			return entity.TaskFilter{}, fmt.Errorf("%w: 'sprint' must be a sprint id or 'none'", entity.ErrBadRequest)
+		}
+
+		f.SprintID = &sprintID
+	}
+
+	if q := query.Get("priority"); q != "" {
+		for _, p := range strings.Split(q, ",") {
+			priority := entity.Priority(p)
+			if !priority.Valid() {
+				return entity.TaskFilter{}, fmt.Errorf("%w: unknown priority %q", entity.ErrBadRequest, p)
+			}
+
+			f.Priorities = append(f.Priorities, priority)
+		}
+	}
+
+	if q := query.Get("priority_min"); q != "" {
+		minPriority := entity.Priority(q)
+		if !minPriority.Valid() {
+			return entity.TaskFilter{}, fmt.Errorf("%w: unknown priority %q", entity.ErrBadRequest, q)
+		}
+
+		atLeast := minPriority.AtLeast()
+
+		if len(f.Priorities) > 0 {
+			atLeast = slices.DeleteFunc(atLeast, func(p entity.Priority) bool { return !slices.Contains(f.Priorities, p) })
+			if len(atLeast) == 0 {
+				return entity.TaskFilter{}, fmt.Errorf("%w: no priority matches both 'priority' and 'priority_min'", entity.ErrBadRequest)
+			}
+		}
+
+		f.Priorities = atLeast
+	}
+
+	if q := query.Get("labels"); q != "" {
+		for _, name := range strings.Split(q, ",") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				f.Labels = append(f.Labels, name)
+			}
+		}
+	}
+
+	switch f.LabelMatch = entity.LabelMatch(query.Get("labels_match")); f.LabelMatch {
+	case "":
+		f.LabelMatch = entity.LabelMatchAny
+	case entity.LabelMatchAny, entity.LabelMatchAll:
+	default:
+		return entity.TaskFilter{}, fmt.Errorf("%w: 'labels_match' must be %s or %s", entity.ErrBadRequest, entity.LabelMatchAny, entity.LabelMatchAll)
+	}
+
+	for key := range query {
+		if name, ok := strings.CutPrefix(key, "cf."); ok {
+			if f.CustomFieldQueries == nil {
+				f.CustomFieldQueries = make(map[string]string)
+			}
+
+			f.CustomFieldQueries[name] = query.Get(key)
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"restAPI/entity"
+	"testing"
+)
+
+func TestTaskFilterParams_Priority(t *testing.T) {
+	filter := func(rawQuery string) (entity.TaskFilter, error) {
+		// query strings are decoded as browsers and clients send them, '+' being a space
+		query, err := url.ParseQuery(rawQuery)
+		require.NoError(t, err)
+
+		r, err := http.NewRequest(http.MethodGet, "/tasks?"+query.Encode(), nil)
+		require.NoError(t, err)
+
+		return taskFilterParams(r)
+	}
+
+	f, err := filter("priority=low,urgent")
+	require.NoError(t, err)
+	require.Equal(t, []entity.Priority{entity.PriorityLow, entity.PriorityUrgent}, f.Priorities)
+
+	f, err = filter("priority_min=high")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []entity.Priority{entity.PriorityHigh, entity.PriorityUrgent}, f.Priorities)
+
+	f, err = filter("priority=low,high&priority_min=medium")
+	require.NoError(t, err)
+	require.Equal(t, []entity.Priority{entity.PriorityHigh}, f.Priorities)
+
+	_, err = filter("priority=low&priority_min=high")
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+
+	_, err = filter("priority_min=high+")
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+}
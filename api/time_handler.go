@@ -0,0 +1,324 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+	"strings"
+)
+
+type TimeService interface {
+	LogTime(ctx context.Context, taskID int64, c entity.TimeEntryToCreate) (entity.TimeEntry, error)
+	StartTimer(ctx context.Context, taskID int64, note string) (entity.TimeEntry, error)
+	RunningTimer(ctx context.Context) (entity.TimeEntry, error)
+	StopTimer(ctx context.Context) (entity.TimeEntry, error)
+	TaskTimeEntries(ctx context.Context, taskID int64, r entity.DateRange) ([]entity.TimeEntry, error)
+	UpdateTimeEntry(ctx context.Context, taskID int64, id int64, upd entity.TimeEntryToUpdate) (entity.TimeEntry, error)
+	DeleteTimeEntry(ctx context.Context, taskID int64, id int64) error
+	TaskTimeSummary(ctx context.Context, taskID int64, r entity.DateRange) (entity.TimeSummary, error)
+	ProjectTimeSummary(ctx context.Context, projectID int64, r entity.DateRange, group entity.TimeGroup) (entity.TimeSummary, error)
+	UserTimeSummary(ctx context.Context, r entity.DateRange, group entity.TimeGroup) (entity.TimeSummary, error)
+	ExportTimeEntries(ctx context.Context, projectID int64, r entity.DateRange, userID *int64) ([]entity.TimeEntry, error)
+}
+
+type TimeHandler struct {
+	time TimeService
+}
+
+func NewTimeHandler(time TimeService) *TimeHandler {
+	return &TimeHandler{time: time}
+}
+
+func (h *TimeHandler) LogTime(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request entity.TimeEntryToCreate
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	entry, err := h.time.LogTime(ctx, taskID, request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, entry)
+}
+
+// TaskTimeEntries returns time entries of the task by date, 'from' and 'to' optionally limit their dates.
+func (h *TimeHandler) TaskTimeEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	entries, err := h.time.TaskTimeEntries(ctx, taskID, dateRangeParams(r))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, entries)
+}
+
+func (h *TimeHandler) UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, entryID, err := timeEntryPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	var upd entity.TimeEntryToUpdate
+
+	err = json.NewDecoder(r.Body).Decode(&upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	entry, err := h.time.UpdateTimeEntry(ctx, taskID, entryID, upd)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, entry)
+}
+
+func (h *TimeHandler) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	taskID, entryID, err := timeEntryPathValues(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	err = h.time.DeleteTimeEntry(ctx, taskID, entryID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type StartTimerRequest struct {
+	Note string `json:"note"`
+}
+
+// StartTimer starts a timer on the task, the body is optional.
+func (h *TimeHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var request StartTimerRequest
+
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		sendError(w, err)
+		return
+	}
+
+	entry, err := h.time.StartTimer(ctx, taskID, request.Note)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, entry)
+}
+
+func (h *TimeHandler) RunningTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	entry, err := h.time.RunningTimer(ctx)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, entry)
+}
+
+func (h *TimeHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	entry, err := h.time.StopTimer(ctx)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, entry)
+}
+
+// TaskTimeSummary returns time logged on the task by each user between 'from' and 'to',
+// by default within the last 30 days.
+func (h *TimeHandler) TaskTimeSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	taskID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	summary, err := h.time.TaskTimeSummary(ctx, taskID, dateRangeParams(r))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, summary)
+}
+
+// ProjectTimeSummary works like TaskTimeSummary, 'group' is task (default) or user.
+func (h *TimeHandler) ProjectTimeSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	summary, err := h.time.ProjectTimeSummary(ctx, projectID, dateRangeParams(r), entity.TimeGroup(r.URL.Query().Get("group")))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, summary)
+}
+
+// UserTimeSummary works like TaskTimeSummary for time of the authenticated user, 'group' is project (default) or task.
+func (h *TimeHandler) UserTimeSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	summary, err := h.time.UserTimeSummary(ctx, dateRangeParams(r), entity.TimeGroup(r.URL.Query().Get("group")))
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, summary)
+}
+
+// ExportTimeEntries writes time logged in the project as CSV, it accepts the range of TaskTimeSummary
+// and 'user_id' to export time of a single member.
+func (h *TimeHandler) ExportTimeEntries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	projectID, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	var userID *int64
+
+	if q := r.URL.Query().Get("user_id"); q != "" {
+		id, err := strconv.ParseInt(q, 10, 64)
+		if err != nil {
+			sendError(w, errors.New("'user_id' must be an integer"))
+			return
+		}
+
+		userID = &id
+	}
+
+	entries, err := h.time.ExportTimeEntries(ctx, projectID, dateRangeParams(r), userID)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="time-entries-%d.csv"`, projectID))
+
+	cw := csv.NewWriter(w)
+
+	_ = cw.Write([]string{"date", "user_id", "user", "task_id", "task", "minutes", "hours", "note"})
+
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			e.Date,
+			strconv.FormatInt(e.UserID, 10),
+			csvText(e.UserName),
+			strconv.FormatInt(e.TaskID, 10),
+			csvText(e.TaskName),
+			strconv.Itoa(e.Minutes),
+			strconv.FormatFloat(float64(e.Minutes)/60, 'f', 2, 64),
+			csvText(e.Note),
+		})
+	}
+
+	cw.Flush()
+
+	if err = cw.Error(); err != nil {
+		log.Println(err)
+	}
+}
+
+// csvText escapes user text which spreadsheets would otherwise run as a formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+func dateRangeParams(r *http.Request) entity.DateRange {
+	query := r.URL.Query()
+
+	return entity.DateRange{From: query.Get("from"), To: query.Get("to")}
+}
+
+func timeEntryPathValues(r *http.Request) (taskID int64, entryID int64, err error) {
+	taskID, err = strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'id' must be an integer")
+	}
+
+	entryID, err = strconv.ParseInt(r.PathValue("entry_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("'entry_id' must be an integer")
+	}
+
+	return taskID, entryID, nil
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCSVText(t *testing.T) {
+	for in, out := range map[string]string{
+		"":                  "",
+		"review":            "review",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1":                "'+1",
+		"-2":                "'-2",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\tcmd":             "'\tcmd",
+		"a=b":               "a=b",
+	} {
+		require.Equal(t, out, csvText(in), in)
+	}
+}
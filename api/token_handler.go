@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restAPI/entity"
+	"strconv"
+)
+
+type TokenService interface {
+	CreateAccessToken(ctx context.Context, t entity.PersonalAccessToken) (entity.PersonalAccessToken, error)
+	AccessTokens(ctx context.Context) ([]entity.PersonalAccessToken, error)
+	RevokeAccessToken(ctx context.Context, id int64) error
+	UserByAccessToken(ctx context.Context, token string, scope string) (entity.User, error)
+}
+
+type TokenHandler struct {
+	token TokenService
+}
+
+func NewTokenHandler(token TokenService) *TokenHandler {
+	return &TokenHandler{token: token}
+}
+
+func (h *TokenHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var token entity.PersonalAccessToken
+
+	err := json.NewDecoder(r.Body).Decode(&token)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	token, err = h.token.CreateAccessToken(ctx, token)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, token)
+}
+
+func (h *TokenHandler) AccessTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	tokens, err := h.token.AccessTokens(ctx)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, tokens)
+}
+
+func (h *TokenHandler) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	qID := r.PathValue("id")
+	id, err := strconv.ParseInt(qID, 10, 64)
+	if err != nil {
+		sendError(w, errors.New("'id' must be an integer"))
+		return
+	}
+
+	err = h.token.RevokeAccessToken(ctx, id)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"restAPI/entity"
@@ -10,8 +11,9 @@ import (
 
 type UserService interface {
 	UserByID(ctx context.Context, id int64) (entity.User, error)
-	Users(ctx context.Context) ([]entity.User, error)
+	Users(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.User], error)
 	ProjectUsers(ctx context.Context, projectID int64) ([]entity.User, error)
+	SetTimeZone(ctx context.Context, timeZone string) (entity.User, error)
 
 	DeleteUser(ctx context.Context, id int64) error
 }
@@ -67,7 +69,13 @@ func (h *UserHandler) UserByID(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) Users(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	users, err := h.user.Users(ctx)
+	lq, err := listQueryParams(r)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	users, err := h.user.Users(ctx, lq)
 	if err != nil {
 		sendError(w, err)
 		return
@@ -94,3 +102,28 @@ func (h *UserHandler) ProjectUsers(w http.ResponseWriter, r *http.Request) {
 
 	sendResponse(w, users)
 }
+
+type UpdateMeRequest struct {
+	TimeZone string `json:"time_zone"`
+}
+
+// UpdateMe changes settings of the authenticated user.
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request UpdateMeRequest
+
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	user, err := h.user.SetTimeZone(ctx, request.TimeZone)
+	if err != nil {
+		sendError(w, err)
+		return
+	}
+
+	sendResponse(w, user)
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
 )
 
 type Config struct {
@@ -16,6 +17,20 @@ type Config struct {
 	HTTPPort string
 
 	RedisAddr string
+
+	// SecretKey signs links sent to users.
+	SecretKey string
+
+	// BlobStore is "local" to keep files in BlobDir or "s3" for an S3-compatible storage.
+	BlobStore   string
+	BlobDir     string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	// AttachmentQuota limits total size of files attached in one project, in bytes.
+	AttachmentQuota int64
 }
 
 func NewConfig() (*Config, error) {
@@ -24,6 +39,25 @@ func NewConfig() (*Config, error) {
 		return nil, err
 	}
 
+	quota := int64(100)
+
+	if q := os.Getenv("ATTACHMENT_QUOTA_MB"); q != "" {
+		quota, err = strconv.ParseInt(q, 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid attachment quota field")
+		}
+	}
+
+	blobStore := os.Getenv("BLOB_STORE")
+	if blobStore == "" {
+		blobStore = "local"
+	}
+
+	blobDir := os.Getenv("BLOB_DIR")
+	if blobDir == "" {
+		blobDir = "data/blobs"
+	}
+
 	return &Config{
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
@@ -34,6 +68,18 @@ func NewConfig() (*Config, error) {
 		HTTPPort: os.Getenv("HTTP_PORT"),
 
 		RedisAddr: os.Getenv("REDIS_ADDR"),
+
+		SecretKey: os.Getenv("SECRET_KEY"),
+
+		BlobStore:   blobStore,
+		BlobDir:     blobDir,
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3Region:    os.Getenv("S3_REGION"),
+		S3Bucket:    os.Getenv("S3_BUCKET"),
+		S3AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("S3_SECRET_KEY"),
+
+		AttachmentQuota: quota << 20,
 	}, nil
 }
 
@@ -75,6 +121,23 @@ func (c *Config) Validate() []error {
 		errorList = append(errorList, err)
 	}
 
+	if c.SecretKey == "" {
+		err := errors.New("invalid secret key field \n")
+		errorList = append(errorList, err)
+	}
+
+	switch c.BlobStore {
+	case "local":
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" || c.S3AccessKey == "" || c.S3SecretKey == "" {
+			err := errors.New("invalid S3 endpoint, bucket or credentials field \n")
+			errorList = append(errorList, err)
+		}
+	default:
+		err := errors.New("invalid blob store field \n")
+		errorList = append(errorList, err)
+	}
+
 	if len(errorList) != 0 {
 		return errorList
 	}
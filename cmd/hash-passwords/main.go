@@ -0,0 +1,36 @@
+// Command hash-passwords is a one-off migration which replaces passwords
+// stored as plain text with hashes made by the current algorithm.
+package main
+
+import (
+	"context"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"restAPI/bootstrap"
+	"restAPI/repository"
+	"restAPI/service"
+)
+
+func main() {
+	cfg, err := bootstrap.NewConfig()
+	if err != nil {
+		log.Fatal("Problem with config load: ", err)
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	if err != nil {
+		log.Fatal("Problem with Postgres connection: ", err)
+	}
+	defer db.Close()
+
+	passwords := service.NewPasswordHasher(service.NewArgon2id(), service.NewBcrypt(bcrypt.DefaultCost))
+
+	authServ := service.NewAuthService(repository.NewAuthRepository(db), repository.NewUserRepository(db), nil, passwords, nil)
+
+	count, err := authServ.HashPlaintextPasswords(context.Background())
+	if err != nil {
+		log.Fatal("Problem with password migration: ", err)
+	}
+
+	log.Printf("hashed %d plain text passwords", count)
+}
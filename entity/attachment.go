@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+type Attachment struct {
+	ID          int64     `json:"id"`
+	TaskID      int64     `json:"task_id"`
+	ProjectID   int64     `json:"project_id"`
+	UserID      int64     `json:"user_id"`
+	BlobKey     string    `json:"-"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	// DownloadURL is a short-lived signed link, it is only set when a single attachment is requested.
+	DownloadURL string `json:"download_url,omitempty"`
+}
@@ -0,0 +1,13 @@
+package entity
+
+// Board shows project tasks in columns of workflow statuses.
+type Board struct {
+	ProjectID int64         `json:"project_id"`
+	Columns   []BoardColumn `json:"columns"`
+}
+
+// BoardColumn holds tasks in Status ordered by rank.
+type BoardColumn struct {
+	Status Status `json:"status"`
+	Tasks  []Task `json:"tasks"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// ChecklistItem is a lightweight step of a task, lighter than a subtask.
+type ChecklistItem struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChecklistItemToUpdate holds a partial checklist item update, nil fields are left unchanged.
+type ChecklistItemToUpdate struct {
+	Text *string `json:"text"`
+	Done *bool   `json:"done"`
+}
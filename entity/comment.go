@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Comment is a message on a task. EditedAt is set once the comment is edited,
+// earlier bodies are kept as CommentVersion.
+type Comment struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	UserID    int64      `json:"user_id"`
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+}
+
+// CommentVersion is a body comment had before an edit, CreatedAt is when that body was written.
+type CommentVersion struct {
+	ID        int64     `json:"id"`
+	CommentID int64     `json:"comment_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CustomField is a typed task attribute defined by a project, names are unique within a project regardless of case.
+// Options list the allowed values of select fields.
+type CustomField struct {
+	ID        int64           `json:"id"`
+	ProjectID int64           `json:"project_id"`
+	Name      string          `json:"name"`
+	Type      CustomFieldType `json:"type"`
+	Options   []string        `json:"options"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// CustomFieldToUpdate holds a partial custom field update, nil fields are left unchanged.
+// The type of a field can't change.
+type CustomFieldToUpdate struct {
+	Name    *string   `json:"name"`
+	Options *[]string `json:"options"`
+}
+
+// CustomFieldType tells which values a field holds: text is a string, number is a JSON number,
+// date is a YYYY-MM-DD string, single_select is one of the options, multi_select is a list of them
+// and user is the id of a project member.
+type CustomFieldType string
+
+const (
+	FieldText         CustomFieldType = "text"
+	FieldNumber       CustomFieldType = "number"
+	FieldDate         CustomFieldType = "date"
+	FieldSingleSelect CustomFieldType = "single_select"
+	FieldMultiSelect  CustomFieldType = "multi_select"
+	FieldUser         CustomFieldType = "user"
+)
+
+func (t CustomFieldType) Valid() bool {
+	switch t {
+	case FieldText, FieldNumber, FieldDate, FieldSingleSelect, FieldMultiSelect, FieldUser:
+		return true
+	}
+
+	return false
+}
+
+// CustomFieldValues are values of task custom fields by field name, null removes a value.
+type CustomFieldValues map[string]json.RawMessage
+
+// CustomFieldCondition keeps tasks whose value of Field compares by Op to any of Values,
+// a multi_select value matches if it contains any of them.
+type CustomFieldCondition struct {
+	Field  CustomField
+	Op     string
+	Values []string
+}
@@ -3,7 +3,12 @@ package entity
 import "errors"
 
 var (
-	ErrNotFound     = errors.New("not found")
-	ErrUnauthorized = errors.New("unauthorized")
-	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrBadRequest      = errors.New("bad request")
+	ErrConflict        = errors.New("conflict")
+	ErrExpired         = errors.New("expired")
+	ErrTooManyRequests = errors.New("too many requests")
+	ErrTooLarge        = errors.New("too large")
 )
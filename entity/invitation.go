@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+type Invitation struct {
+	ID         int64      `json:"id"`
+	ProjectID  int64      `json:"project_id"`
+	Email      string     `json:"email"`
+	Role       Role       `json:"role"`
+	InvitedBy  int64      `json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Pending reports whether invitation can still be accepted at moment now.
+func (i Invitation) Pending(now time.Time) bool {
+	return i.AcceptedAt == nil && i.RevokedAt == nil && i.ExpiresAt.After(now)
+}
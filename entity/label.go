@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// Label tags tasks of a project, names are unique within a project regardless of case.
+type Label struct {
+	ID        int64     `json:"id"`
+	ProjectID int64     `json:"project_id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LabelToUpdate holds a partial label update, nil fields are left unchanged.
+type LabelToUpdate struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+// LabelMatch tells whether tasks need any or all of the labels a list is filtered by.
+type LabelMatch string
+
+const (
+	LabelMatchAny LabelMatch = "any"
+	LabelMatchAll LabelMatch = "all"
+)
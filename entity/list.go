@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ListQuery pages, sorts and filters a list endpoint, zero fields use defaults.
+type ListQuery struct {
+	Limit int
+	// After is the opaque cursor returned as Page.NextCursor.
+	After string
+	// Sort names a sortable field, a leading '-' sorts in descending order.
+	Sort string
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	NameContains  string
+}
+
+// Page is a single page of a list, NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -3,8 +3,44 @@ package entity
 import "time"
 
 type Project struct {
-	ID        int64     `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	UserID    int64     `json:"user_id,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          int64     `json:"id,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description"`
+	Archived    bool      `json:"archived"`
+	UserID      int64     `json:"user_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProjectToUpdate holds a partial project update, nil fields are left unchanged.
+type ProjectToUpdate struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Archived    *bool   `json:"archived"`
+}
+
+// Role is a project member's role, every role includes rights of the roles below it.
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleMember Role = "member"
+	RoleViewer Role = "viewer"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether r grants everything role grants.
+func (r Role) AtLeast(role Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[role]
 }
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Recurrence repeats task TaskID as a template by Rule, a subset of the iCalendar RRULE:
+// FREQ is DAILY, WEEKLY or MONTHLY, with optional INTERVAL, BYDAY (DAILY and WEEKLY only)
+// and either UNTIL or COUNT, e.g. "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10".
+// Occurrences keep the wall clock time of StartAt in TimeZone. NextAt is nil once the rule has ended.
+type Recurrence struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	Rule      string     `json:"rule"`
+	StartAt   time.Time  `json:"start_at"`
+	TimeZone  string     `json:"time_zone"`
+	NextAt    *time.Time `json:"next_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RecurrenceToSet replaces the recurrence of a task. Zero StartAt means the start of the task,
+// or now if it has none, empty TimeZone means the user's time zone.
+type RecurrenceToSet struct {
+	Rule     string    `json:"rule"`
+	StartAt  time.Time `json:"start_at"`
+	TimeZone string    `json:"time_zone"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+const (
+	SearchTypeTask    = "task"
+	SearchTypeProject = "project"
+)
+
+// SearchResult is a task or project matching a search query. Title and Snippet are
+// HTML-escaped with matched words wrapped in <mark> tags.
+type SearchResult struct {
+	Type      string  `json:"type"`
+	ID        int64   `json:"id"`
+	ProjectID int64   `json:"project_id"`
+	Title     string  `json:"title"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+}
+
+type SearchQuery struct {
+	Text string
+	// Type limits results to SearchTypeTask or SearchTypeProject, empty means both.
+	Type  string
+	Limit int
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     int64     `json:"-"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// AuthSessionID returns ID of the session request was authenticated with.
+func AuthSessionID(ctx context.Context) string {
+	sessionID, _ := ctx.Value("session_id").(string)
+	return sessionID
+}
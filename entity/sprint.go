@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+// Sprint is a time-boxed iteration of a project, tasks are planned into it.
+type Sprint struct {
+	ID        int64      `json:"id"`
+	ProjectID int64      `json:"project_id"`
+	Name      string     `json:"name"`
+	Goal      string     `json:"goal"`
+	StartAt   time.Time  `json:"start_at"`
+	EndAt     time.Time  `json:"end_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// SprintToUpdate holds a partial sprint update, nil fields are left unchanged.
+type SprintToUpdate struct {
+	Name    *string    `json:"name"`
+	Goal    *string    `json:"goal"`
+	StartAt *time.Time `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+// TaskChange is a state of a task recorded in its history since ChangedAt.
+type TaskChange struct {
+	TaskID    int64
+	SprintID  *int64
+	Completed bool
+	ChangedAt time.Time
+}
+
+// SprintReport compares work committed to a sprint with work completed in it, counted in tasks.
+// Committed tasks were in the sprint when it started, Added ones joined it later.
+type SprintReport struct {
+	Sprint    Sprint          `json:"sprint"`
+	Committed int             `json:"committed"`
+	Added     int             `json:"added"`
+	Completed int             `json:"completed"`
+	Remaining int             `json:"remaining"`
+	Burndown  []BurndownPoint `json:"burndown"`
+}
+
+// BurndownPoint is the number of open sprint tasks at the end of Date. Ideal falls evenly
+// from the committed tasks at the start of the sprint to zero at its end.
+type BurndownPoint struct {
+	Date      string  `json:"date"`
+	Remaining int     `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+}
+
+// CloseSprintResult tells where unfinished tasks of a closed sprint went,
+// nil NextSprintID means they went back to the backlog.
+type CloseSprintResult struct {
+	Sprint       Sprint `json:"sprint"`
+	NextSprintID *int64 `json:"next_sprint_id"`
+	RolledOver   int    `json:"rolled_over"`
+}
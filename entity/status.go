@@ -0,0 +1,60 @@
+package entity
+
+// Status is a step of a project's task workflow. Reaching a terminal status completes the task.
+type Status struct {
+	ID        int64  `json:"id"`
+	ProjectID int64  `json:"project_id"`
+	Name      string `json:"name"`
+	Position  int    `json:"position"`
+	Terminal  bool   `json:"terminal"`
+}
+
+type StatusToUpdate struct {
+	Name     *string `json:"name"`
+	Position *int    `json:"position"`
+	Terminal *bool   `json:"terminal"`
+}
+
+type StatusTransition struct {
+	FromStatusID int64 `json:"from_status_id"`
+	ToStatusID   int64 `json:"to_status_id"`
+}
+
+// Workflow is the ordered set of project statuses and the allowed moves between them.
+// Empty Transitions allow any move.
+type Workflow struct {
+	Statuses    []Status           `json:"statuses"`
+	Transitions []StatusTransition `json:"transitions"`
+}
+
+// Allows reports whether a task may move between the statuses.
+func (w Workflow) Allows(fromStatusID int64, toStatusID int64) bool {
+	if len(w.Transitions) == 0 {
+		return true
+	}
+
+	for _, t := range w.Transitions {
+		if t.FromStatusID == fromStatusID && t.ToStatusID == toStatusID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w Workflow) Status(id int64) (Status, bool) {
+	for _, s := range w.Statuses {
+		if s.ID == id {
+			return s, true
+		}
+	}
+
+	return Status{}, false
+}
+
+// DefaultStatuses are created for every new project.
+var DefaultStatuses = []Status{
+	{Name: "todo", Position: 1},
+	{Name: "in progress", Position: 2},
+	{Name: "done", Position: 3, Terminal: true},
+}
@@ -2,17 +2,182 @@ package entity
 
 import "time"
 
+// Task is created by UserID and worked on by AssigneeID.
+// Tasks created by a recurrence have RecurrenceID and OccurrenceAt set.
+// Rank orders tasks within a board column, lower ranks go first.
 type Task struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	UserID      int64     `json:"user_id"`
-	ProjectID   int64     `json:"project_id"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	UserID       int64      `json:"user_id"`
+	AssigneeID   *int64     `json:"assignee_id"`
+	ProjectID    int64      `json:"project_id"`
+	ParentTaskID *int64     `json:"parent_task_id"`
+	SprintID     *int64     `json:"sprint_id"`
+	RecurrenceID *int64     `json:"recurrence_id"`
+	OccurrenceAt *time.Time `json:"occurrence_at"`
+	StatusID     int64      `json:"status_id"`
+	Status       string     `json:"status"`
+	Rank         string     `json:"rank"`
+	Priority     Priority   `json:"priority"`
+	StartAt      *time.Time `json:"start_at"`
+	DueAt        *time.Time `json:"due_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CommentCount int        `json:"comment_count"`
+	// EstimateMinutes is optional, SpentMinutes sums time entries of the task.
+	EstimateMinutes *int `json:"estimate_minutes"`
+	SpentMinutes    int  `json:"spent_minutes"`
+	// Blocked is set while any task blocking this one is not completed.
+	Blocked      bool              `json:"blocked"`
+	Labels       []Label           `json:"labels"`
+	CustomFields CustomFieldValues `json:"custom_fields"`
+
+	// SubtaskProgress counts direct subtasks, completed ones are done.
+	SubtaskProgress   Progress `json:"subtask_progress"`
+	ChecklistProgress Progress `json:"checklist_progress"`
+	// Checklist is only set when a single task is requested.
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+}
+
+// Progress tells how many of Total parts are Done.
+type Progress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// TaskDependencies lists tasks related to a task through "blocks" links.
+type TaskDependencies struct {
+	Blocks    []Task `json:"blocks"`
+	BlockedBy []Task `json:"blocked_by"`
 }
 
+// MaxTaskDepth limits nesting of subtasks, a top-level task is at depth 1.
+const MaxTaskDepth = 5
+
+// SubtaskAction decides what happens to subtasks of a deleted task.
+type SubtaskAction string
+
+const (
+	// SubtasksCascade deletes subtasks along with their parent.
+	SubtasksCascade SubtaskAction = "cascade"
+	// SubtasksPromote moves subtasks one level up, to the parent of the deleted task.
+	SubtasksPromote SubtaskAction = "promote"
+)
+
 type TaskToCreate struct {
-	Name        string `json:"name"`
-	ProjectID   int64  `json:"project_id"`
-	Description string `json:"description"`
+	Name        string     `json:"name"`
+	ProjectID   int64      `json:"project_id"`
+	Description string     `json:"description"`
+	AssigneeID  *int64     `json:"assignee_id"`
+	Priority    Priority   `json:"priority"`
+	StartAt     *time.Time `json:"start_at"`
+	DueAt       *time.Time `json:"due_at"`
+	// ParentTaskID makes the task a subtask, the parent must be in the same project.
+	ParentTaskID    *int64            `json:"parent_task_id"`
+	EstimateMinutes *int              `json:"estimate_minutes"`
+	CustomFields    CustomFieldValues `json:"custom_fields"`
+}
+
+// TaskToUpdate holds a partial task update, nil fields are left unchanged.
+type TaskToUpdate struct {
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	Priority    *Priority  `json:"priority"`
+	StartAt     *time.Time `json:"start_at"`
+	DueAt       *time.Time `json:"due_at"`
+	// ClearStartAt and ClearDueAt remove the dates, they can't be combined with new ones.
+	ClearStartAt bool `json:"clear_start_at"`
+	ClearDueAt   bool `json:"clear_due_at"`
+	// EstimateMinutes of 0 removes the estimate.
+	EstimateMinutes *int `json:"estimate_minutes"`
+	// CustomFields are merged into the values of the task.
+	CustomFields CustomFieldValues `json:"custom_fields"`
+}
+
+// Priority of a task, tasks without one have PriorityNone.
+type Priority string
+
+const (
+	PriorityNone   Priority = "none"
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+var priorityRanks = map[Priority]int{
+	PriorityNone:   0,
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+func (p Priority) Valid() bool {
+	_, ok := priorityRanks[p]
+	return ok
+}
+
+// Rank orders priorities from PriorityNone as 0 to PriorityUrgent.
+func (p Priority) Rank() int {
+	return priorityRanks[p]
+}
+
+// AtLeast returns priorities starting from p up to the most urgent one.
+func (p Priority) AtLeast() []Priority {
+	var priorities []Priority
+
+	for priority, rank := range priorityRanks {
+		if rank >= priorityRanks[p] {
+			priorities = append(priorities, priority)
+		}
+	}
+
+	return priorities
+}
+
+// DueWindow names a range of due dates relative to the current moment.
+type DueWindow string
+
+const (
+	DueOverdue DueWindow = "overdue"
+	DueToday   DueWindow = "today"
+	DueWeek    DueWindow = "week"
+)
+
+// TaskFilter narrows task lists, zero fields don't filter.
+type TaskFilter struct {
+	ListQuery
+
+	Due        DueWindow
+	Priorities []Priority
+	Status     string
+	AssigneeID *int64
+	// AssignedToMe keeps tasks assigned to the authenticated user.
+	AssignedToMe bool
+	Unassigned   bool
+	// TimeZone overrides the user's time zone when Due is resolved.
+	TimeZone string
+
+	SprintID *int64
+	// Backlog keeps tasks which are not planned into any sprint.
+	Backlog bool
+
+	// Labels keeps tasks tagged with any or, if LabelMatch is LabelMatchAll, all of the label names.
+	Labels     []string
+	LabelMatch LabelMatch
+
+	// CustomFieldQueries are raw filters by custom field name, e.g. ">=3" for a number,
+	// they are resolved into CustomFields within a project.
+	CustomFieldQueries map[string]string
+	CustomFields       []CustomFieldCondition
+	// SortField is the custom field named by a "cf.<name>" Sort, resolved like CustomFields.
+	SortField *CustomField
+
+	// Open keeps only tasks that are not completed.
+	Open      bool
+	DueAfter  *time.Time
+	DueBefore *time.Time
 }
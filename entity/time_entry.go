@@ -0,0 +1,74 @@
+package entity
+
+import "time"
+
+// TimeEntry is time UserID spent on a task on Date, in the YYYY-MM-DD format.
+// Entries logged by a timer have StartedAt set, Minutes stays zero while the timer is Running.
+type TimeEntry struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	TaskName  string     `json:"task_name"`
+	UserID    int64      `json:"user_id"`
+	UserName  string     `json:"user_name"`
+	Date      string     `json:"date"`
+	Minutes   int        `json:"minutes"`
+	Note      string     `json:"note"`
+	StartedAt *time.Time `json:"started_at"`
+	Running   bool       `json:"running"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TimeEntryToCreate is time logged by hand, empty Date means today in the user's time zone.
+type TimeEntryToCreate struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+	Note    string `json:"note"`
+}
+
+// TimeEntryToUpdate holds a partial time entry update, nil fields are left unchanged.
+type TimeEntryToUpdate struct {
+	Date    *string `json:"date"`
+	Minutes *int    `json:"minutes"`
+	Note    *string `json:"note"`
+}
+
+// DateRange includes both From and To, in the YYYY-MM-DD format.
+type DateRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// TimeFilter narrows time entries, nil fields don't filter.
+type TimeFilter struct {
+	DateRange
+
+	ProjectID *int64
+	TaskID    *int64
+	UserID    *int64
+}
+
+// TimeGroup names what time summaries are broken down by.
+type TimeGroup string
+
+const (
+	TimeByTask    TimeGroup = "task"
+	TimeByUser    TimeGroup = "user"
+	TimeByProject TimeGroup = "project"
+)
+
+// TimeSummary is the time logged within a date range, broken down by Group.
+// Running timers are not counted.
+type TimeSummary struct {
+	DateRange
+
+	Group   TimeGroup   `json:"group"`
+	Minutes int         `json:"minutes"`
+	Totals  []TimeTotal `json:"totals"`
+}
+
+// TimeTotal is the time logged on a task, by a user or in a project, depending on the group.
+type TimeTotal struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Minutes int    `json:"minutes"`
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	ScopeProjectsRead  = "projects:read"
+	ScopeProjectsWrite = "projects:write"
+	ScopeTasksRead     = "tasks:read"
+	ScopeTasksWrite    = "tasks:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeSearchRead    = "search:read"
+)
+
+// Scopes lists every scope which can be granted to a personal access token.
+var Scopes = []string{
+	ScopeProjectsRead, ScopeProjectsWrite,
+	ScopeTasksRead, ScopeTasksWrite,
+	ScopeUsersRead, ScopeUsersWrite,
+	ScopeSearchRead,
+}
+
+type PersonalAccessToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// Token is the secret itself, it is only shown once right after creation.
+	Token string `json:"token,omitempty"`
+}
+
+// Allows reports whether token grants scope. Token without scopes grants all of them.
+func (t PersonalAccessToken) Allows(scope string) bool {
+	if !slices.Contains(Scopes, scope) {
+		return false
+	}
+
+	return len(t.Scopes) == 0 || slices.Contains(t.Scopes, scope)
+}
@@ -12,6 +12,7 @@ type User struct {
 	Email      string    `json:"email"`
 	CreatedAt  time.Time `json:"created_at"`
 	IsVerified bool      `json:"is_verified"`
+	TimeZone   string    `json:"time_zone"`
 }
 
 func AuthUser(ctx context.Context) User {
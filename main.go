@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"golang.org/x/crypto/bcrypt"
 	"log"
 	"restAPI/api"
 	"restAPI/bootstrap"
 	"restAPI/repository"
 	"restAPI/service"
+	"time"
+	_ "time/tzdata"
 )
 
 func main() {
@@ -39,6 +43,30 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	authRepo := repository.NewAuthRepository(db)
 	taskRepo := repository.NewTaskRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	invRepo := repository.NewInvitationRepository(db)
+	statusRepo := repository.NewStatusRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	checklistRepo := repository.NewChecklistRepository(db)
+	labelRepo := repository.NewLabelRepository(db)
+	customFieldRepo := repository.NewCustomFieldRepository(db)
+	sprintRepo := repository.NewSprintRepository(db)
+	timeRepo := repository.NewTimeEntryRepository(db)
+	recurrenceRepo := repository.NewRecurrenceRepository(db)
+
+	var blobs service.BlobStore
+
+	switch cfg.BlobStore {
+	case "s3":
+		blobs, err = repository.NewS3BlobStore(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey)
+		if err != nil {
+			log.Fatal("Problem with S3 blob store: ", err)
+		}
+	default:
+		blobs = repository.NewLocalBlobStore(cfg.BlobDir)
+	}
 
 	client, err := bootstrap.RedisConnect(cfg.RedisAddr)
 	if err != nil {
@@ -48,18 +76,42 @@ func main() {
 
 	cache := repository.NewRedisCache(userRepo, client)
 
+	passwords := service.NewPasswordHasher(service.NewArgon2id(), service.NewBcrypt(bcrypt.DefaultCost))
+
+	mailer := service.NewMailer(kafkaConn)
+
 	userServ := service.NewUserService(cache, authRepo, projRepo)
-	authServ := service.NewAuthService(authRepo, userRepo, kafkaConn)
-	projServ := service.NewProjectRepository(projRepo, taskRepo)
+	invServ := service.NewInvitationService(invRepo, projRepo, mailer, cfg.SecretKey)
+	authServ := service.NewAuthService(authRepo, userRepo, mailer, passwords, invServ)
+	projServ := service.NewProjectRepository(projRepo, taskRepo, statusRepo, checklistRepo, labelRepo, customFieldRepo)
+	tokenServ := service.NewTokenService(tokenRepo)
+	searchServ := service.NewSearchService(searchRepo)
+	commentServ := service.NewCommentService(commentRepo, taskRepo, projRepo)
+	attachmentServ := service.NewAttachmentService(attachmentRepo, taskRepo, projRepo, blobs, cfg.SecretKey, cfg.AttachmentQuota)
+	sprintServ := service.NewSprintService(sprintRepo, taskRepo, projRepo)
+	timeServ := service.NewTimeService(timeRepo, taskRepo, projRepo)
+	recurrenceServ := service.NewRecurrenceService(recurrenceRepo, taskRepo, projRepo)
 
 	taskHandler := api.NewTaskHandler(projServ)
 	projectHandler := api.NewProjectHandler(projServ)
 	userHandler := api.NewUserHandler(userServ)
 	authHandler := api.NewAuthHandler(authServ)
+	tokenHandler := api.NewTokenHandler(tokenServ)
+	invHandler := api.NewInvitationHandler(invServ)
+	searchHandler := api.NewSearchHandler(searchServ)
+	commentHandler := api.NewCommentHandler(commentServ)
+	attachmentHandler := api.NewAttachmentHandler(attachmentServ)
+	sprintHandler := api.NewSprintHandler(sprintServ)
+	timeHandler := api.NewTimeHandler(timeServ)
+	recurrenceHandler := api.NewRecurrenceHandler(recurrenceServ)
+
+	go authServ.SweepSessions(context.Background(), time.Hour)
+	go attachmentServ.SweepBlobs(context.Background(), 10*time.Minute)
+	go recurrenceServ.RunScheduler(context.Background(), time.Minute)
 
-	mw := api.NewMiddleware(authServ)
+	mw := api.NewMiddleware(authServ, tokenServ)
 
-	server := api.NewServer(taskHandler, projectHandler, userHandler, authHandler, cfg.HTTPPort, mw)
+	server := api.NewServer(taskHandler, projectHandler, userHandler, authHandler, tokenHandler, invHandler, searchHandler, commentHandler, attachmentHandler, sprintHandler, timeHandler, recurrenceHandler, cfg.HTTPPort, mw)
 
 	err = server.Start()
 	if err != nil {
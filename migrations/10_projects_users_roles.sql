@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE projects_users ADD COLUMN role TEXT NOT NULL DEFAULT 'member'
+    CHECK (role IN ('owner', 'admin', 'member', 'viewer'));
+
+INSERT INTO projects_users(project_id, user_id, role)
+SELECT id, user_id, 'owner' FROM projects
+ON CONFLICT (project_id, user_id) DO UPDATE SET role = 'owner';
+
+-- +goose Down
+ALTER TABLE projects_users DROP COLUMN role;
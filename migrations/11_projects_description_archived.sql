@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE projects ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN archived;
+ALTER TABLE projects DROP COLUMN description;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE project_invitations(
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('admin', 'member', 'viewer')),
+    invited_by BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL,
+    expires_at timestamptz NOT NULL,
+    accepted_at timestamptz,
+    revoked_at timestamptz
+);
+
+CREATE UNIQUE INDEX project_invitations_pending_idx ON project_invitations(project_id, email)
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- +goose Down
+DROP TABLE project_invitations;
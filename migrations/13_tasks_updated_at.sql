@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN updated_at timestamptz;
+UPDATE tasks SET updated_at = created_at;
+ALTER TABLE tasks ALTER COLUMN updated_at SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN updated_at;
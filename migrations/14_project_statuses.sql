@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE project_statuses(
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INT NOT NULL,
+    is_terminal BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (project_id, name)
+);
+
+-- a project without transitions allows moving tasks between any statuses
+CREATE TABLE project_status_transitions(
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    from_status_id BIGINT NOT NULL REFERENCES project_statuses(id) ON DELETE CASCADE,
+    to_status_id BIGINT NOT NULL REFERENCES project_statuses(id) ON DELETE CASCADE,
+    PRIMARY KEY (from_status_id, to_status_id)
+);
+
+INSERT INTO project_statuses(project_id, name, position, is_terminal)
+SELECT p.id, s.name, s.position, s.is_terminal
+FROM projects p
+    CROSS JOIN (VALUES ('todo', 1, FALSE), ('in progress', 2, FALSE), ('done', 3, TRUE)) AS s(name, position, is_terminal);
+
+ALTER TABLE tasks ADD COLUMN status_id BIGINT REFERENCES project_statuses(id);
+ALTER TABLE tasks ADD COLUMN completed_at timestamptz;
+
+UPDATE tasks t SET status_id = s.id FROM project_statuses s WHERE s.project_id = t.project_id AND s.name = 'todo';
+
+ALTER TABLE tasks ALTER COLUMN status_id SET NOT NULL;
+
+CREATE INDEX tasks_status_id_idx ON tasks(status_id);
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN completed_at;
+ALTER TABLE tasks DROP COLUMN status_id;
+DROP TABLE project_status_transitions;
+DROP TABLE project_statuses;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN assignee_id BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+-- until now the creator was the only person a task belonged to
+UPDATE tasks SET assignee_id = user_id;
+
+CREATE INDEX tasks_assignee_id_idx ON tasks(assignee_id);
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN assignee_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN start_at timestamptz;
+ALTER TABLE tasks ADD COLUMN due_at timestamptz;
+ALTER TABLE tasks ADD COLUMN priority TEXT NOT NULL DEFAULT 'none'
+    CHECK (priority IN ('none', 'low', 'medium', 'high', 'urgent'));
+
+CREATE INDEX tasks_due_at_idx ON tasks(due_at) WHERE completed_at IS NULL;
+
+ALTER TABLE users ADD COLUMN time_zone TEXT NOT NULL DEFAULT 'UTC';
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN time_zone;
+ALTER TABLE tasks DROP COLUMN priority;
+ALTER TABLE tasks DROP COLUMN due_at;
+ALTER TABLE tasks DROP COLUMN start_at;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', description), 'B')
+) STORED;
+
+ALTER TABLE projects ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', description), 'B')
+) STORED;
+
+CREATE INDEX tasks_search_vector_idx ON tasks USING GIN (search_vector);
+CREATE INDEX projects_search_vector_idx ON projects USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX projects_search_vector_idx;
+DROP INDEX tasks_search_vector_idx;
+ALTER TABLE projects DROP COLUMN search_vector;
+ALTER TABLE tasks DROP COLUMN search_vector;
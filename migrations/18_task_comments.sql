@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE task_comments(
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at timestamptz NOT NULL,
+    edited_at timestamptz
+);
+
+CREATE INDEX task_comments_task_id_idx ON task_comments(task_id, id);
+
+-- previous bodies of edited comments
+CREATE TABLE task_comment_versions(
+    id BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX task_comment_versions_comment_id_idx ON task_comment_versions(comment_id);
+
+-- +goose Down
+DROP TABLE task_comment_versions;
+DROP TABLE task_comments;
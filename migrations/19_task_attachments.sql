@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE task_attachments(
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blob_key TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX task_attachments_task_id_idx ON task_attachments(task_id);
+CREATE INDEX task_attachments_project_id_idx ON task_attachments(project_id);
+
+-- blobs of deleted attachments, removed from the blob store in the background
+-- so that deletes cascading from tasks and projects clean up storage too
+CREATE TABLE orphaned_blobs(
+    blob_key TEXT PRIMARY KEY,
+    orphaned_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION orphan_attachment_blob() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO orphaned_blobs(blob_key) VALUES (OLD.blob_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER task_attachments_orphan_blob AFTER DELETE ON task_attachments
+    FOR EACH ROW EXECUTE FUNCTION orphan_attachment_blob();
+
+-- +goose Down
+DROP TRIGGER task_attachments_orphan_blob ON task_attachments;
+DROP FUNCTION orphan_attachment_blob();
+DROP TABLE orphaned_blobs;
+DROP TABLE task_attachments;
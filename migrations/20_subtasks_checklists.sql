@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN parent_task_id BIGINT REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE INDEX tasks_parent_task_id_idx ON tasks(parent_task_id);
+
+CREATE TABLE task_checklist_items(
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT false,
+    position INT NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX task_checklist_items_task_id_idx ON task_checklist_items(task_id, position);
+
+-- +goose Down
+DROP TABLE task_checklist_items;
+ALTER TABLE tasks DROP COLUMN parent_task_id;
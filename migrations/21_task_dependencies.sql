@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE task_dependencies(
+    blocker_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocked_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at timestamptz NOT NULL,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX task_dependencies_blocked_id_idx ON task_dependencies(blocked_id);
+
+-- +goose Down
+DROP TABLE task_dependencies;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE labels(
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL,
+    created_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX labels_project_id_name_idx ON labels(project_id, lower(name));
+
+CREATE TABLE task_labels(
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id BIGINT NOT NULL REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX task_labels_label_id_idx ON task_labels(label_id);
+
+-- +goose Down
+DROP TABLE task_labels;
+DROP TABLE labels;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- rank orders tasks within a status column, keys are compared byte by byte
+ALTER TABLE tasks ADD COLUMN rank TEXT COLLATE "C";
+
+-- keys must not end with '0', the lowest rank digit
+UPDATE tasks t SET rank = r.rank
+FROM (
+    SELECT id, lpad(row_number() OVER (PARTITION BY status_id ORDER BY created_at, id)::text, 10, '0') || 'V' AS rank
+    FROM tasks
+) r
+WHERE r.id = t.id;
+
+ALTER TABLE tasks ALTER COLUMN rank SET NOT NULL;
+
+CREATE INDEX tasks_status_id_rank_idx ON tasks(status_id, rank);
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN rank;
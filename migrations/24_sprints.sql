@@ -0,0 +1,66 @@
+-- +goose Up
+CREATE TABLE sprints(
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    goal TEXT NOT NULL DEFAULT '',
+    start_at timestamptz NOT NULL,
+    end_at timestamptz NOT NULL,
+    closed_at timestamptz,
+    created_at timestamptz NOT NULL,
+    CHECK (end_at > start_at)
+);
+
+CREATE INDEX sprints_project_id_idx ON sprints(project_id, start_at);
+
+ALTER TABLE tasks ADD COLUMN sprint_id BIGINT REFERENCES sprints(id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_sprint_id_idx ON tasks(sprint_id);
+
+-- task_history keeps every change of task status and sprint, burndown charts are computed from it
+CREATE TABLE task_history(
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    status_id BIGINT NOT NULL,
+    sprint_id BIGINT,
+    completed BOOLEAN NOT NULL,
+    changed_at timestamptz NOT NULL
+);
+
+CREATE INDEX task_history_task_id_idx ON task_history(task_id, changed_at);
+CREATE INDEX task_history_sprint_id_idx ON task_history(sprint_id);
+
+-- existing tasks were created open and completed later
+INSERT INTO task_history(task_id, status_id, completed, changed_at)
+SELECT id, status_id, false, created_at FROM tasks;
+
+INSERT INTO task_history(task_id, status_id, completed, changed_at)
+SELECT id, status_id, true, completed_at FROM tasks WHERE completed_at IS NOT NULL;
+
+-- +goose StatementBegin
+CREATE FUNCTION record_task_history() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_history(task_id, status_id, sprint_id, completed, changed_at)
+    VALUES (NEW.id, NEW.status_id, NEW.sprint_id, NEW.completed_at IS NOT NULL, NEW.updated_at);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER tasks_history_insert AFTER INSERT ON tasks
+    FOR EACH ROW EXECUTE FUNCTION record_task_history();
+
+CREATE TRIGGER tasks_history_update AFTER UPDATE ON tasks
+    FOR EACH ROW
+    WHEN (OLD.status_id IS DISTINCT FROM NEW.status_id
+        OR OLD.completed_at IS DISTINCT FROM NEW.completed_at
+        OR OLD.sprint_id IS DISTINCT FROM NEW.sprint_id)
+    EXECUTE FUNCTION record_task_history();
+
+-- +goose Down
+DROP TRIGGER tasks_history_update ON tasks;
+DROP TRIGGER tasks_history_insert ON tasks;
+DROP FUNCTION record_task_history();
+DROP TABLE task_history;
+ALTER TABLE tasks DROP COLUMN sprint_id;
+DROP TABLE sprints;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN estimate_minutes INT CHECK (estimate_minutes > 0);
+
+-- time_entries are logged by hand with minutes set, or by a timer which sets minutes when it stops
+CREATE TABLE time_entries(
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    spent_on DATE NOT NULL,
+    minutes INT CHECK (minutes > 0),
+    note TEXT NOT NULL DEFAULT '',
+    started_at timestamptz,
+    created_at timestamptz NOT NULL,
+    CHECK (minutes IS NOT NULL OR started_at IS NOT NULL)
+);
+
+-- a user has at most one running timer
+CREATE UNIQUE INDEX time_entries_running_timer_idx ON time_entries(user_id) WHERE minutes IS NULL;
+
+CREATE INDEX time_entries_task_id_idx ON time_entries(task_id, spent_on);
+CREATE INDEX time_entries_user_id_idx ON time_entries(user_id, spent_on);
+
+-- +goose Down
+DROP TABLE time_entries;
+ALTER TABLE tasks DROP COLUMN estimate_minutes;
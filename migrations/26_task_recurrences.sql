@@ -0,0 +1,26 @@
+-- +goose Up
+-- task_recurrences repeat their template task, next_at is the next occurrence to create
+-- or NULL once the rule has ended. locked_until leases the row to one scheduler.
+CREATE TABLE task_recurrences(
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL UNIQUE REFERENCES tasks(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL,
+    start_at timestamptz NOT NULL,
+    time_zone TEXT NOT NULL,
+    next_at timestamptz,
+    locked_until timestamptz,
+    created_at timestamptz NOT NULL
+);
+
+CREATE INDEX task_recurrences_next_at_idx ON task_recurrences(next_at) WHERE next_at IS NOT NULL;
+
+ALTER TABLE tasks ADD COLUMN recurrence_id BIGINT REFERENCES task_recurrences(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN occurrence_at timestamptz;
+
+-- each occurrence is created once, even by concurrent schedulers
+CREATE UNIQUE INDEX tasks_recurrence_occurrence_idx ON tasks(recurrence_id, occurrence_at);
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN occurrence_at;
+ALTER TABLE tasks DROP COLUMN recurrence_id;
+DROP TABLE task_recurrences;
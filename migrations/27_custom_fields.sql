@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE custom_fields(
+    id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('text', 'number', 'date', 'single_select', 'multi_select', 'user')),
+    options TEXT[] NOT NULL DEFAULT '{}',
+    created_at timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX custom_fields_project_id_name_idx ON custom_fields(project_id, lower(name));
+
+-- custom_fields of a task are keyed by field id, so renaming a field keeps the values
+ALTER TABLE tasks ADD COLUMN custom_fields jsonb NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN custom_fields;
+DROP TABLE custom_fields;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN last_seen_at timestamptz;
+ALTER TABLE sessions ADD COLUMN expires_at timestamptz;
+ALTER TABLE sessions ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+
+UPDATE sessions SET last_seen_at = created_at, expires_at = created_at + INTERVAL '24 hours';
+
+ALTER TABLE sessions ALTER COLUMN last_seen_at SET NOT NULL;
+ALTER TABLE sessions ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX sessions_user_id_idx ON sessions(user_id);
+CREATE INDEX sessions_expires_at_idx ON sessions(expires_at);
+
+-- +goose Down
+DROP INDEX sessions_expires_at_idx;
+DROP INDEX sessions_user_id_idx;
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions DROP COLUMN ip;
+ALTER TABLE sessions DROP COLUMN expires_at;
+ALTER TABLE sessions DROP COLUMN last_seen_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE verification_codes ADD COLUMN created_at timestamptz NOT NULL DEFAULT now();
+ALTER TABLE verification_codes ADD COLUMN expires_at timestamptz NOT NULL DEFAULT now() + INTERVAL '24 hours';
+ALTER TABLE verification_codes ADD COLUMN used_at timestamptz;
+
+ALTER TABLE verification_codes ALTER COLUMN created_at DROP DEFAULT;
+ALTER TABLE verification_codes ALTER COLUMN expires_at DROP DEFAULT;
+
+-- +goose Down
+ALTER TABLE verification_codes DROP COLUMN used_at;
+ALTER TABLE verification_codes DROP COLUMN expires_at;
+ALTER TABLE verification_codes DROP COLUMN created_at;
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"restAPI/entity"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+const attachmentColumns = "id, task_id, project_id, user_id, blob_key, name, content_type, size, created_at"
+
+func scanAttachment(row scanner) (a entity.Attachment, err error) {
+	err = row.Scan(&a.ID, &a.TaskID, &a.ProjectID, &a.UserID, &a.BlobKey, &a.Name, &a.ContentType, &a.Size, &a.CreatedAt)
+	return a, err
+}
+
+// CreateAttachment stores attachment unless it would bring the project over quota bytes.
+// The project row is locked, so concurrent uploads can't exceed the quota together.
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, a entity.Attachment, quota int64) (entity.Attachment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "SELECT 1 FROM projects WHERE id = $1 FOR UPDATE", a.ProjectID)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	var used int64
+
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(size), 0) FROM task_attachments WHERE project_id = $1", a.ProjectID).Scan(&used)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	if used+a.Size > quota {
+		return entity.Attachment{}, fmt.Errorf("%w: project storage quota of %d bytes exceeded", entity.ErrTooLarge, quota)
+	}
+
+	q := "INSERT INTO task_attachments(task_id, project_id, user_id, blob_key, name, content_type, size, created_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id"
+
+	err = tx.QueryRowContext(ctx, q, a.TaskID, a.ProjectID, a.UserID, a.BlobKey, a.Name, a.ContentType, a.Size, a.CreatedAt).Scan(&a.ID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.Attachment{}, fmt.Errorf("%w: task", entity.ErrNotFound)
+		}
+
+		return entity.Attachment{}, err
+	}
+
+	return a, tx.Commit()
+}
+
+// ProjectStorage returns the total size of project attachments in bytes.
+func (r *AttachmentRepository) ProjectStorage(ctx context.Context, projectID int64) (used int64, err error) {
+	q := "SELECT COALESCE(SUM(size), 0) FROM task_attachments WHERE project_id = $1"
+
+	err = r.db.QueryRowContext(ctx, q, projectID).Scan(&used)
+	if err != nil {
+		return 0, err
+	}
+
+	return used, nil
+}
+
+func (r *AttachmentRepository) AttachmentByID(ctx context.Context, id int64) (a entity.Attachment, err error) {
+	q := "SELECT " + attachmentColumns + " FROM task_attachments WHERE id = $1"
+
+	a, err = scanAttachment(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Attachment{}, entity.ErrNotFound
+		}
+
+		return a, err
+	}
+
+	return a, nil
+}
+
+func (r *AttachmentRepository) TaskAttachments(ctx context.Context, taskID int64) (attachments []entity.Attachment, err error) {
+	q := "SELECT " + attachmentColumns + " FROM task_attachments WHERE task_id = $1 ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, q, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	return attachments, nil
+}
+
+// DeleteAttachment deletes attachment row, its blob is queued for removal by a trigger.
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, id int64) error {
+	q := "DELETE FROM task_attachments WHERE id = $1"
+
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return nil
+}
+
+// OrphanedBlobs returns up to limit keys of blobs which no attachment refers to anymore.
+func (r *AttachmentRepository) OrphanedBlobs(ctx context.Context, limit int) (keys []string, err error) {
+	q := "SELECT blob_key FROM orphaned_blobs ORDER BY orphaned_at LIMIT $1"
+
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+
+		err = rows.Scan(&key)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// ForgetBlobs removes keys from the orphaned blobs once they are deleted from the blob store.
+func (r *AttachmentRepository) ForgetBlobs(ctx context.Context, keys []string) error {
+	q := "DELETE FROM orphaned_blobs WHERE blob_key = ANY($1)"
+
+	_, err := r.db.ExecContext(ctx, q, pq.Array(keys))
+	return err
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"github.com/google/uuid"
 	"restAPI/entity"
 	"time"
 )
@@ -17,10 +16,11 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 	return &AuthRepository{db: db}
 }
 
-func (r *AuthRepository) UserByEmailAndPassword(ctx context.Context, email string, password string) (u entity.User, err error) {
-	q := "SELECT id, name, email, created_at, is_verified FROM users WHERE email = $1 AND password = $2"
+// UserCredentials returns user by email together with the stored password hash.
+func (r *AuthRepository) UserCredentials(ctx context.Context, email string) (u entity.User, err error) {
+	q := "SELECT id, name, password, email, created_at, is_verified, time_zone FROM users WHERE email = $1"
 
-	err = r.db.QueryRowContext(ctx, q, email, password).Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.IsVerified)
+	err = r.db.QueryRowContext(ctx, q, email).Scan(&u.ID, &u.Name, &u.Password, &u.Email, &u.CreatedAt, &u.IsVerified, &u.TimeZone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.User{}, entity.ErrNotFound
@@ -32,10 +32,45 @@ func (r *AuthRepository) UserByEmailAndPassword(ctx context.Context, email strin
 	return u, nil
 }
 
-func (r *AuthRepository) CreateSession(ctx context.Context, sessionID uuid.UUID, userID int64, createdAt time.Time) error {
-	q := "INSERT INTO sessions(id, user_id, created_at) VALUES($1, $2, $3)"
+// ReplacePassword swaps the stored password of a user only if it still equals oldPassword,
+// so concurrent rehashes never overwrite a password changed in the meantime.
+func (r *AuthRepository) ReplacePassword(ctx context.Context, userID int64, oldPassword string, newPassword string) error {
+	q := "UPDATE users SET password = $3 WHERE id = $1 AND password = $2"
 
-	_, err := r.db.ExecContext(ctx, q, sessionID, userID, createdAt)
+	_, err := r.db.ExecContext(ctx, q, userID, oldPassword, newPassword)
+	return err
+}
+
+// UserPasswords returns id and stored password of every user.
+func (r *AuthRepository) UserPasswords(ctx context.Context) (users []entity.User, err error) {
+	q := "SELECT id, password FROM users"
+
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user entity.User
+
+		err = rows.Scan(&user.ID, &user.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *AuthRepository) CreateSession(ctx context.Context, session entity.Session) error {
+	q := `INSERT INTO sessions(id, user_id, ip, user_agent, created_at, last_seen_at, expires_at)
+	VALUES($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := r.db.ExecContext(ctx, q, session.ID, session.UserID, session.IP, session.UserAgent,
+		session.CreatedAt, session.LastSeenAt, session.ExpiresAt)
 	if err != nil {
 		return err
 	}
@@ -43,40 +78,238 @@ func (r *AuthRepository) CreateSession(ctx context.Context, sessionID uuid.UUID,
 	return nil
 }
 
-func (r *AuthRepository) UserBySessionID(ctx context.Context, sessionID string) (u entity.User, err error) {
-	q := "SELECT u.id, u.email, u.name, u.created_at, u.is_verified FROM users u JOIN sessions s ON u.id = s.user_id WHERE s.id = $1"
+// TouchSession returns owner of a not yet expired session and slides its expiry:
+// a session lives idleTTL since the last request, but no longer than maxTTL since creation.
+func (r *AuthRepository) TouchSession(ctx context.Context, sessionID string, seenAt time.Time, idleTTL time.Duration, maxTTL time.Duration) (u entity.User, s entity.Session, err error) {
+	q := `WITH s AS (
+		UPDATE sessions
+		SET last_seen_at = $2,
+		    expires_at = LEAST($2::timestamptz + make_interval(secs => $3), created_at + make_interval(secs => $4))
+		WHERE id = $1 AND expires_at > $2
+		RETURNING id, user_id, ip, user_agent, created_at, last_seen_at, expires_at
+	)
+	SELECT u.id, u.email, u.name, u.created_at, u.is_verified, u.time_zone,
+	       s.id, s.user_id, s.ip, s.user_agent, s.created_at, s.last_seen_at, s.expires_at
+	FROM users u JOIN s ON u.id = s.user_id`
 
-	err = r.db.QueryRowContext(ctx, q, sessionID).Scan(&u.ID, &u.Email, &u.Name, &u.CreatedAt, &u.IsVerified)
+	err = r.db.QueryRowContext(ctx, q, sessionID, seenAt, idleTTL.Seconds(), maxTTL.Seconds()).Scan(
+		&u.ID, &u.Email, &u.Name, &u.CreatedAt, &u.IsVerified, &u.TimeZone,
+		&s.ID, &s.UserID, &s.IP, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return entity.User{}, entity.ErrNotFound
+			return entity.User{}, entity.Session{}, entity.ErrNotFound
 		}
 
-		return u, err
+		return u, s, err
 	}
 
-	return u, nil
+	return u, s, nil
+}
+
+func (r *AuthRepository) UserSessions(ctx context.Context, userID int64, now time.Time) (sessions []entity.Session, err error) {
+	q := `SELECT id, user_id, ip, user_agent, created_at, last_seen_at, expires_at
+	FROM sessions
+	WHERE user_id = $1 AND expires_at > $2
+	ORDER BY last_seen_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, q, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s entity.Session
+
+		err = rows.Scan(&s.ID, &s.UserID, &s.IP, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, nil
+}
+
+func (r *AuthRepository) DeleteSession(ctx context.Context, sessionID string, userID int64) error {
+	q := "DELETE FROM sessions WHERE id = $1 AND user_id = $2"
+
+	res, err := r.db.ExecContext(ctx, q, sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteUserSessions deletes every session of a user except keepID, pass empty keepID to delete all.
+func (r *AuthRepository) DeleteUserSessions(ctx context.Context, userID int64, keepID string) error {
+	q := "DELETE FROM sessions WHERE user_id = $1 AND id::text <> $2"
+
+	_, err := r.db.ExecContext(ctx, q, userID, keepID)
+	return err
+}
+
+func (r *AuthRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error) {
+	q := "DELETE FROM sessions WHERE expires_at <= $1"
+
+	res, err := r.db.ExecContext(ctx, q, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
 }
 
-func (r *AuthRepository) SaveVerificationCode(ctx context.Context, code string, userID int64) error {
-	q := "INSERT INTO verification_codes(code, user_id) VALUES ($1, $2)"
+func (r *AuthRepository) SaveVerificationCode(ctx context.Context, code string, userID int64, createdAt time.Time, expiresAt time.Time) error {
+	q := "INSERT INTO verification_codes(code, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)"
 
-	_, err := r.db.ExecContext(ctx, q, code, userID)
+	_, err := r.db.ExecContext(ctx, q, code, userID, createdAt, expiresAt)
 	return err
 }
 
-func (r *AuthRepository) VerifyUser(ctx context.Context, code string) error {
-	q := "SELECT user_id FROM verification_codes WHERE code = $1 "
+// ReplaceVerificationCode issues a new code for the user unless the previous one
+// was created after throttleSince, in which case entity.ErrTooManyRequests is returned.
+func (r *AuthRepository) ReplaceVerificationCode(ctx context.Context, code string, userID int64, createdAt time.Time, expiresAt time.Time, throttleSince time.Time) error {
+	q := `INSERT INTO verification_codes(code, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id) DO UPDATE
+	SET code = EXCLUDED.code, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at, used_at = NULL
+	WHERE verification_codes.created_at <= $5`
+
+	res, err := r.db.ExecContext(ctx, q, code, userID, createdAt, expiresAt, throttleSince)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrTooManyRequests
+	}
+
+	return nil
+}
+
+// VerifyUser consumes verification code and marks its user verified.
+// Using a code of an already verified user is a no-op.
+func (r *AuthRepository) VerifyUser(ctx context.Context, code string, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := `SELECT vc.user_id, vc.expires_at, vc.used_at IS NOT NULL, u.is_verified
+	FROM verification_codes vc
+	    JOIN users u ON u.id = vc.user_id
+	WHERE vc.code = $1
+	FOR UPDATE`
+
+	var (
+		userID     int64
+		expiresAt  time.Time
+		used       bool
+		isVerified bool
+	)
+
+	err = tx.QueryRowContext(ctx, q, code).Scan(&userID, &expiresAt, &used, &isVerified)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ErrNotFound
+		}
+
+		return err
+	}
+
+	if used || isVerified {
+		return nil
+	}
+
+	if !expiresAt.After(now) {
+		return entity.ErrExpired
+	}
 
-	var id int64
+	q = "UPDATE verification_codes SET used_at = $2 WHERE code = $1"
 
-	err := r.db.QueryRowContext(ctx, q, code).Scan(&id)
+	_, err = tx.ExecContext(ctx, q, code, now)
 	if err != nil {
 		return err
 	}
 
 	q = "UPDATE users SET is_verified = TRUE WHERE id = $1"
 
-	_, err = r.db.ExecContext(ctx, q, id)
+	_, err = tx.ExecContext(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AuthRepository) SavePasswordResetToken(ctx context.Context, tokenHash string, userID int64, createdAt time.Time, expiresAt time.Time) error {
+	q := "INSERT INTO password_reset_tokens(token_hash, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)"
+
+	_, err := r.db.ExecContext(ctx, q, tokenHash, userID, createdAt, expiresAt)
 	return err
 }
+
+// ResetPassword consumes a valid reset token, sets new password, invalidates the rest of
+// user's reset tokens and deletes all of user's sessions in one transaction.
+func (r *AuthRepository) ResetPassword(ctx context.Context, tokenHash string, password string, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := `UPDATE password_reset_tokens SET used_at = $2
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+	RETURNING user_id`
+
+	var userID int64
+
+	err = tx.QueryRowContext(ctx, q, tokenHash, now).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ErrNotFound
+		}
+
+		return err
+	}
+
+	q = "UPDATE users SET password = $2 WHERE id = $1"
+
+	_, err = tx.ExecContext(ctx, q, userID, password)
+	if err != nil {
+		return err
+	}
+
+	q = "UPDATE password_reset_tokens SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL"
+
+	_, err = tx.ExecContext(ctx, q, userID, now)
+	if err != nil {
+		return err
+	}
+
+	q = "DELETE FROM sessions WHERE user_id = $1"
+
+	_, err = tx.ExecContext(ctx, q, userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
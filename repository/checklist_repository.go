@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+)
+
+type ChecklistRepository struct {
+	db *sql.DB
+}
+
+func NewChecklistRepository(db *sql.DB) *ChecklistRepository {
+	return &ChecklistRepository{db: db}
+}
+
+const checklistColumns = "id, task_id, text, done, position, created_at"
+
+func scanChecklistItem(row scanner) (i entity.ChecklistItem, err error) {
+	err = row.Scan(&i.ID, &i.TaskID, &i.Text, &i.Done, &i.Position, &i.CreatedAt)
+	return i, err
+}
+
+// CreateChecklistItem appends item to the end of the task checklist.
+func (r *ChecklistRepository) CreateChecklistItem(ctx context.Context, i entity.ChecklistItem) (entity.ChecklistItem, error) {
+	q := `INSERT INTO task_checklist_items(task_id, text, done, position, created_at)
+		VALUES ($1, $2, $3, (SELECT COALESCE(max(position), 0) + 1 FROM task_checklist_items WHERE task_id = $1), $4)
+		RETURNING ` + checklistColumns
+
+	i, err := scanChecklistItem(r.db.QueryRowContext(ctx, q, i.TaskID, i.Text, i.Done, i.CreatedAt))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.ChecklistItem{}, fmt.Errorf("%w: task", entity.ErrNotFound)
+		}
+
+		return entity.ChecklistItem{}, err
+	}
+
+	return i, nil
+}
+
+func (r *ChecklistRepository) Checklist(ctx context.Context, taskID int64) (items []entity.ChecklistItem, err error) {
+	q := "SELECT " + checklistColumns + " FROM task_checklist_items WHERE task_id = $1 ORDER BY position, id"
+
+	rows, err := r.db.QueryContext(ctx, q, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		i, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, i)
+	}
+
+	return items, nil
+}
+
+// UpdateChecklistItem changes item of task, it fails with entity.ErrNotFound if item belongs to another task.
+func (r *ChecklistRepository) UpdateChecklistItem(ctx context.Context, taskID int64, id int64, upd entity.ChecklistItemToUpdate) (i entity.ChecklistItem, err error) {
+	q := `UPDATE task_checklist_items
+		SET text = COALESCE($3, text),
+		    done = COALESCE($4, done)
+		WHERE id = $1 AND task_id = $2
+		RETURNING ` + checklistColumns
+
+	i, err = scanChecklistItem(r.db.QueryRowContext(ctx, q, id, taskID, upd.Text, upd.Done))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ChecklistItem{}, entity.ErrNotFound
+		}
+
+		return i, err
+	}
+
+	return i, nil
+}
+
+func (r *ChecklistRepository) DeleteChecklistItem(ctx context.Context, taskID int64, id int64) error {
+	q := "DELETE FROM task_checklist_items WHERE id = $1 AND task_id = $2"
+
+	res, err := r.db.ExecContext(ctx, q, id, taskID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"restAPI/entity"
+	"time"
+)
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+const commentColumns = "c.id, c.task_id, c.user_id, c.body, c.created_at, c.edited_at"
+
+func scanComment(row scanner) (c entity.Comment, err error) {
+	err = row.Scan(&c.ID, &c.TaskID, &c.UserID, &c.Body, &c.CreatedAt, &c.EditedAt)
+	return c, err
+}
+
+var commentList = listSpec[entity.Comment]{
+	id:      "c.id",
+	itemID:  func(c entity.Comment) int64 { return c.ID },
+	created: "c.created_at",
+	name:    "c.body",
+	sorts: map[string]sortKey[entity.Comment]{
+		"id":         {expr: "c.id", cast: "bigint", value: func(c entity.Comment) any { return c.ID }},
+		"created_at": {expr: "c.created_at", cast: "timestamptz", value: func(c entity.Comment) any { return c.CreatedAt }},
+	},
+}
+
+func (r *CommentRepository) CreateComment(ctx context.Context, c entity.Comment) (entity.Comment, error) {
+	q := "INSERT INTO task_comments(task_id, user_id, body, created_at) VALUES ($1, $2, $3, $4) RETURNING id"
+
+	err := r.db.QueryRowContext(ctx, q, c.TaskID, c.UserID, c.Body, c.CreatedAt).Scan(&c.ID)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	return c, nil
+}
+
+func (r *CommentRepository) CommentByID(ctx context.Context, id int64) (c entity.Comment, err error) {
+	q := "SELECT " + commentColumns + " FROM task_comments c WHERE c.id = $1"
+
+	c, err = scanComment(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Comment{}, entity.ErrNotFound
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
+
+// TaskComments returns comments of task, oldest first unless lq sorts otherwise.
+func (r *CommentRepository) TaskComments(ctx context.Context, taskID int64, lq entity.ListQuery) (entity.Page[entity.Comment], error) {
+	q := &listQuery{}
+	q.where("c.task_id = ?", taskID)
+
+	p, err := paginate(q, commentList, lq)
+	if err != nil {
+		return entity.Page[entity.Comment]{}, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+commentColumns+" FROM task_comments c"+q.sql()+p.orderBy(), q.args...)
+	if err != nil {
+		return entity.Page[entity.Comment]{}, err
+	}
+	defer rows.Close()
+
+	var comments []entity.Comment
+
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return entity.Page[entity.Comment]{}, err
+		}
+
+		comments = append(comments, c)
+	}
+
+	return p.page(comments)
+}
+
+// UpdateComment replaces comment body and keeps the previous one as a version.
+func (r *CommentRepository) UpdateComment(ctx context.Context, id int64, body string, editedAt time.Time) (c entity.Comment, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Comment{}, err
+	}
+	defer tx.Rollback()
+
+	q := "SELECT body, COALESCE(edited_at, created_at) FROM task_comments WHERE id = $1 FOR UPDATE"
+
+	var previous entity.CommentVersion
+
+	err = tx.QueryRowContext(ctx, q, id).Scan(&previous.Body, &previous.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Comment{}, entity.ErrNotFound
+		}
+
+		return entity.Comment{}, err
+	}
+
+	q = "INSERT INTO task_comment_versions(comment_id, body, created_at) VALUES ($1, $2, $3)"
+
+	_, err = tx.ExecContext(ctx, q, id, previous.Body, previous.CreatedAt)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	q = "UPDATE task_comments c SET body = $2, edited_at = $3 WHERE c.id = $1 RETURNING " + commentColumns
+
+	c, err = scanComment(tx.QueryRowContext(ctx, q, id, body, editedAt))
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	return c, tx.Commit()
+}
+
+func (r *CommentRepository) DeleteComment(ctx context.Context, id int64) error {
+	q := "DELETE FROM task_comments WHERE id = $1"
+
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return nil
+}
+
+// CommentVersions returns previous bodies of comment, oldest first.
+func (r *CommentRepository) CommentVersions(ctx context.Context, commentID int64) (versions []entity.CommentVersion, err error) {
+	q := "SELECT id, comment_id, body, created_at FROM task_comment_versions WHERE comment_id = $1 ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, q, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v entity.CommentVersion
+
+		err = rows.Scan(&v.ID, &v.CommentID, &v.Body, &v.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, v)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"restAPI/entity"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CustomFieldRepository struct {
+	db *sql.DB
+}
+
+func NewCustomFieldRepository(db *sql.DB) *CustomFieldRepository {
+	return &CustomFieldRepository{db: db}
+}
+
+const customFieldColumns = "id, project_id, name, type, options, created_at"
+
+func scanCustomField(row scanner) (f entity.CustomField, err error) {
+	err = row.Scan(&f.ID, &f.ProjectID, &f.Name, &f.Type, pq.Array(&f.Options), &f.CreatedAt)
+	return f, err
+}
+
+func (r *CustomFieldRepository) CreateCustomField(ctx context.Context, f entity.CustomField) (entity.CustomField, error) {
+	q := "INSERT INTO custom_fields(project_id, name, type, options, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING " + customFieldColumns
+
+	f, err := scanCustomField(r.db.QueryRowContext(ctx, q, f.ProjectID, f.Name, f.Type, pq.Array(f.Options), f.CreatedAt))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.CustomField{}, fmt.Errorf("%w: custom field with this name already exists", entity.ErrConflict)
+		}
+
+		return entity.CustomField{}, err
+	}
+
+	return f, nil
+}
+
+func (r *CustomFieldRepository) ProjectCustomFields(ctx context.Context, projectID int64) (fields []entity.CustomField, err error) {
+	q := "SELECT " + customFieldColumns + " FROM custom_fields WHERE project_id = $1 ORDER BY id"
+
+	rows, err := r.db.QueryContext(ctx, q, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// UpdateCustomField changes custom field of project. Values which are no longer among the options
+// of a select field are removed from tasks in the same transaction, tasks of a renamed field are touched.
+func (r *CustomFieldRepository) UpdateCustomField(ctx context.Context, projectID int64, id int64, upd entity.CustomFieldToUpdate, updatedAt time.Time) (f entity.CustomField, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.CustomField{}, err
+	}
+	defer tx.Rollback()
+
+	var options any
+	if upd.Options != nil {
+		options = pq.Array(*upd.Options)
+	}
+
+	q := "UPDATE custom_fields SET name = COALESCE($3, name), options = COALESCE($4, options) WHERE id = $1 AND project_id = $2 RETURNING " + customFieldColumns
+
+	f, err = scanCustomField(tx.QueryRowContext(ctx, q, id, projectID, upd.Name, options))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.CustomField{}, fmt.Errorf("%w: custom field", entity.ErrNotFound)
+		}
+
+		if isUniqueViolation(err) {
+			return entity.CustomField{}, fmt.Errorf("%w: custom field with this name already exists", entity.ErrConflict)
+		}
+
+		return entity.CustomField{}, err
+	}
+
+	key := strconv.FormatInt(id, 10)
+
+	switch {
+	case f.Type == entity.FieldSingleSelect && upd.Options != nil:
+		q = "UPDATE tasks SET custom_fields = custom_fields - $2::text, updated_at = $4 WHERE project_id = $1 AND custom_fields ->> $2::text <> ALL($3)"
+	case f.Type == entity.FieldMultiSelect && upd.Options != nil:
+		q = `UPDATE tasks
+			SET custom_fields = CASE WHEN kept.value = '[]' THEN custom_fields - $2::text ELSE jsonb_set(custom_fields, ARRAY[$2::text], kept.value) END,
+			    updated_at = $4
+			FROM (
+				SELECT t.id, (SELECT COALESCE(jsonb_agg(v), '[]') FROM jsonb_array_elements_text(t.custom_fields -> $2::text) v WHERE v = ANY($3)) AS value
+				FROM tasks t WHERE t.project_id = $1 AND t.custom_fields -> $2::text IS NOT NULL
+			) kept
+			WHERE tasks.id = kept.id AND kept.value <> tasks.custom_fields -> $2::text`
+	default:
+		q = ""
+	}
+
+	if q != "" {
+		_, err = tx.ExecContext(ctx, q, projectID, key, pq.Array(f.Options), updatedAt)
+		if err != nil {
+			return entity.CustomField{}, err
+		}
+	}
+
+	if upd.Name != nil {
+		err = touchCustomFieldTasks(ctx, tx, projectID, id, updatedAt)
+		if err != nil {
+			return entity.CustomField{}, err
+		}
+	}
+
+	return f, tx.Commit()
+}
+
+// DeleteCustomField deletes custom field of project and its values of tasks in the same transaction.
+func (r *CustomFieldRepository) DeleteCustomField(ctx context.Context, projectID int64, id int64, updatedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM custom_fields WHERE id = $1 AND project_id = $2", id, projectID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: custom field", entity.ErrNotFound)
+	}
+
+	q := "UPDATE tasks SET custom_fields = custom_fields - $2::text, updated_at = $3 WHERE project_id = $1 AND custom_fields -> $2::text IS NOT NULL"
+
+	_, err = tx.ExecContext(ctx, q, projectID, strconv.FormatInt(id, 10), updatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// touchCustomFieldTasks bumps updated_at of tasks with a value of field, so clients syncing by it see the change.
+func touchCustomFieldTasks(ctx context.Context, tx *sql.Tx, projectID int64, fieldID int64, updatedAt time.Time) error {
+	q := "UPDATE tasks SET updated_at = $3 WHERE project_id = $1 AND custom_fields -> $2::text IS NOT NULL"
+
+	_, err := tx.ExecContext(ctx, q, projectID, strconv.FormatInt(fieldID, 10), updatedAt)
+	return err
+}
+
+// customFieldValues encodes values for customFieldsByID and customFieldsRemoved, nil means no values.
+func customFieldValues(values entity.CustomFieldValues) (any, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// customFieldsByID returns SQL turning jsonb values keyed by field name into values keyed by id of fields
+// of project, values of unknown fields and nulls are dropped.
+func customFieldsByID(values string, project string) string {
+	return "(SELECT COALESCE(jsonb_object_agg(f.id::text, v.value), '{}') FROM jsonb_each(" + values + ") v " +
+		"JOIN custom_fields f ON f.project_id = " + project + " AND f.name = v.key WHERE v.value <> 'null')"
+}
+
+// customFieldsRemoved returns SQL selecting ids of fields of project set to null in jsonb values.
+func customFieldsRemoved(values string, project string) string {
+	return "ARRAY(SELECT f.id::text FROM jsonb_each(" + values + ") v " +
+		"JOIN custom_fields f ON f.project_id = " + project + " AND f.name = v.key WHERE v.value = 'null')"
+}
+
+// customFieldCondition adds condition c to q of tasks t.
+func customFieldCondition(q *listQuery, c entity.CustomFieldCondition) error {
+	key := strconv.FormatInt(c.Field.ID, 10)
+
+	var cast string
+
+	switch c.Field.Type {
+	case entity.FieldNumber:
+		cast = "numeric"
+	case entity.FieldDate:
+		cast = "date"
+	case entity.FieldText:
+		values := make([]string, 0, len(c.Values))
+		for _, v := range c.Values {
+			values = append(values, strings.ToLower(v))
+		}
+
+		q.where("lower(t.custom_fields ->> ?::text) = ANY(?)", key, pq.Array(values))
+		return nil
+	case entity.FieldSingleSelect, entity.FieldUser:
+		q.where("t.custom_fields ->> ?::text = ANY(?)", key, pq.Array(c.Values))
+		return nil
+	case entity.FieldMultiSelect:
+		q.where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(t.custom_fields -> ?::text) v WHERE v = ANY(?))", key, pq.Array(c.Values))
+		return nil
+	default:
+		return fmt.Errorf("unknown custom field type %q", c.Field.Type)
+	}
+
+	switch c.Op {
+	case "=":
+		q.where(fmt.Sprintf("(t.custom_fields ->> ?::text)::%s = ANY(?::%s[])", cast, cast), key, pq.Array(c.Values))
+	case "<", "<=", ">", ">=":
+		q.where(fmt.Sprintf("(t.custom_fields ->> ?::text)::%s %s ?::%s", cast, c.Op, cast), key, c.Values[0])
+	default:
+		return fmt.Errorf("unknown custom field operator %q", c.Op)
+	}
+
+	return nil
+}
+
+// customFieldSort returns the sort key of field. Tasks without a value of a number or a date go last,
+// without a text first.
+func customFieldSort(field entity.CustomField) (sortKey[entity.Task], error) {
+	value := fmt.Sprintf("(t.custom_fields ->> '%d')", field.ID)
+
+	text := func(t entity.Task, missing string) string {
+		var s string
+		if json.Unmarshal(t.CustomFields[field.Name], &s) != nil {
+			return missing
+		}
+
+		return s
+	}
+
+	switch field.Type {
+	case entity.FieldNumber:
+		return sortKey[entity.Task]{expr: "COALESCE(" + value + "::numeric, 'NaN')", cast: "numeric", value: func(t entity.Task) any {
+			raw, ok := t.CustomFields[field.Name]
+			if !ok {
+				return "NaN"
+			}
+			return json.Number(raw)
+		}}, nil
+	case entity.FieldDate:
+		return sortKey[entity.Task]{expr: "COALESCE(" + value + "::date, 'infinity')", cast: "date", value: func(t entity.Task) any {
+			return text(t, "infinity")
+		}}, nil
+	case entity.FieldText, entity.FieldSingleSelect:
+		return sortKey[entity.Task]{expr: "COALESCE(" + value + ", '')", cast: "text", value: func(t entity.Task) any {
+			return text(t, "")
+		}}, nil
+	default:
+		return sortKey[entity.Task]{}, fmt.Errorf("%w: can't sort by a %s field", entity.ErrBadRequest, field.Type)
+	}
+}
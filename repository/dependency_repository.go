@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+// AddDependency records that blockerID blocks blockedID, both tasks must be in one project.
+// It fails with entity.ErrConflict if the link would close a cycle of dependencies.
+func (r *TaskRepository) AddDependency(ctx context.Context, blockerID int64, blockedID int64, createdAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// links within a project are serialized, so concurrent ones can't form a cycle together
+	q := "SELECT 1 FROM projects p JOIN tasks t ON t.project_id = p.id WHERE t.id = $1 FOR UPDATE OF p"
+
+	_, err = tx.ExecContext(ctx, q, blockerID)
+	if err != nil {
+		return err
+	}
+
+	// blocked lists everything blockedID blocks, directly or not
+	q = `WITH RECURSIVE blocked AS (
+		SELECT $2::bigint AS id
+		UNION
+		SELECT d.blocked_id FROM task_dependencies d JOIN blocked b ON d.blocker_id = b.id
+	) SELECT EXISTS (SELECT 1 FROM blocked WHERE id = $1)`
+
+	var cycle bool
+
+	err = tx.QueryRowContext(ctx, q, blockerID, blockedID).Scan(&cycle)
+	if err != nil {
+		return err
+	}
+
+	if cycle {
+		return fmt.Errorf("%w: task %d already depends on task %d", entity.ErrConflict, blockerID, blockedID)
+	}
+
+	q = "INSERT INTO task_dependencies(blocker_id, blocked_id, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+
+	_, err = tx.ExecContext(ctx, q, blockerID, blockedID, createdAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("%w: task", entity.ErrNotFound)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TaskRepository) RemoveDependency(ctx context.Context, blockerID int64, blockedID int64) error {
+	q := "DELETE FROM task_dependencies WHERE blocker_id = $1 AND blocked_id = $2"
+
+	res, err := r.db.ExecContext(ctx, q, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: dependency", entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+// TaskDependencies returns tasks which id blocks and tasks which block it.
+func (r *TaskRepository) TaskDependencies(ctx context.Context, id int64) (deps entity.TaskDependencies, err error) {
+	q := selectTasks + " JOIN task_dependencies d ON d.blocked_id = t.id WHERE d.blocker_id = $1 ORDER BY t.id"
+
+	deps.Blocks, err = r.tasks(ctx, q, id)
+	if err != nil {
+		return entity.TaskDependencies{}, err
+	}
+
+	q = selectTasks + " JOIN task_dependencies d ON d.blocker_id = t.id WHERE d.blocked_id = $1 ORDER BY t.id"
+
+	deps.BlockedBy, err = r.tasks(ctx, q, id)
+	if err != nil {
+		return entity.TaskDependencies{}, err
+	}
+
+	return deps, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+func (r *InvitationRepository) CreateInvitation(ctx context.Context, inv entity.Invitation) (entity.Invitation, error) {
+	q := `INSERT INTO project_invitations(project_id, email, role, invited_by, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, q, inv.ProjectID, inv.Email, inv.Role, inv.InvitedBy, inv.CreatedAt, inv.ExpiresAt).Scan(&inv.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Invitation{}, fmt.Errorf("%w: invitation for %s is already pending", entity.ErrConflict, inv.Email)
+		}
+
+		return entity.Invitation{}, err
+	}
+
+	return inv, nil
+}
+
+func (r *InvitationRepository) InvitationByID(ctx context.Context, id int64) (inv entity.Invitation, err error) {
+	q := `SELECT id, project_id, email, role, invited_by, created_at, expires_at, accepted_at, revoked_at
+	FROM project_invitations WHERE id = $1`
+
+	err = r.db.QueryRowContext(ctx, q, id).Scan(&inv.ID, &inv.ProjectID, &inv.Email, &inv.Role, &inv.InvitedBy,
+		&inv.CreatedAt, &inv.ExpiresAt, &inv.AcceptedAt, &inv.RevokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Invitation{}, entity.ErrNotFound
+		}
+
+		return inv, err
+	}
+
+	return inv, nil
+}
+
+// PendingInvitations returns not accepted and not revoked invitations of a project, expired ones included.
+func (r *InvitationRepository) PendingInvitations(ctx context.Context, projectID int64) (invitations []entity.Invitation, err error) {
+	q := `SELECT id, project_id, email, role, invited_by, created_at, expires_at, accepted_at, revoked_at
+	FROM project_invitations
+	WHERE project_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+	ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, q, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var inv entity.Invitation
+
+		err = rows.Scan(&inv.ID, &inv.ProjectID, &inv.Email, &inv.Role, &inv.InvitedBy,
+			&inv.CreatedAt, &inv.ExpiresAt, &inv.AcceptedAt, &inv.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, inv)
+	}
+
+	return invitations, nil
+}
+
+// ExtendInvitation sets new expiry of a pending invitation, which also invalidates previously sent links.
+func (r *InvitationRepository) ExtendInvitation(ctx context.Context, id int64, expiresAt time.Time) error {
+	q := "UPDATE project_invitations SET expires_at = $2 WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL"
+
+	return r.execPending(ctx, q, id, expiresAt)
+}
+
+func (r *InvitationRepository) RevokeInvitation(ctx context.Context, id int64, revokedAt time.Time) error {
+	q := "UPDATE project_invitations SET revoked_at = $2 WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL"
+
+	return r.execPending(ctx, q, id, revokedAt)
+}
+
+func (r *InvitationRepository) execPending(ctx context.Context, q string, id int64, t time.Time) error {
+	res, err := r.db.ExecContext(ctx, q, id, t)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: invitation is not pending", entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+// AcceptInvitation marks invitation accepted and adds user to the project with invited role.
+// Users who are already members keep their current role.
+func (r *InvitationRepository) AcceptInvitation(ctx context.Context, id int64, userID int64, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := `UPDATE project_invitations SET accepted_at = $2
+	WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+	RETURNING project_id, role`
+
+	var (
+		projectID int64
+		role      entity.Role
+	)
+
+	err = tx.QueryRowContext(ctx, q, id, now).Scan(&projectID, &role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: invitation is not pending", entity.ErrNotFound)
+		}
+
+		return err
+	}
+
+	q = "INSERT INTO projects_users(project_id, user_id, role) VALUES ($1, $2, $3) ON CONFLICT (project_id, user_id) DO NOTHING"
+
+	_, err = tx.ExecContext(ctx, q, projectID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
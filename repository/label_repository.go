@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+type LabelRepository struct {
+	db *sql.DB
+}
+
+func NewLabelRepository(db *sql.DB) *LabelRepository {
+	return &LabelRepository{db: db}
+}
+
+const labelColumns = "id, project_id, name, color, created_at"
+
+func scanLabel(row scanner) (l entity.Label, err error) {
+	err = row.Scan(&l.ID, &l.ProjectID, &l.Name, &l.Color, &l.CreatedAt)
+	return l, err
+}
+
+func (r *LabelRepository) CreateLabel(ctx context.Context, l entity.Label) (entity.Label, error) {
+	q := "INSERT INTO labels(project_id, name, color, created_at) VALUES ($1, $2, $3, $4) RETURNING " + labelColumns
+
+	l, err := scanLabel(r.db.QueryRowContext(ctx, q, l.ProjectID, l.Name, l.Color, l.CreatedAt))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Label{}, fmt.Errorf("%w: label with this name already exists", entity.ErrConflict)
+		}
+
+		return entity.Label{}, err
+	}
+
+	return l, nil
+}
+
+func (r *LabelRepository) ProjectLabels(ctx context.Context, projectID int64) (labels []entity.Label, err error) {
+	q := "SELECT " + labelColumns + " FROM labels WHERE project_id = $1 ORDER BY lower(name)"
+
+	rows, err := r.db.QueryContext(ctx, q, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		l, err := scanLabel(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		labels = append(labels, l)
+	}
+
+	return labels, nil
+}
+
+// UpdateLabel changes label of project and touches tasks tagged with it in the same transaction.
+func (r *LabelRepository) UpdateLabel(ctx context.Context, projectID int64, id int64, upd entity.LabelToUpdate, updatedAt time.Time) (l entity.Label, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Label{}, err
+	}
+	defer tx.Rollback()
+
+	q := "UPDATE labels SET name = COALESCE($3, name), color = COALESCE($4, color) WHERE id = $1 AND project_id = $2 RETURNING " + labelColumns
+
+	l, err = scanLabel(tx.QueryRowContext(ctx, q, id, projectID, upd.Name, upd.Color))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Label{}, entity.ErrNotFound
+		}
+
+		if isUniqueViolation(err) {
+			return entity.Label{}, fmt.Errorf("%w: label with this name already exists", entity.ErrConflict)
+		}
+
+		return entity.Label{}, err
+	}
+
+	err = touchLabeledTasks(ctx, tx, id, updatedAt)
+	if err != nil {
+		return entity.Label{}, err
+	}
+
+	return l, tx.Commit()
+}
+
+// DeleteLabel deletes label of project and untags its tasks in the same transaction.
+func (r *LabelRepository) DeleteLabel(ctx context.Context, projectID int64, id int64, updatedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = touchLabeledTasks(ctx, tx, id, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM labels WHERE id = $1 AND project_id = $2", id, projectID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// touchLabeledTasks bumps updated_at of tasks tagged with label, so clients syncing by it see the change.
+func touchLabeledTasks(ctx context.Context, tx *sql.Tx, labelID int64, updatedAt time.Time) error {
+	q := "UPDATE tasks SET updated_at = $2 WHERE id IN (SELECT task_id FROM task_labels WHERE label_id = $1)"
+
+	_, err := tx.ExecContext(ctx, q, labelID, updatedAt)
+	return err
+}
+
+// TagTask tags task with label, both must belong to one project.
+func (r *LabelRepository) TagTask(ctx context.Context, taskID int64, labelID int64, updatedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := `INSERT INTO task_labels(task_id, label_id)
+		SELECT t.id, l.id FROM tasks t JOIN labels l ON l.project_id = t.project_id WHERE t.id = $1 AND l.id = $2
+		ON CONFLICT DO NOTHING`
+
+	res, err := tx.ExecContext(ctx, q, taskID, labelID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		var tagged bool
+
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM task_labels WHERE task_id = $1 AND label_id = $2)", taskID, labelID).Scan(&tagged)
+		if err != nil {
+			return err
+		}
+
+		if !tagged {
+			return fmt.Errorf("%w: label", entity.ErrNotFound)
+		}
+
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE tasks SET updated_at = $2 WHERE id = $1", taskID, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *LabelRepository) UntagTask(ctx context.Context, taskID int64, labelID int64, updatedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2", taskID, labelID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: label", entity.ErrNotFound)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE tasks SET updated_at = $2 WHERE id = $1", taskID, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"restAPI/entity"
+	"strings"
+)
+
+// listQuery collects conditions of a list query, '?' in a condition is replaced
+// by the placeholder of the next argument.
+type listQuery struct {
+	conds []string
+	args  []any
+}
+
+func (q *listQuery) where(cond string, args ...any) {
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		cond = strings.Replace(cond, "?", fmt.Sprintf("$%d", len(q.args)), 1)
+	}
+
+	q.conds = append(q.conds, cond)
+}
+
+func (q *listQuery) sql() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(q.conds, " AND ")
+}
+
+// sortKey is a field a list can be sorted by. expr must never be NULL so rows can be
+// compared with a cursor, cast is the SQL type of expr and value reads expr from an item.
+type sortKey[T any] struct {
+	expr  string
+	cast  string
+	value func(T) any
+}
+
+// listSpec describes columns of a listed table shared by all list queries.
+type listSpec[T any] struct {
+	id      string
+	itemID  func(T) int64
+	created string
+	name    string
+	sorts   map[string]sortKey[T]
+}
+
+// pager orders and limits a list query and cuts the result into a page.
+type pager[T any] struct {
+	spec  listSpec[T]
+	field string
+	key   sortKey[T]
+	desc  bool
+	limit int
+}
+
+// paginate adds filters and the cursor condition of lq to q.
+func paginate[T any](q *listQuery, spec listSpec[T], lq entity.ListQuery) (pager[T], error) {
+	p := pager[T]{spec: spec, field: "id", limit: lq.Limit}
+
+	if p.limit <= 0 {
+		p.limit = entity.DefaultListLimit
+	}
+
+	if p.limit > entity.MaxListLimit {
+		return pager[T]{}, fmt.Errorf("%w: 'limit' can't be greater than %d", entity.ErrBadRequest, entity.MaxListLimit)
+	}
+
+	if lq.Sort != "" {
+		p.field, p.desc = strings.TrimPrefix(lq.Sort, "-"), strings.HasPrefix(lq.Sort, "-")
+	}
+
+	key, ok := spec.sorts[p.field]
+	if !ok {
+		return pager[T]{}, fmt.Errorf("%w: can't sort by %q", entity.ErrBadRequest, p.field)
+	}
+
+	p.key = key
+
+	if lq.CreatedAfter != nil {
+		q.where(spec.created+" >= ?", *lq.CreatedAfter)
+	}
+
+	if lq.CreatedBefore != nil {
+		q.where(spec.created+" < ?", *lq.CreatedBefore)
+	}
+
+	if lq.NameContains != "" {
+		q.where(spec.name+` ILIKE '%' || ? || '%'`, escapeLike(lq.NameContains))
+	}
+
+	if lq.After != "" {
+		value, id, err := p.decodeCursor(lq.After)
+		if err != nil {
+			return pager[T]{}, err
+		}
+
+		op := ">"
+		if p.desc {
+			op = "<"
+		}
+
+		q.where(fmt.Sprintf("(%s, %s) %s (?::%s, ?)", key.expr, spec.id, op, key.cast), value, id)
+	}
+
+	return p, nil
+}
+
+// orderBy returns ORDER BY and LIMIT clauses, one extra row tells whether there is a next page.
+func (p pager[T]) orderBy() string {
+	dir := "ASC"
+	if p.desc {
+		dir = "DESC"
+	}
+
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %d", p.key.expr, dir, p.spec.id, dir, p.limit+1)
+}
+
+func (p pager[T]) page(items []T) (entity.Page[T], error) {
+	if len(items) <= p.limit {
+		return entity.Page[T]{Items: items}, nil
+	}
+
+	items = items[:p.limit]
+	last := items[len(items)-1]
+
+	cursor, err := json.Marshal([]any{p.field, p.key.value(last), p.spec.itemID(last)})
+	if err != nil {
+		return entity.Page[T]{}, err
+	}
+
+	return entity.Page[T]{
+		Items:      items,
+		NextCursor: base64.RawURLEncoding.EncodeToString(cursor),
+	}, nil
+}
+
+// decodeCursor returns sort value and id of the last item of the previous page.
+// Sort value is returned as text and cast to the sort key type by the query.
+func (p pager[T]) decodeCursor(cursor string) (string, int64, error) {
+	invalid := fmt.Errorf("%w: invalid cursor", entity.ErrBadRequest)
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, invalid
+	}
+
+	var parts []json.RawMessage
+
+	err = json.Unmarshal(data, &parts)
+	if err != nil || len(parts) != 3 {
+		return "", 0, invalid
+	}
+
+	var field string
+	var id int64
+
+	err = json.Unmarshal(parts[0], &field)
+	if err != nil {
+		return "", 0, invalid
+	}
+
+	if field != p.field {
+		return "", 0, fmt.Errorf("%w: cursor was made for sorting by %q", entity.ErrBadRequest, field)
+	}
+
+	err = json.Unmarshal(parts[2], &id)
+	if err != nil {
+		return "", 0, invalid
+	}
+
+	// the value is either a JSON string or a number
+	var text string
+	var number json.Number
+
+	err = json.Unmarshal(parts[1], &text)
+	if err != nil {
+		err = json.Unmarshal(parts[1], &number)
+		if err != nil {
+			return "", 0, invalid
+		}
+
+		text = number.String()
+	}
+
+	return text, id, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
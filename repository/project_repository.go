@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"restAPI/entity"
+	"strconv"
+	"time"
 )
 
 type ProjectRepository struct {
@@ -18,7 +21,7 @@ func NewProjectRepository(database *sql.DB) *ProjectRepository {
 }
 
 func (r *ProjectRepository) CreateProject(ctx context.Context, project entity.Project) (entity.Project, error) {
-	q := "INSERT INTO projects(name, user_id, created_at) VALUES($1, $2, $3) RETURNING id"
+	q := "INSERT INTO projects(name, description, archived, user_id, created_at) VALUES($1, $2, $3, $4, $5) RETURNING id"
 
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -26,46 +29,98 @@ func (r *ProjectRepository) CreateProject(ctx context.Context, project entity.Pr
 	}
 	defer tx.Rollback()
 
-	err = tx.QueryRowContext(ctx, q, project.Name, project.UserID, project.CreatedAt).Scan(&project.ID)
+	err = tx.QueryRowContext(ctx, q, project.Name, project.Description, project.Archived, project.UserID, project.CreatedAt).Scan(&project.ID)
 	if err != nil {
 		return entity.Project{}, err
 	}
 
-	err = r.addProjectMember(ctx, tx, project.ID, project.UserID)
+	err = r.addProjectMember(ctx, tx, project.ID, project.UserID, entity.RoleOwner)
 	if err != nil {
 		return entity.Project{}, err
 	}
 
+	q = "INSERT INTO project_statuses(project_id, name, position, is_terminal) VALUES ($1, $2, $3, $4)"
+
+	for _, s := range entity.DefaultStatuses {
+		_, err = tx.ExecContext(ctx, q, project.ID, s.Name, s.Position, s.Terminal)
+		if err != nil {
+			return entity.Project{}, err
+		}
+	}
+
 	return project, tx.Commit()
 }
 
-func (r *ProjectRepository) UserProjects(ctx context.Context, userID int64) (projects []entity.Project, err error) {
-	q := "SELECT p.id, p.name, p.user_id, p.created_at FROM projects p JOIN projects_users pu ON pu.project_id = p.id WHERE pu.user_id = $1"
+var projectList = listSpec[entity.Project]{
+	id:      "p.id",
+	itemID:  func(p entity.Project) int64 { return p.ID },
+	created: "p.created_at",
+	name:    "p.name",
+	sorts: map[string]sortKey[entity.Project]{
+		"id":         {expr: "p.id", cast: "bigint", value: func(p entity.Project) any { return p.ID }},
+		"name":       {expr: "p.name", cast: "text", value: func(p entity.Project) any { return p.Name }},
+		"created_at": {expr: "p.created_at", cast: "timestamptz", value: func(p entity.Project) any { return p.CreatedAt }},
+	},
+}
+
+func (r *ProjectRepository) UserProjects(ctx context.Context, userID int64, lq entity.ListQuery) (entity.Page[entity.Project], error) {
+	q := &listQuery{}
+	q.where("pu.user_id = ?", userID)
+
+	pg, err := paginate(q, projectList, lq)
+	if err != nil {
+		return entity.Page[entity.Project]{}, err
+	}
 
-	rows, err := r.db.QueryContext(ctx, q, userID)
+	query := "SELECT p.id, p.name, p.description, p.archived, p.user_id, p.created_at FROM projects p JOIN projects_users pu ON pu.project_id = p.id" +
+		q.sql() + pg.orderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
 	if err != nil {
-		return nil, err
+		return entity.Page[entity.Project]{}, err
 	}
 	defer rows.Close()
 
+	var projects []entity.Project
+
 	for rows.Next() {
 		var p entity.Project
 
-		err = rows.Scan(&p.ID, &p.Name, &p.UserID, &p.CreatedAt)
+		err = rows.Scan(&p.ID, &p.Name, &p.Description, &p.Archived, &p.UserID, &p.CreatedAt)
 		if err != nil {
-			return nil, err
+			return entity.Page[entity.Project]{}, err
 		}
 
 		projects = append(projects, p)
 	}
 
-	return projects, nil
+	return pg.page(projects)
 }
 
 func (r *ProjectRepository) ProjectByID(ctx context.Context, id int64) (p entity.Project, err error) {
-	q := "SELECT id, name, user_id, created_at FROM projects WHERE id = $1"
+	q := "SELECT id, name, description, archived, user_id, created_at FROM projects WHERE id = $1"
+
+	err = r.db.QueryRowContext(ctx, q, id).Scan(&p.ID, &p.Name, &p.Description, &p.Archived, &p.UserID, &p.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Project{}, entity.ErrNotFound
+		}
+
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (r *ProjectRepository) UpdateProject(ctx context.Context, id int64, upd entity.ProjectToUpdate) (p entity.Project, err error) {
+	q := `UPDATE projects
+	SET name = COALESCE($2, name),
+	    description = COALESCE($3, description),
+	    archived = COALESCE($4, archived)
+	WHERE id = $1
+	RETURNING id, name, description, archived, user_id, created_at`
 
-	err = r.db.QueryRowContext(ctx, q, id).Scan(&p.ID, &p.Name, &p.UserID, &p.CreatedAt)
+	err = r.db.QueryRowContext(ctx, q, id, upd.Name, upd.Description, upd.Archived).Scan(&p.ID, &p.Name, &p.Description, &p.Archived, &p.UserID, &p.CreatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.Project{}, entity.ErrNotFound
@@ -77,6 +132,62 @@ func (r *ProjectRepository) ProjectByID(ctx context.Context, id int64) (p entity
 	return p, nil
 }
 
+// TransferProject makes member toUserID the project owner and demotes the previous owner to admin.
+func (r *ProjectRepository) TransferProject(ctx context.Context, projectID int64, fromUserID int64, toUserID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := "SELECT user_id FROM projects WHERE id = $1 FOR UPDATE"
+
+	var ownerID int64
+
+	err = tx.QueryRowContext(ctx, q, projectID).Scan(&ownerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ErrNotFound
+		}
+
+		return err
+	}
+
+	if ownerID != fromUserID {
+		return fmt.Errorf("%w: project owner has changed", entity.ErrConflict)
+	}
+
+	q = "UPDATE projects_users SET role = $3 WHERE project_id = $1 AND user_id = $2"
+
+	res, err := tx.ExecContext(ctx, q, projectID, toUserID, entity.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: new owner must be a project member", entity.ErrNotFound)
+	}
+
+	_, err = tx.ExecContext(ctx, q, projectID, fromUserID, entity.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	q = "UPDATE projects SET user_id = $2 WHERE id = $1"
+
+	_, err = tx.ExecContext(ctx, q, projectID, toUserID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (r *ProjectRepository) DeleteProject(ctx context.Context, projectID int64) error {
 	q := "DELETE FROM projects WHERE id = $1"
 
@@ -88,14 +199,14 @@ func (r *ProjectRepository) DeleteProject(ctx context.Context, projectID int64)
 	return nil
 }
 
-func (r *ProjectRepository) AddProjectMember(ctx context.Context, projectID int64, userID int64) error {
+func (r *ProjectRepository) AddProjectMember(ctx context.Context, projectID int64, userID int64, role entity.Role) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	err = r.addProjectMember(ctx, tx, projectID, userID)
+	err = r.addProjectMember(ctx, tx, projectID, userID, role)
 	if err != nil {
 		return err
 	}
@@ -103,13 +214,123 @@ func (r *ProjectRepository) AddProjectMember(ctx context.Context, projectID int6
 	return tx.Commit()
 }
 
-func (r *ProjectRepository) addProjectMember(ctx context.Context, tx *sql.Tx, projectID int64, userID int64) error {
-	q := "INSERT INTO projects_users(project_id, user_id) VALUES ($1, $2)"
+func (r *ProjectRepository) addProjectMember(ctx context.Context, tx *sql.Tx, projectID int64, userID int64, role entity.Role) error {
+	q := "INSERT INTO projects_users(project_id, user_id, role) VALUES ($1, $2, $3)"
 
-	_, err := tx.ExecContext(ctx, q, projectID, userID)
+	_, err := tx.ExecContext(ctx, q, projectID, userID, role)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: user is already a project member", entity.ErrConflict)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// MemberRole returns role of user in project or entity.ErrNotFound if user is not a member.
+func (r *ProjectRepository) MemberRole(ctx context.Context, projectID int64, userID int64) (role entity.Role, err error) {
+	q := "SELECT role FROM projects_users WHERE project_id = $1 AND user_id = $2"
+
+	err = r.db.QueryRowContext(ctx, q, projectID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", entity.ErrNotFound
+		}
+
+		return "", err
+	}
+
+	return role, nil
+}
+
+func (r *ProjectRepository) UpdateMemberRole(ctx context.Context, projectID int64, userID int64, role entity.Role) error {
+	q := "UPDATE projects_users SET role = $3 WHERE project_id = $1 AND user_id = $2"
+
+	res, err := r.db.ExecContext(ctx, q, projectID, userID, role)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
 	return nil
 }
+
+// RemoveProjectMember removes user from project and reassigns tasks assigned to user to reassignTo,
+// tasks are left unassigned when reassignTo is 0. reassignTo must be at least a member of the project.
+// Values of user custom fields pointing at user are removed.
+func (r *ProjectRepository) RemoveProjectMember(ctx context.Context, projectID int64, userID int64, reassignTo int64, updatedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := "DELETE FROM projects_users WHERE project_id = $1 AND user_id = $2 RETURNING role"
+
+	var role entity.Role
+
+	err = tx.QueryRowContext(ctx, q, projectID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: not a project member", entity.ErrNotFound)
+		}
+
+		return err
+	}
+
+	if role == entity.RoleOwner {
+		return fmt.Errorf("%w: project owner can't be removed", entity.ErrConflict)
+	}
+
+	if reassignTo != 0 {
+		// the lock keeps the new assignee from being removed or demoted before commit
+		q = "SELECT role FROM projects_users WHERE project_id = $1 AND user_id = $2 FOR UPDATE"
+
+		err = tx.QueryRowContext(ctx, q, projectID, reassignTo).Scan(&role)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: tasks can only be reassigned to a project member", entity.ErrBadRequest)
+			}
+
+			return err
+		}
+
+		if !role.AtLeast(entity.RoleMember) {
+			return fmt.Errorf("%w: tasks can't be reassigned to %s", entity.ErrBadRequest, role)
+		}
+	}
+
+	q = "UPDATE tasks SET assignee_id = NULLIF($3::bigint, 0), updated_at = $4 WHERE project_id = $1 AND assignee_id = $2"
+
+	_, err = tx.ExecContext(ctx, q, projectID, userID, reassignTo, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	q = `UPDATE tasks SET custom_fields = custom_fields - removed.keys, updated_at = $3
+		FROM (
+			SELECT t.id, ARRAY(
+				SELECT f.id::text FROM custom_fields f
+				WHERE f.project_id = t.project_id AND f.type = 'user' AND t.custom_fields ->> f.id::text = $2::text
+			) AS keys
+			FROM tasks t WHERE t.project_id = $1
+		) removed
+		WHERE tasks.id = removed.id AND cardinality(removed.keys) > 0`
+
+	_, err = tx.ExecContext(ctx, q, projectID, strconv.FormatInt(userID, 10), updatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,74 @@
+package repository
+
+import "strings"
+
+// rankDigits are digits of rank keys in ascending byte order, so keys compare
+// correctly with the "C" collation of the tasks.rank column.
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// rankBetween returns a key ordered strictly between prev and next. Empty prev means
+// the start of a column and empty next means its end. Keys never end with the lowest
+// digit, so there is always room for a key before any other.
+func rankBetween(prev string, next string) string {
+	if next == "" {
+		return rankAfter(prev)
+	}
+
+	// stepping down keeps keys short when tasks are repeatedly put on top
+	if prev == "" {
+		if d := rankDigit(next, 0); d > 1 {
+			return string(rankDigits[d-1])
+		}
+	}
+
+	// skip the common prefix, missing digits of prev count as the lowest digit
+	n := 0
+	for n < len(next) && rankDigit(prev, n) == rankDigit(next, n) {
+		n++
+	}
+
+	if n > 0 {
+		return next[:n] + rankBetween(rankTail(prev, n), next[n:])
+	}
+
+	lo, hi := rankDigit(prev, 0), rankDigit(next, 0)
+
+	if hi-lo > 1 {
+		return string(rankDigits[(lo+hi)/2])
+	}
+
+	// the first digits are adjacent, next without its tail is already greater than prev
+	if len(next) > 1 {
+		return next[:1]
+	}
+
+	return string(rankDigits[lo]) + rankAfter(rankTail(prev, 1))
+}
+
+// rankAfter returns a key greater than prev, stepping up a digit keeps keys short
+// when tasks are repeatedly put at the bottom.
+func rankAfter(prev string) string {
+	for i := 0; i < len(prev); i++ {
+		if d := rankDigit(prev, i); d < len(rankDigits)-1 {
+			return prev[:i] + string(rankDigits[d+1])
+		}
+	}
+
+	return prev + string(rankDigits[len(rankDigits)/2])
+}
+
+func rankDigit(key string, i int) int {
+	if i >= len(key) {
+		return 0
+	}
+
+	return strings.IndexByte(rankDigits, key[i])
+}
+
+func rankTail(key string, n int) string {
+	if n >= len(key) {
+		return ""
+	}
+
+	return key[n:]
+}
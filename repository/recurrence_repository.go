@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+type RecurrenceRepository struct {
+	db *sql.DB
+}
+
+func NewRecurrenceRepository(db *sql.DB) *RecurrenceRepository {
+	return &RecurrenceRepository{db: db}
+}
+
+const recurrenceColumns = "id, task_id, rule, start_at, time_zone, next_at, created_at"
+
+func scanRecurrence(row scanner) (rec entity.Recurrence, err error) {
+	err = row.Scan(&rec.ID, &rec.TaskID, &rec.Rule, &rec.StartAt, &rec.TimeZone, &rec.NextAt, &rec.CreatedAt)
+	return rec, err
+}
+
+// SetRecurrence creates or replaces the recurrence of task rec.TaskID. A replaced recurrence keeps its id,
+// so occurrences it has already created are not created again.
+func (r *RecurrenceRepository) SetRecurrence(ctx context.Context, rec entity.Recurrence) (entity.Recurrence, error) {
+	q := `INSERT INTO task_recurrences(task_id, rule, start_at, time_zone, next_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (task_id) DO UPDATE
+		SET rule = EXCLUDED.rule,
+		    start_at = EXCLUDED.start_at,
+		    time_zone = EXCLUDED.time_zone,
+		    next_at = EXCLUDED.next_at,
+		    locked_until = NULL
+		RETURNING ` + recurrenceColumns
+
+	rec, err := scanRecurrence(r.db.QueryRowContext(ctx, q, rec.TaskID, rec.Rule, rec.StartAt, rec.TimeZone, rec.NextAt, rec.CreatedAt))
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return entity.Recurrence{}, entity.ErrNotFound
+		}
+
+		return entity.Recurrence{}, err
+	}
+
+	return rec, nil
+}
+
+func (r *RecurrenceRepository) RecurrenceByTask(ctx context.Context, taskID int64) (rec entity.Recurrence, err error) {
+	q := "SELECT " + recurrenceColumns + " FROM task_recurrences WHERE task_id = $1"
+
+	rec, err = scanRecurrence(r.db.QueryRowContext(ctx, q, taskID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Recurrence{}, fmt.Errorf("%w: recurrence", entity.ErrNotFound)
+		}
+
+		return rec, err
+	}
+
+	return rec, nil
+}
+
+// DeleteRecurrence stops repeating task, tasks it has created are kept.
+func (r *RecurrenceRepository) DeleteRecurrence(ctx context.Context, taskID int64) error {
+	q := "DELETE FROM task_recurrences WHERE task_id = $1"
+
+	res, err := r.db.ExecContext(ctx, q, taskID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: recurrence", entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ClaimDueRecurrences leases up to limit recurrences due at now until leaseUntil. Rows claimed by another
+// scheduler are skipped until their lease ends, recurrences of archived projects are not due.
+func (r *RecurrenceRepository) ClaimDueRecurrences(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) (recs []entity.Recurrence, err error) {
+	q := `UPDATE task_recurrences SET locked_until = $2
+		WHERE id IN (
+			SELECT rc.id FROM task_recurrences rc
+			JOIN tasks t ON t.id = rc.task_id
+			JOIN projects p ON p.id = t.project_id
+			WHERE rc.next_at <= $1 AND (rc.locked_until IS NULL OR rc.locked_until <= $1) AND NOT p.archived
+			ORDER BY rc.next_at
+			LIMIT $3
+			FOR UPDATE OF rc SKIP LOCKED
+		)
+		RETURNING ` + recurrenceColumns
+
+	rows, err := r.db.QueryContext(ctx, q, now, leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rec, err := scanRecurrence(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		recs = append(recs, rec)
+	}
+
+	return recs, rows.Err()
+}
+
+// AdvanceRecurrence moves recurrence id from occurrence from to next and ends its lease, nil next ends the recurrence.
+// It does nothing if the recurrence has been replaced in the meantime.
+func (r *RecurrenceRepository) AdvanceRecurrence(ctx context.Context, id int64, from time.Time, next *time.Time) error {
+	q := "UPDATE task_recurrences SET next_at = $3, locked_until = NULL WHERE id = $1 AND next_at = $2"
+
+	_, err := r.db.ExecContext(ctx, q, id, from, next)
+	return err
+}
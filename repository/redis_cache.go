@@ -30,7 +30,10 @@ func (r *RedisCache) CreateUser(ctx context.Context, u entity.User) (entity.User
 
 	key := fmt.Sprintf("user:%d", user.ID)
 
-	value, err := json.Marshal(user)
+	cached := user
+	cached.Password = ""
+
+	value, err := json.Marshal(cached)
 	if err != nil {
 		log.Println(err)
 		return user, nil
@@ -77,8 +80,22 @@ func (r *RedisCache) UserByEmail(ctx context.Context, email string) (u entity.Us
 	return r.user.UserByEmail(ctx, email)
 }
 
-func (r *RedisCache) Users(ctx context.Context) (users []entity.User, err error) {
-	return r.user.Users(ctx)
+func (r *RedisCache) UpdateTimeZone(ctx context.Context, id int64, timeZone string) (entity.User, error) {
+	user, err := r.user.UpdateTimeZone(ctx, id, timeZone)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	err = r.client.Del(ctx, fmt.Sprintf("user:%d", id), fmt.Sprintf("user:%s", user.Email)).Err()
+	if err != nil {
+		log.Println(err)
+	}
+
+	return user, nil
+}
+
+func (r *RedisCache) Users(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.User], error) {
+	return r.user.Users(ctx, lq)
 }
 
 func (r *RedisCache) ProjectUsers(ctx context.Context, projectID int64) (users []entity.User, err error) {
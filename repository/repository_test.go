@@ -2,10 +2,19 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
 	"restAPI/bootstrap"
 	"restAPI/entity"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -39,13 +48,29 @@ func TestRepository_CreateUser(t *testing.T) {
 	user, err = userRepo.CreateUser(eCtx, user)
 	require.NoError(t, err)
 
-	// Get user by email && password
-	user2, err := authRepo.UserByEmailAndPassword(eCtx, user.Email, user.Password)
+	// Get user credentials by email
+	user2, err := authRepo.UserCredentials(eCtx, user.Email)
 	require.NoError(t, err)
+	require.Equal(t, user, user2)
 
-	user.Password = ""
+	// Replace password only while the old one is still stored
+	hash := uuid.NewString()
 
-	require.Equal(t, user, user2)
+	err = authRepo.ReplacePassword(eCtx, user.ID, uuid.NewString(), hash)
+	require.NoError(t, err)
+
+	user2, err = authRepo.UserCredentials(eCtx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, user.Password, user2.Password)
+
+	err = authRepo.ReplacePassword(eCtx, user.ID, user.Password, hash)
+	require.NoError(t, err)
+
+	user2, err = authRepo.UserCredentials(eCtx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, hash, user2.Password)
+
+	user.Password = ""
 
 	// Get user by ID
 	user2, err = userRepo.UserByID(eCtx, user.ID)
@@ -58,9 +83,9 @@ func TestRepository_CreateUser(t *testing.T) {
 	require.Equal(t, user, user2)
 
 	// Get users
-	users, err := userRepo.Users(eCtx)
+	users, err := userRepo.Users(eCtx, entity.ListQuery{NameContains: user.Name})
 	require.NoError(t, err)
-	require.Contains(t, users, user)
+	require.Equal(t, []entity.User{user}, users.Items)
 
 	// Delete user
 	err = userRepo.DeleteUser(eCtx, user.ID)
@@ -86,7 +111,7 @@ func TestRepository_Users_Error(t *testing.T) {
 	authRepo := NewAuthRepository(db)
 	userRepo := NewUserRepository(db)
 
-	_, err = authRepo.UserByEmailAndPassword(eCtx, uuid.NewString(), uuid.NewString())
+	_, err = authRepo.UserCredentials(eCtx, uuid.NewString())
 	require.ErrorIs(t, err, entity.ErrNotFound)
 
 	_, err = userRepo.UserByID(eCtx, time.Now().UnixNano())
@@ -103,10 +128,10 @@ func TestRepository_Users_Error(t *testing.T) {
 	err = userRepo.DeleteUser(eCtx, time.Now().UnixNano())
 	require.Error(t, err)
 
-	_, err = userRepo.Users(eCtx)
+	_, err = userRepo.Users(eCtx, entity.ListQuery{})
 	require.Error(t, err)
 
-	_, err = authRepo.UserByEmailAndPassword(eCtx, uuid.NewString(), uuid.NewString())
+	_, err = authRepo.UserCredentials(eCtx, uuid.NewString())
 	require.Error(t, err)
 
 	_, err = userRepo.UserByID(eCtx, time.Now().UnixNano())
@@ -154,9 +179,9 @@ func TestRepository_CreateProject(t *testing.T) {
 	require.NoError(t, err)
 
 	// User projects
-	projects, err := repo.UserProjects(eCtx, user.ID)
+	projects, err := repo.UserProjects(eCtx, user.ID, entity.ListQuery{})
 	require.NoError(t, err)
-	require.Contains(t, projects, actualProject)
+	require.Contains(t, projects.Items, actualProject)
 
 	// Project by ID
 	expectedProject, err := repo.ProjectByID(eCtx, actualProject.ID)
@@ -194,7 +219,7 @@ func TestRepository_Projects_Error(t *testing.T) {
 
 	db.Close()
 
-	_, err = repo.UserProjects(eCtx, time.Now().UnixNano())
+	_, err = repo.UserProjects(eCtx, time.Now().UnixNano(), entity.ListQuery{})
 	require.Error(t, err)
 
 	err = repo.DeleteProject(eCtx, time.Now().UnixNano())
@@ -287,15 +312,15 @@ func TestRepository_CreateTask(t *testing.T) {
 	actualTask2, err = task.CreateTask(eCtx, actualTask2)
 	require.NoError(t, err)
 
-	actualTasks, err := task.ProjectTasks(eCtx, actualProject.ID)
+	actualTasks, err := task.ProjectTasks(eCtx, actualProject.ID, entity.TaskFilter{})
 	require.NoError(t, err)
-	require.Contains(t, actualTasks, actualTask)
-	require.NotContains(t, actualTasks, actualTask2)
+	require.Contains(t, actualTasks.Items, actualTask)
+	require.NotContains(t, actualTasks.Items, actualTask2)
 
-	actualTasks, err = task.UserTasks(eCtx, user.ID)
+	actualTasks, err = task.UserTasks(eCtx, user.ID, entity.TaskFilter{})
 	require.NoError(t, err)
-	require.Contains(t, actualTasks, actualTask)
-	require.NotContains(t, actualTasks, actualTask2)
+	require.Contains(t, actualTasks.Items, actualTask)
+	require.NotContains(t, actualTasks.Items, actualTask2)
 
 	db.Close()
 
@@ -305,9 +330,2477 @@ func TestRepository_CreateTask(t *testing.T) {
 	_, err = task.TaskByID(eCtx, time.Now().UnixNano())
 	require.Error(t, err)
 
-	_, err = task.ProjectTasks(eCtx, time.Now().UnixNano())
+	_, err = task.ProjectTasks(eCtx, time.Now().UnixNano(), entity.TaskFilter{})
 	require.Error(t, err)
 
-	_, err = task.UserTasks(eCtx, time.Now().UnixNano())
+	_, err = task.UserTasks(eCtx, time.Now().UnixNano(), entity.TaskFilter{})
 	require.Error(t, err)
 }
+
+func TestRepository_Sessions(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	authRepo := NewAuthRepository(db)
+
+	user := entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	}
+
+	user, err = userRepo.CreateUser(eCtx, user)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	current := entity.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		IP:         "127.0.0.1",
+		UserAgent:  uuid.NewString(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+
+	other := current
+	other.ID = uuid.New()
+
+	expired := current
+	expired.ID = uuid.New()
+	expired.ExpiresAt = now.Add(-time.Minute)
+
+	for _, s := range []entity.Session{current, other, expired} {
+		err = authRepo.CreateSession(eCtx, s)
+		require.NoError(t, err)
+	}
+
+	// Touch slides expiry but never beyond max lifetime
+	seenAt := now.Add(time.Minute)
+
+	user2, session, err := authRepo.TouchSession(eCtx, current.ID.String(), seenAt, time.Hour, 30*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, user2.ID)
+	require.True(t, seenAt.Equal(session.LastSeenAt))
+	require.True(t, now.Add(30*time.Minute).Equal(session.ExpiresAt))
+
+	_, _, err = authRepo.TouchSession(eCtx, expired.ID.String(), seenAt, time.Hour, time.Hour)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Only active sessions are listed
+	sessions, err := authRepo.UserSessions(eCtx, user.ID, seenAt)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	// Revoke all others
+	err = authRepo.DeleteUserSessions(eCtx, user.ID, current.ID.String())
+	require.NoError(t, err)
+
+	sessions, err = authRepo.UserSessions(eCtx, user.ID, seenAt)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, current.ID, sessions[0].ID)
+
+	err = authRepo.DeleteSession(eCtx, other.ID.String(), user.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = authRepo.DeleteSession(eCtx, current.ID.String(), user.ID)
+	require.NoError(t, err)
+
+	_, err = authRepo.DeleteExpiredSessions(eCtx, seenAt)
+	require.NoError(t, err)
+
+	err = userRepo.DeleteUser(eCtx, user.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_ResetPassword(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	authRepo := NewAuthRepository(db)
+
+	user := entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	}
+
+	user, err = userRepo.CreateUser(eCtx, user)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	session := entity.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+
+	err = authRepo.CreateSession(eCtx, session)
+	require.NoError(t, err)
+
+	token, expiredToken := uuid.NewString(), uuid.NewString()
+
+	err = authRepo.SavePasswordResetToken(eCtx, token, user.ID, now, now.Add(time.Hour))
+	require.NoError(t, err)
+
+	err = authRepo.SavePasswordResetToken(eCtx, expiredToken, user.ID, now, now.Add(-time.Minute))
+	require.NoError(t, err)
+
+	err = authRepo.ResetPassword(eCtx, expiredToken, uuid.NewString(), now)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	password := uuid.NewString()
+
+	err = authRepo.ResetPassword(eCtx, token, password, now)
+	require.NoError(t, err)
+
+	user2, err := authRepo.UserCredentials(eCtx, user.Email)
+	require.NoError(t, err)
+	require.Equal(t, password, user2.Password)
+
+	// Token is single-use and sessions are gone
+	err = authRepo.ResetPassword(eCtx, token, uuid.NewString(), now)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	sessions, err := authRepo.UserSessions(eCtx, user.ID, now)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	err = userRepo.DeleteUser(eCtx, user.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_VerifyUser(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	authRepo := NewAuthRepository(db)
+
+	user := entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	}
+
+	user, err = userRepo.CreateUser(eCtx, user)
+	require.NoError(t, err)
+
+	now := time.Now()
+	code := uuid.NewString()
+
+	err = authRepo.SaveVerificationCode(eCtx, code, user.ID, now, now.Add(-time.Minute))
+	require.NoError(t, err)
+
+	err = authRepo.VerifyUser(eCtx, code, now)
+	require.ErrorIs(t, err, entity.ErrExpired)
+
+	err = authRepo.VerifyUser(eCtx, uuid.NewString(), now)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Resend is throttled
+	err = authRepo.ReplaceVerificationCode(eCtx, uuid.NewString(), user.ID, now, now.Add(time.Hour), now.Add(-time.Minute))
+	require.ErrorIs(t, err, entity.ErrTooManyRequests)
+
+	code = uuid.NewString()
+
+	err = authRepo.ReplaceVerificationCode(eCtx, code, user.ID, now, now.Add(time.Hour), now)
+	require.NoError(t, err)
+
+	err = authRepo.VerifyUser(eCtx, code, now)
+	require.NoError(t, err)
+
+	// Verifying twice is fine
+	err = authRepo.VerifyUser(eCtx, code, now.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	user2, err := userRepo.UserByID(eCtx, user.ID)
+	require.NoError(t, err)
+	require.True(t, user2.IsVerified)
+
+	err = userRepo.DeleteUser(eCtx, user.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_AccessTokens(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	tokenRepo := NewTokenRepository(db)
+
+	user := entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	}
+
+	user, err = userRepo.CreateUser(eCtx, user)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Round(time.Millisecond)
+	expiresAt := now.Add(-time.Minute)
+
+	token := entity.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      uuid.NewString(),
+		Scopes:    []string{entity.ScopeTasksRead},
+		CreatedAt: now,
+	}
+
+	hash := uuid.NewString()
+
+	token, err = tokenRepo.CreateAccessToken(eCtx, token, hash)
+	require.NoError(t, err)
+
+	expired := entity.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      uuid.NewString(),
+		Scopes:    []string{},
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+	}
+
+	expiredHash := uuid.NewString()
+
+	expired, err = tokenRepo.CreateAccessToken(eCtx, expired, expiredHash)
+	require.NoError(t, err)
+
+	// Usage is recorded
+	user2, token2, err := tokenRepo.UserByAccessToken(eCtx, hash, now)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, user2.ID)
+	require.Equal(t, token.Scopes, token2.Scopes)
+	require.NotNil(t, token2.LastUsedAt)
+
+	_, _, err = tokenRepo.UserByAccessToken(eCtx, expiredHash, now)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	tokens, err := tokenRepo.UserAccessTokens(eCtx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	// Revoke
+	err = tokenRepo.DeleteAccessToken(eCtx, token.ID, user.ID)
+	require.NoError(t, err)
+
+	_, _, err = tokenRepo.UserByAccessToken(eCtx, hash, now)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = tokenRepo.DeleteAccessToken(eCtx, token.ID, user.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = userRepo.DeleteUser(eCtx, user.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_ProjectMembers(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+
+	owner, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	member, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    owner.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	// Creator becomes owner
+	role, err := repo.MemberRole(eCtx, project.ID, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleOwner, role)
+
+	_, err = repo.MemberRole(eCtx, project.ID, member.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.AddProjectMember(eCtx, project.ID, member.ID, entity.RoleViewer)
+	require.NoError(t, err)
+
+	err = repo.AddProjectMember(eCtx, project.ID, member.ID, entity.RoleViewer)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	err = repo.UpdateMemberRole(eCtx, project.ID, member.ID, entity.RoleAdmin)
+	require.NoError(t, err)
+
+	role, err = repo.MemberRole(eCtx, project.ID, member.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleAdmin, role)
+
+	// Partial update
+	description := uuid.NewString()
+	archived := true
+
+	project2, err := repo.UpdateProject(eCtx, project.ID, entity.ProjectToUpdate{Description: &description, Archived: &archived})
+	require.NoError(t, err)
+	require.Equal(t, project.Name, project2.Name)
+	require.Equal(t, description, project2.Description)
+	require.True(t, project2.Archived)
+
+	// Transfer ownership
+	err = repo.TransferProject(eCtx, project.ID, member.ID, owner.ID)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	err = repo.TransferProject(eCtx, project.ID, owner.ID, member.ID)
+	require.NoError(t, err)
+
+	project2, err = repo.ProjectByID(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, member.ID, project2.UserID)
+
+	role, err = repo.MemberRole(eCtx, project.ID, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleAdmin, role)
+
+	role, err = repo.MemberRole(eCtx, project.ID, member.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleOwner, role)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_RemoveProjectMember(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+
+	owner, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	member, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    owner.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	err = repo.AddProjectMember(eCtx, project.ID, member.ID, entity.RoleMember)
+	require.NoError(t, err)
+
+	_, err = NewCustomFieldRepository(db).CreateCustomField(eCtx, entity.CustomField{
+		ProjectID: project.ID,
+		Name:      "Reviewer",
+		Type:      entity.FieldUser,
+		Options:   []string{},
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:         uuid.NewString(),
+		UserID:       member.ID,
+		AssigneeID:   &member.ID,
+		ProjectID:    project.ID,
+		CustomFields: entity.CustomFieldValues{"Reviewer": json.RawMessage(strconv.FormatInt(member.ID, 10))},
+		CreatedAt:    time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+	require.Contains(t, task.CustomFields, "Reviewer")
+
+	assigned, err := taskRepo.AssignedTasks(eCtx, member.ID, entity.TaskFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{task}, assigned.Items)
+
+	removedAt := time.Now().UTC().Round(time.Millisecond).Add(time.Hour)
+
+	err = repo.RemoveProjectMember(eCtx, project.ID, owner.ID, member.ID, removedAt)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	viewer, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	// Tasks can't go to outsiders or viewers, the member stays
+	err = repo.RemoveProjectMember(eCtx, project.ID, member.ID, viewer.ID, removedAt)
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+
+	err = repo.AddProjectMember(eCtx, project.ID, viewer.ID, entity.RoleViewer)
+	require.NoError(t, err)
+
+	err = repo.RemoveProjectMember(eCtx, project.ID, member.ID, viewer.ID, removedAt)
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+
+	role, err := repo.MemberRole(eCtx, project.ID, member.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleMember, role)
+
+	err = repo.RemoveProjectMember(eCtx, project.ID, member.ID, owner.ID, removedAt)
+	require.NoError(t, err)
+
+	_, err = repo.MemberRole(eCtx, project.ID, member.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Former members no longer list tasks they created
+	created, err := taskRepo.UserTasks(eCtx, member.ID, entity.TaskFilter{})
+	require.NoError(t, err)
+	require.Empty(t, created.Items)
+
+	// Creator stays, assignment moves, values pointing at the member are removed
+	task, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, member.ID, task.UserID)
+	require.Equal(t, &owner.ID, task.AssigneeID)
+	require.NotContains(t, task.CustomFields, "Reviewer")
+	require.Equal(t, removedAt, task.UpdatedAt.UTC())
+
+	task, err = taskRepo.AssignTask(eCtx, task.ID, nil, time.Now().UTC().Round(time.Millisecond))
+	require.NoError(t, err)
+	require.Nil(t, task.AssigneeID)
+
+	_, err = taskRepo.AssignTask(eCtx, time.Now().UnixNano(), nil, time.Now())
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.RemoveProjectMember(eCtx, project.ID, member.ID, owner.ID, removedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Invitations(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	invRepo := NewInvitationRepository(db)
+
+	owner, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	invitee, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    owner.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	inv := entity.Invitation{
+		ProjectID: project.ID,
+		Email:     invitee.Email,
+		Role:      entity.RoleViewer,
+		InvitedBy: owner.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	inv, err = invRepo.CreateInvitation(eCtx, inv)
+	require.NoError(t, err)
+
+	_, err = invRepo.CreateInvitation(eCtx, inv)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	inv2, err := invRepo.InvitationByID(eCtx, inv.ID)
+	require.NoError(t, err)
+	require.Equal(t, inv, inv2)
+
+	invitations, err := invRepo.PendingInvitations(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Invitation{inv}, invitations)
+
+	// Expired invitation can't be accepted until it is resent
+	err = invRepo.AcceptInvitation(eCtx, inv.ID, invitee.ID, now.Add(2*time.Hour))
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = invRepo.ExtendInvitation(eCtx, inv.ID, now.Add(3*time.Hour))
+	require.NoError(t, err)
+
+	err = invRepo.AcceptInvitation(eCtx, inv.ID, invitee.ID, now.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	role, err := repo.MemberRole(eCtx, project.ID, invitee.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.RoleViewer, role)
+
+	err = invRepo.RevokeInvitation(eCtx, inv.ID, now)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	invitations, err = invRepo.PendingInvitations(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Empty(t, invitations)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_UpdateTask(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:        uuid.NewString(),
+		Description: uuid.NewString(),
+		UserID:      user.ID,
+		ProjectID:   project.ID,
+		CreatedAt:   time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	// Partial update keeps other fields
+	name := uuid.NewString()
+	updatedAt := time.Now().UTC().Round(time.Millisecond).Add(time.Minute)
+
+	updated, err := taskRepo.UpdateTask(eCtx, task.ID, entity.TaskToUpdate{Name: &name}, updatedAt)
+	require.NoError(t, err)
+
+	task.Name = name
+	task.UpdatedAt = updatedAt
+	require.Equal(t, task, updated)
+
+	_, err = taskRepo.UpdateTask(eCtx, time.Now().UnixNano(), entity.TaskToUpdate{Name: &name}, updatedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Delete
+	err = taskRepo.DeleteTask(eCtx, task.ID, "")
+	require.NoError(t, err)
+
+	_, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = taskRepo.DeleteTask(eCtx, task.ID, "")
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Statuses(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	statusRepo := NewStatusRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	// New project gets default statuses
+	workflow, err := statusRepo.Workflow(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, workflow.Statuses, len(entity.DefaultStatuses))
+	require.Empty(t, workflow.Transitions)
+
+	todo, inProgress, done := workflow.Statuses[0], workflow.Statuses[1], workflow.Statuses[2]
+	require.True(t, done.Terminal)
+
+	_, err = statusRepo.CreateStatus(eCtx, entity.Status{ProjectID: project.ID, Name: todo.Name})
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	review, err := statusRepo.CreateStatus(eCtx, entity.Status{ProjectID: project.ID, Name: "review"})
+	require.NoError(t, err)
+	require.Equal(t, done.Position+1, review.Position)
+
+	name := "in review"
+	review, err = statusRepo.UpdateStatus(eCtx, project.ID, review.ID, entity.StatusToUpdate{Name: &name}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, name, review.Name)
+
+	_, err = statusRepo.UpdateStatus(eCtx, time.Now().UnixNano(), review.ID, entity.StatusToUpdate{Name: &name}, time.Now())
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Task starts in the first status
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+	require.Equal(t, todo.ID, task.StatusID)
+	require.Equal(t, todo.Name, task.Status)
+	require.Nil(t, task.CompletedAt)
+
+	transitions := []entity.StatusTransition{
+		{FromStatusID: todo.ID, ToStatusID: inProgress.ID},
+		{FromStatusID: inProgress.ID, ToStatusID: done.ID},
+	}
+
+	err = statusRepo.ReplaceTransitions(eCtx, project.ID, transitions)
+	require.NoError(t, err)
+
+	workflow, err = statusRepo.Workflow(eCtx, project.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, transitions, workflow.Transitions)
+
+	// Status change is conditional on the current status
+	completedAt := time.Now().UTC().Round(time.Millisecond)
+
+	_, err = taskRepo.SetTaskStatus(eCtx, task.ID, inProgress.ID, done.ID, &completedAt, completedAt)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	moved, err := taskRepo.SetTaskStatus(eCtx, task.ID, todo.ID, done.ID, &completedAt, completedAt)
+	require.NoError(t, err)
+	require.Equal(t, done.ID, moved.StatusID)
+	require.Equal(t, done.Name, moved.Status)
+	require.Equal(t, &completedAt, moved.CompletedAt)
+
+	// Tasks follow their status in and out of being terminal
+	terminal := false
+	reopenedAt := completedAt.Add(time.Hour)
+
+	_, err = statusRepo.UpdateStatus(eCtx, project.ID, done.ID, entity.StatusToUpdate{Terminal: &terminal}, reopenedAt)
+	require.NoError(t, err)
+
+	moved, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Nil(t, moved.CompletedAt)
+	require.Equal(t, reopenedAt, moved.UpdatedAt.UTC())
+
+	terminal = true
+	completedAt = reopenedAt.Add(time.Hour)
+
+	_, err = statusRepo.UpdateStatus(eCtx, project.ID, done.ID, entity.StatusToUpdate{Terminal: &terminal}, completedAt)
+	require.NoError(t, err)
+
+	moved, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, completedAt, moved.CompletedAt.UTC())
+
+	// Status with tasks can't be deleted
+	err = statusRepo.DeleteStatus(eCtx, project.ID, done.ID)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	err = statusRepo.DeleteStatus(eCtx, project.ID, review.ID)
+	require.NoError(t, err)
+
+	err = statusRepo.DeleteStatus(eCtx, project.ID, review.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_TaskSchedule(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		TimeZone:  "Europe/Moscow",
+	})
+	require.NoError(t, err)
+
+	user, err = userRepo.UpdateTimeZone(eCtx, user.ID, "Asia/Tokyo")
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Tokyo", user.TimeZone)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Round(time.Millisecond)
+	yesterday, tomorrow := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+
+	overdue, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		Priority:  entity.PriorityUrgent,
+		DueAt:     &yesterday,
+		CreatedAt: now,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &yesterday, overdue.DueAt)
+
+	upcoming, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		StartAt:   &now,
+		DueAt:     &tomorrow,
+		CreatedAt: now,
+	})
+	require.NoError(t, err)
+	require.Equal(t, entity.PriorityNone, upcoming.Priority)
+
+	tasks, err := taskRepo.ProjectTasks(eCtx, project.ID, entity.TaskFilter{Open: true, DueBefore: &now})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{overdue}, tasks.Items)
+
+	tasks, err = taskRepo.ProjectTasks(eCtx, project.ID, entity.TaskFilter{DueAfter: &now})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{upcoming}, tasks.Items)
+
+	tasks, err = taskRepo.UserTasks(eCtx, user.ID, entity.TaskFilter{Priorities: entity.PriorityHigh.AtLeast()})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{overdue}, tasks.Items)
+
+	high := entity.PriorityHigh
+
+	upcoming, err = taskRepo.UpdateTask(eCtx, upcoming.ID, entity.TaskToUpdate{Priority: &high}, now)
+	require.NoError(t, err)
+	require.Equal(t, high, upcoming.Priority)
+	require.Equal(t, &tomorrow, upcoming.DueAt)
+
+	// A set date can be cleared again
+	nextWeek := now.Add(7 * 24 * time.Hour)
+
+	upcoming, err = taskRepo.UpdateTask(eCtx, upcoming.ID, entity.TaskToUpdate{DueAt: &nextWeek}, now)
+	require.NoError(t, err)
+	require.Equal(t, &nextWeek, upcoming.DueAt)
+
+	upcoming, err = taskRepo.UpdateTask(eCtx, upcoming.ID, entity.TaskToUpdate{ClearDueAt: true}, now)
+	require.NoError(t, err)
+	require.Nil(t, upcoming.DueAt)
+	require.Equal(t, high, upcoming.Priority)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_ListPagination(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	var tasks []entity.Task
+
+	for _, name := range []string{"a", "b", "c"} {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:      name,
+			UserID:    user.ID,
+			ProjectID: project.ID,
+			CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		})
+		require.NoError(t, err)
+
+		tasks = append(tasks, task)
+	}
+
+	// Walk pages in descending name order
+	f := entity.TaskFilter{ListQuery: entity.ListQuery{Limit: 2, Sort: "-name"}}
+
+	page, err := taskRepo.ProjectTasks(eCtx, project.ID, f)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{tasks[2], tasks[1]}, page.Items)
+	require.NotEmpty(t, page.NextCursor)
+
+	f.After = page.NextCursor
+
+	page, err = taskRepo.ProjectTasks(eCtx, project.ID, f)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{tasks[0]}, page.Items)
+	require.Empty(t, page.NextCursor)
+
+	// Cursor is bound to its sort field
+	f.Sort = "created_at"
+
+	_, err = taskRepo.ProjectTasks(eCtx, project.ID, f)
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+
+	_, err = taskRepo.ProjectTasks(eCtx, project.ID, entity.TaskFilter{ListQuery: entity.ListQuery{Sort: "password"}})
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+
+	// Filters
+	page, err = taskRepo.ProjectTasks(eCtx, project.ID, entity.TaskFilter{ListQuery: entity.ListQuery{NameContains: "b"}, Unassigned: true, Status: "todo"})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Task{tasks[1]}, page.Items)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Search(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	searchRepo := NewSearchRepository(db)
+
+	member, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	outsider, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	// a random word keeps other test data out of the results
+	word := "zq" + uuid.NewString()[:8]
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      "Launch " + word,
+		UserID:    member.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:        "Write <b>" + word + "</b> notes",
+		Description: "Everything about " + word,
+		UserID:      member.ID,
+		ProjectID:   project.ID,
+		CreatedAt:   time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	results, err := searchRepo.Search(eCtx, member.ID, entity.SearchQuery{Text: word, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	// task matches in both name and description so it ranks first
+	require.Equal(t, entity.SearchTypeTask, results[0].Type)
+	require.Equal(t, task.ID, results[0].ID)
+	require.Equal(t, "Write &lt;b&gt;<mark>"+word+"</mark>&lt;/b&gt; notes", results[0].Title)
+	require.Equal(t, entity.SearchTypeProject, results[1].Type)
+	require.Equal(t, project.ID, results[1].ID)
+
+	results, err = searchRepo.Search(eCtx, member.ID, entity.SearchQuery{Text: word, Type: entity.SearchTypeProject, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	results, err = searchRepo.Search(eCtx, outsider.ID, entity.SearchQuery{Text: word, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Comments(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	commentRepo := NewCommentRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Round(time.Millisecond)
+
+	first, err := commentRepo.CreateComment(eCtx, entity.Comment{TaskID: task.ID, UserID: user.ID, Body: "first", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	second, err := commentRepo.CreateComment(eCtx, entity.Comment{TaskID: task.ID, UserID: user.ID, Body: "second", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	actual, err := commentRepo.CommentByID(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first, actual)
+
+	task, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, task.CommentCount)
+
+	page, err := commentRepo.TaskComments(eCtx, task.ID, entity.ListQuery{Limit: 1})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Comment{first}, page.Items)
+
+	page, err = commentRepo.TaskComments(eCtx, task.ID, entity.ListQuery{Limit: 1, After: page.NextCursor})
+	require.NoError(t, err)
+	require.Equal(t, []entity.Comment{second}, page.Items)
+	require.Empty(t, page.NextCursor)
+
+	// Edits keep previous bodies
+	editedAt := createdAt.Add(time.Minute)
+
+	edited, err := commentRepo.UpdateComment(eCtx, first.ID, "first, edited", editedAt)
+	require.NoError(t, err)
+	require.Equal(t, "first, edited", edited.Body)
+	require.Equal(t, &editedAt, edited.EditedAt)
+
+	_, err = commentRepo.UpdateComment(eCtx, first.ID, "first, edited twice", editedAt.Add(time.Minute))
+	require.NoError(t, err)
+
+	versions, err := commentRepo.CommentVersions(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, "first", versions[0].Body)
+	require.Equal(t, createdAt, versions[0].CreatedAt)
+	require.Equal(t, "first, edited", versions[1].Body)
+	require.Equal(t, editedAt, versions[1].CreatedAt)
+
+	_, err = commentRepo.UpdateComment(eCtx, time.Now().UnixNano(), "body", editedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = commentRepo.DeleteComment(eCtx, second.ID)
+	require.NoError(t, err)
+
+	err = commentRepo.DeleteComment(eCtx, second.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Attachments(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	attachmentRepo := NewAttachmentRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	newAttachment := func(size int64) entity.Attachment {
+		return entity.Attachment{
+			TaskID:      task.ID,
+			ProjectID:   project.ID,
+			UserID:      user.ID,
+			BlobKey:     uuid.NewString(),
+			Name:        "report.pdf",
+			ContentType: "application/pdf",
+			Size:        size,
+			CreatedAt:   time.Now().UTC().Round(time.Millisecond),
+		}
+	}
+
+	first, err := attachmentRepo.CreateAttachment(eCtx, newAttachment(60), 100)
+	require.NoError(t, err)
+
+	actual, err := attachmentRepo.AttachmentByID(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first, actual)
+
+	_, err = attachmentRepo.CreateAttachment(eCtx, newAttachment(41), 100)
+	require.ErrorIs(t, err, entity.ErrTooLarge)
+
+	second, err := attachmentRepo.CreateAttachment(eCtx, newAttachment(40), 100)
+	require.NoError(t, err)
+
+	used, err := attachmentRepo.ProjectStorage(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), used)
+
+	attachments, err := attachmentRepo.TaskAttachments(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Attachment{first, second}, attachments)
+
+	err = attachmentRepo.DeleteAttachment(eCtx, first.ID)
+	require.NoError(t, err)
+
+	err = attachmentRepo.DeleteAttachment(eCtx, first.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// deleting the project cascades to attachments, their blobs become orphaned
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+
+	keys, err := attachmentRepo.OrphanedBlobs(eCtx, 1000)
+	require.NoError(t, err)
+	require.Contains(t, keys, first.BlobKey)
+	require.Contains(t, keys, second.BlobKey)
+
+	err = attachmentRepo.ForgetBlobs(eCtx, []string{first.BlobKey, second.BlobKey})
+	require.NoError(t, err)
+
+	keys, err = attachmentRepo.OrphanedBlobs(eCtx, 1000)
+	require.NoError(t, err)
+	require.NotContains(t, keys, first.BlobKey)
+	require.NotContains(t, keys, second.BlobKey)
+}
+
+func TestLocalBlobStore(t *testing.T) {
+	store := NewLocalBlobStore(t.TempDir())
+
+	testBlobStore(t, store)
+
+	err := store.Put(eCtx, "../outside", strings.NewReader("x"), 1, "text/plain")
+	require.Error(t, err)
+}
+
+// TestS3BlobStore runs against a local MinIO, see the minio target of the Makefile.
+func TestS3BlobStore(t *testing.T) {
+	store, err := NewS3BlobStore("http://localhost:9000", "", "test-"+uuid.NewString(), "minioadmin", "minioadmin")
+	require.NoError(t, err)
+
+	req, err := store.request(eCtx, http.MethodPut, "", nil)
+	require.NoError(t, err)
+
+	resp, err := store.do(req, emptyPayloadHash)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			t.Skip("MinIO is not running: ", err)
+		}
+	}
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	testBlobStore(t, store)
+}
+
+func testBlobStore(t *testing.T, store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}) {
+	key := "attachments/1/" + uuid.NewString()
+	content := "hello, attachment"
+
+	err := store.Put(eCtx, key, strings.NewReader(content), int64(len(content)), "text/plain")
+	require.NoError(t, err)
+
+	body, err := store.Get(eCtx, key)
+	require.NoError(t, err)
+
+	actual, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	require.Equal(t, content, string(actual))
+
+	err = store.Delete(eCtx, key)
+	require.NoError(t, err)
+
+	_, err = store.Get(eCtx, key)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// deleting a missing blob is not an error, the sweeper may retry
+	err = store.Delete(eCtx, key)
+	require.NoError(t, err)
+}
+
+func TestRepository_Subtasks(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	statusRepo := NewStatusRepository(db)
+	checklistRepo := NewChecklistRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	workflow, err := statusRepo.Workflow(eCtx, project.ID)
+	require.NoError(t, err)
+
+	todo, done := workflow.Statuses[0], workflow.Statuses[2]
+
+	newTask := func(parentID *int64) entity.Task {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:         uuid.NewString(),
+			UserID:       user.ID,
+			ProjectID:    project.ID,
+			ParentTaskID: parentID,
+			CreatedAt:    time.Now().UTC().Round(time.Millisecond),
+		})
+		require.NoError(t, err)
+
+		return task
+	}
+
+	parent := newTask(nil)
+	first := newTask(&parent.ID)
+	second := newTask(&parent.ID)
+	grandchild := newTask(&first.ID)
+	require.Equal(t, &parent.ID, first.ParentTaskID)
+
+	completedAt := time.Now().UTC().Round(time.Millisecond)
+
+	_, err = taskRepo.SetTaskStatus(eCtx, second.ID, todo.ID, done.ID, &completedAt, completedAt)
+	require.NoError(t, err)
+
+	parent, err = taskRepo.TaskByID(eCtx, parent.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.Progress{Done: 1, Total: 2}, parent.SubtaskProgress)
+
+	subtasks, err := taskRepo.Subtasks(eCtx, parent.ID)
+	require.NoError(t, err)
+	require.Len(t, subtasks, 2)
+	require.Equal(t, first.ID, subtasks[0].ID)
+
+	depth, err := taskRepo.TaskDepth(eCtx, grandchild.ID)
+	require.NoError(t, err)
+	require.Equal(t, 3, depth)
+
+	// Cycles
+	_, err = taskRepo.SetTaskParent(eCtx, parent.ID, &grandchild.ID, entity.MaxTaskDepth, time.Now())
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	_, err = taskRepo.SetTaskParent(eCtx, parent.ID, &parent.ID, entity.MaxTaskDepth, time.Now())
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	// Depth limit counts the whole moved subtree
+	_, err = taskRepo.SetTaskParent(eCtx, first.ID, &second.ID, 3, time.Now())
+	require.ErrorIs(t, err, entity.ErrBadRequest)
+
+	moved, err := taskRepo.SetTaskParent(eCtx, first.ID, &second.ID, 4, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, &second.ID, moved.ParentTaskID)
+
+	moved, err = taskRepo.SetTaskParent(eCtx, first.ID, nil, entity.MaxTaskDepth, time.Now())
+	require.NoError(t, err)
+	require.Nil(t, moved.ParentTaskID)
+
+	// Checklist
+	item, err := checklistRepo.CreateChecklistItem(eCtx, entity.ChecklistItem{TaskID: first.ID, Text: "write", CreatedAt: completedAt})
+	require.NoError(t, err)
+	require.Equal(t, 1, item.Position)
+
+	_, err = checklistRepo.CreateChecklistItem(eCtx, entity.ChecklistItem{TaskID: first.ID, Text: "review", CreatedAt: completedAt})
+	require.NoError(t, err)
+
+	isDone := true
+
+	item, err = checklistRepo.UpdateChecklistItem(eCtx, first.ID, item.ID, entity.ChecklistItemToUpdate{Done: &isDone})
+	require.NoError(t, err)
+	require.True(t, item.Done)
+
+	_, err = checklistRepo.UpdateChecklistItem(eCtx, parent.ID, item.ID, entity.ChecklistItemToUpdate{Done: &isDone})
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	items, err := checklistRepo.Checklist(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, item, items[0])
+
+	first, err = taskRepo.TaskByID(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.Progress{Done: 1, Total: 2}, first.ChecklistProgress)
+
+	err = checklistRepo.DeleteChecklistItem(eCtx, first.ID, item.ID)
+	require.NoError(t, err)
+
+	err = checklistRepo.DeleteChecklistItem(eCtx, first.ID, item.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Promoting moves subtasks to the grandparent
+	_, err = taskRepo.SetTaskParent(eCtx, first.ID, &parent.ID, entity.MaxTaskDepth, time.Now())
+	require.NoError(t, err)
+
+	err = taskRepo.DeleteTask(eCtx, first.ID, entity.SubtasksPromote)
+	require.NoError(t, err)
+
+	grandchild, err = taskRepo.TaskByID(eCtx, grandchild.ID)
+	require.NoError(t, err)
+	require.Equal(t, &parent.ID, grandchild.ParentTaskID)
+
+	// Tasks with subtasks need to be told what happens to them
+	err = taskRepo.DeleteTask(eCtx, parent.ID, "")
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	// Cascading deletes the whole subtree
+	err = taskRepo.DeleteTask(eCtx, parent.ID, entity.SubtasksCascade)
+	require.NoError(t, err)
+
+	_, err = taskRepo.TaskByID(eCtx, grandchild.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	_, err = taskRepo.TaskByID(eCtx, second.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+}
+
+func TestRepository_Dependencies(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	statusRepo := NewStatusRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	workflow, err := statusRepo.Workflow(eCtx, project.ID)
+	require.NoError(t, err)
+
+	todo, done := workflow.Statuses[0], workflow.Statuses[2]
+
+	var tasks []entity.Task
+
+	for i := 0; i < 3; i++ {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:      uuid.NewString(),
+			UserID:    user.ID,
+			ProjectID: project.ID,
+			CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		})
+		require.NoError(t, err)
+
+		tasks = append(tasks, task)
+	}
+
+	a, b, c := tasks[0], tasks[1], tasks[2]
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	// a blocks b, b blocks c
+	err = taskRepo.AddDependency(eCtx, a.ID, b.ID, now)
+	require.NoError(t, err)
+
+	err = taskRepo.AddDependency(eCtx, b.ID, c.ID, now)
+	require.NoError(t, err)
+
+	err = taskRepo.AddDependency(eCtx, a.ID, b.ID, now)
+	require.NoError(t, err)
+
+	err = taskRepo.AddDependency(eCtx, c.ID, a.ID, now)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	err = taskRepo.AddDependency(eCtx, b.ID, a.ID, now)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	deps, err := taskRepo.TaskDependencies(eCtx, b.ID)
+	require.NoError(t, err)
+	require.Len(t, deps.Blocks, 1)
+	require.Equal(t, c.ID, deps.Blocks[0].ID)
+	require.Len(t, deps.BlockedBy, 1)
+	require.Equal(t, a.ID, deps.BlockedBy[0].ID)
+
+	b, err = taskRepo.TaskByID(eCtx, b.ID)
+	require.NoError(t, err)
+	require.True(t, b.Blocked)
+
+	a, err = taskRepo.TaskByID(eCtx, a.ID)
+	require.NoError(t, err)
+	require.False(t, a.Blocked)
+
+	// Completing the blocker unblocks the task
+	_, err = taskRepo.SetTaskStatus(eCtx, a.ID, todo.ID, done.ID, &now, now)
+	require.NoError(t, err)
+
+	b, err = taskRepo.TaskByID(eCtx, b.ID)
+	require.NoError(t, err)
+	require.False(t, b.Blocked)
+
+	err = taskRepo.RemoveDependency(eCtx, b.ID, c.ID)
+	require.NoError(t, err)
+
+	err = taskRepo.RemoveDependency(eCtx, b.ID, c.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	c, err = taskRepo.TaskByID(eCtx, c.ID)
+	require.NoError(t, err)
+	require.False(t, c.Blocked)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Labels(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	labelRepo := NewLabelRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Round(time.Millisecond)
+
+	bug, err := labelRepo.CreateLabel(eCtx, entity.Label{ProjectID: project.ID, Name: "bug", Color: "#ff0000", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	ui, err := labelRepo.CreateLabel(eCtx, entity.Label{ProjectID: project.ID, Name: "ui", Color: "#00ff00", CreatedAt: createdAt})
+	require.NoError(t, err)
+
+	_, err = labelRepo.CreateLabel(eCtx, entity.Label{ProjectID: project.ID, Name: "BUG", Color: "#ff0000", CreatedAt: createdAt})
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	labels, err := labelRepo.ProjectLabels(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Label{bug, ui}, labels)
+
+	var tasks []entity.Task
+
+	for i := 0; i < 3; i++ {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:      uuid.NewString(),
+			UserID:    user.ID,
+			ProjectID: project.ID,
+			CreatedAt: createdAt,
+		})
+		require.NoError(t, err)
+
+		tasks = append(tasks, task)
+	}
+
+	// tasks[0] is a bug, tasks[1] is a ui bug, tasks[2] has no labels
+	require.NoError(t, labelRepo.TagTask(eCtx, tasks[0].ID, bug.ID, createdAt))
+	require.NoError(t, labelRepo.TagTask(eCtx, tasks[1].ID, bug.ID, createdAt))
+	require.NoError(t, labelRepo.TagTask(eCtx, tasks[1].ID, ui.ID, createdAt))
+	require.NoError(t, labelRepo.TagTask(eCtx, tasks[1].ID, ui.ID, createdAt))
+
+	err = labelRepo.TagTask(eCtx, tasks[2].ID, time.Now().UnixNano(), createdAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	task, err := taskRepo.TaskByID(eCtx, tasks[1].ID)
+	require.NoError(t, err)
+	require.Len(t, task.Labels, 2)
+	require.Equal(t, "bug", task.Labels[0].Name)
+	require.Equal(t, "ui", task.Labels[1].Name)
+
+	taskIDs := func(f entity.TaskFilter) []int64 {
+		page, err := taskRepo.ProjectTasks(eCtx, project.ID, f)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, task := range page.Items {
+			ids = append(ids, task.ID)
+		}
+
+		return ids
+	}
+
+	sortByID := entity.ListQuery{Sort: "id"}
+
+	require.Equal(t, []int64{tasks[0].ID, tasks[1].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, Labels: []string{"BUG", "ui"}}))
+	require.Equal(t, []int64{tasks[1].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, Labels: []string{"bug", "UI"}, LabelMatch: entity.LabelMatchAll}))
+
+	// Renaming touches tagged tasks
+	renamedAt := createdAt.Add(time.Hour)
+	name := "defect"
+
+	bug, err = labelRepo.UpdateLabel(eCtx, project.ID, bug.ID, entity.LabelToUpdate{Name: &name}, renamedAt)
+	require.NoError(t, err)
+	require.Equal(t, "defect", bug.Name)
+
+	task, err = taskRepo.TaskByID(eCtx, tasks[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, "defect", task.Labels[0].Name)
+	require.True(t, renamedAt.Equal(task.UpdatedAt))
+
+	task, err = taskRepo.TaskByID(eCtx, tasks[2].ID)
+	require.NoError(t, err)
+	require.True(t, createdAt.Equal(task.UpdatedAt))
+
+	_, err = labelRepo.UpdateLabel(eCtx, project.ID+1, bug.ID, entity.LabelToUpdate{Name: &name}, renamedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = labelRepo.UntagTask(eCtx, tasks[1].ID, ui.ID, renamedAt)
+	require.NoError(t, err)
+
+	err = labelRepo.UntagTask(eCtx, tasks[1].ID, ui.ID, renamedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// Deleting untags tasks
+	err = labelRepo.DeleteLabel(eCtx, project.ID, bug.ID, renamedAt)
+	require.NoError(t, err)
+
+	task, err = taskRepo.TaskByID(eCtx, tasks[1].ID)
+	require.NoError(t, err)
+	require.Empty(t, task.Labels)
+
+	err = labelRepo.DeleteLabel(eCtx, project.ID, bug.ID, renamedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Board(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	statusRepo := NewStatusRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	workflow, err := statusRepo.Workflow(eCtx, project.ID)
+	require.NoError(t, err)
+
+	todo, inProgress := workflow.Statuses[0], workflow.Statuses[1]
+
+	var tasks []entity.Task
+
+	for i := 0; i < 3; i++ {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:      uuid.NewString(),
+			UserID:    user.ID,
+			ProjectID: project.ID,
+			CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		})
+		require.NoError(t, err)
+
+		tasks = append(tasks, task)
+	}
+
+	a, b, c := tasks[0], tasks[1], tasks[2]
+
+	column := func(statusID int64) []int64 {
+		tasks, err := taskRepo.BoardTasks(eCtx, project.ID)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, task := range tasks {
+			if task.StatusID == statusID {
+				ids = append(ids, task.ID)
+			}
+		}
+
+		return ids
+	}
+
+	// New tasks go to the bottom
+	require.Equal(t, []int64{a.ID, b.ID, c.ID}, column(todo.ID))
+
+	now := time.Now().UTC().Round(time.Millisecond)
+
+	moved, err := taskRepo.MoveTask(eCtx, c.ID, todo.ID, todo.ID, nil, nil, now)
+	require.NoError(t, err)
+	require.Equal(t, []int64{c.ID, a.ID, b.ID}, column(todo.ID))
+
+	// Only the moved task gets a new rank
+	a2, err := taskRepo.TaskByID(eCtx, a.ID)
+	require.NoError(t, err)
+	require.Equal(t, a.Rank, a2.Rank)
+	require.Less(t, moved.Rank, a.Rank)
+
+	_, err = taskRepo.MoveTask(eCtx, c.ID, todo.ID, todo.ID, &a.ID, nil, now)
+	require.NoError(t, err)
+	require.Equal(t, []int64{a.ID, c.ID, b.ID}, column(todo.ID))
+
+	// Moving to another column changes status
+	moved, err = taskRepo.MoveTask(eCtx, b.ID, todo.ID, inProgress.ID, nil, nil, now)
+	require.NoError(t, err)
+	require.Equal(t, inProgress.ID, moved.StatusID)
+	require.Equal(t, []int64{a.ID, c.ID}, column(todo.ID))
+	require.Equal(t, []int64{b.ID}, column(inProgress.ID))
+
+	_, err = taskRepo.MoveTask(eCtx, a.ID, todo.ID, inProgress.ID, &c.ID, nil, now)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	_, err = taskRepo.MoveTask(eCtx, a.ID, inProgress.ID, todo.ID, nil, nil, now)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	// Transitions append to the new column
+	_, err = taskRepo.SetTaskStatus(eCtx, a.ID, todo.ID, inProgress.ID, nil, now)
+	require.NoError(t, err)
+	require.Equal(t, []int64{b.ID, a.ID}, column(inProgress.ID))
+
+	// Concurrent moves to the top of a column keep ranks distinct
+	var wg sync.WaitGroup
+
+	errs := make([]error, 2)
+
+	for i, id := range []int64{a.ID, b.ID} {
+		wg.Add(1)
+
+		go func(i int, id int64) {
+			defer wg.Done()
+
+			_, errs[i] = taskRepo.MoveTask(eCtx, id, inProgress.ID, todo.ID, nil, nil, now)
+		}(i, id)
+	}
+
+	wg.Wait()
+	require.NoError(t, errors.Join(errs...))
+
+	board, err := taskRepo.BoardTasks(eCtx, project.ID)
+	require.NoError(t, err)
+
+	ranks := map[string]bool{}
+	for _, task := range board {
+		if task.StatusID == todo.ID {
+			ranks[task.Rank] = true
+		}
+	}
+	require.Len(t, ranks, 3)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Sprints(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	sprintRepo := NewSprintRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Round(time.Millisecond)
+
+	first, err := sprintRepo.CreateSprint(eCtx, entity.Sprint{
+		ProjectID: project.ID,
+		Name:      "first",
+		StartAt:   createdAt,
+		EndAt:     createdAt.AddDate(0, 0, 14),
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	second, err := sprintRepo.CreateSprint(eCtx, entity.Sprint{
+		ProjectID: project.ID,
+		Name:      "second",
+		StartAt:   createdAt.AddDate(0, 0, 14),
+		EndAt:     createdAt.AddDate(0, 0, 28),
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	_, err = sprintRepo.SprintByID(eCtx, project.ID+1, first.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	sprints, err := sprintRepo.ProjectSprints(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Sprint{first, second}, sprints)
+
+	var tasks []entity.Task
+
+	for i := 0; i < 3; i++ {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:      uuid.NewString(),
+			UserID:    user.ID,
+			ProjectID: project.ID,
+			CreatedAt: createdAt,
+		})
+		require.NoError(t, err)
+
+		tasks = append(tasks, task)
+	}
+
+	// tasks[0] and tasks[1] are planned into the first sprint, tasks[0] gets done
+	plannedAt := createdAt.Add(time.Minute)
+
+	for _, task := range tasks[:2] {
+		task, err = taskRepo.SetTaskSprint(eCtx, task.ID, &first.ID, plannedAt)
+		require.NoError(t, err)
+		require.Equal(t, &first.ID, task.SprintID)
+	}
+
+	doneAt := plannedAt.Add(time.Hour)
+
+	_, err = taskRepo.SetTaskStatus(eCtx, tasks[0].ID, tasks[0].StatusID, tasks[0].StatusID, &doneAt, doneAt)
+	require.NoError(t, err)
+
+	taskIDs := func(f entity.TaskFilter) []int64 {
+		page, err := taskRepo.ProjectTasks(eCtx, project.ID, f)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, task := range page.Items {
+			ids = append(ids, task.ID)
+		}
+
+		return ids
+	}
+
+	sortByID := entity.ListQuery{Sort: "id"}
+
+	require.Equal(t, []int64{tasks[0].ID, tasks[1].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, SprintID: &first.ID}))
+	require.Equal(t, []int64{tasks[2].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, Backlog: true}))
+
+	// Closing rolls the unfinished task over
+	closedAt := doneAt.Add(time.Hour)
+
+	closed, moved, err := sprintRepo.CloseSprint(eCtx, project.ID, first.ID, &second.ID, closedAt)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, moved)
+	require.True(t, closedAt.Equal(*closed.ClosedAt))
+
+	_, _, err = sprintRepo.CloseSprint(eCtx, project.ID, first.ID, nil, closedAt)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	// Tasks can't roll into a closed sprint
+	_, _, err = sprintRepo.CloseSprint(eCtx, project.ID, second.ID, &first.ID, closedAt)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	require.Equal(t, []int64{tasks[0].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, SprintID: &first.ID}))
+	require.Equal(t, []int64{tasks[1].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, SprintID: &second.ID}))
+
+	history, err := sprintRepo.SprintHistory(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, []entity.TaskChange{
+		{TaskID: tasks[0].ID, ChangedAt: createdAt},
+		{TaskID: tasks[0].ID, SprintID: &first.ID, ChangedAt: plannedAt},
+		{TaskID: tasks[0].ID, SprintID: &first.ID, Completed: true, ChangedAt: doneAt},
+		{TaskID: tasks[1].ID, ChangedAt: createdAt},
+		{TaskID: tasks[1].ID, SprintID: &first.ID, ChangedAt: plannedAt},
+		{TaskID: tasks[1].ID, SprintID: &second.ID, ChangedAt: closedAt},
+	}, utcChanges(history))
+
+	// Deleting sends tasks back to the backlog at the time of deletion
+	deletedAt := closedAt.Add(time.Hour)
+
+	err = sprintRepo.DeleteSprint(eCtx, project.ID, second.ID, deletedAt)
+	require.NoError(t, err)
+
+	err = sprintRepo.DeleteSprint(eCtx, project.ID, second.ID, deletedAt)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	require.Equal(t, []int64{tasks[1].ID, tasks[2].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, Backlog: true}))
+
+	history, err = sprintRepo.SprintHistory(eCtx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, entity.TaskChange{TaskID: tasks[1].ID, ChangedAt: deletedAt}, utcChanges(history)[len(history)-1])
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func utcChanges(changes []entity.TaskChange) []entity.TaskChange {
+	for i := range changes {
+		changes[i].ChangedAt = changes[i].ChangedAt.UTC()
+	}
+
+	return changes
+}
+
+func TestRepository_TimeEntries(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	timeRepo := NewTimeEntryRepository(db)
+
+	var users []entity.User
+
+	for i := 0; i < 2; i++ {
+		user, err := userRepo.CreateUser(eCtx, entity.User{
+			Name:      uuid.NewString(),
+			Password:  uuid.NewString(),
+			Email:     uuid.NewString(),
+			CreatedAt: time.Now().UTC().Round(time.Millisecond),
+		})
+		require.NoError(t, err)
+
+		users = append(users, user)
+	}
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    users[0].ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Round(time.Millisecond)
+	estimate := 120
+
+	task, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:            uuid.NewString(),
+		UserID:          users[0].ID,
+		ProjectID:       project.ID,
+		EstimateMinutes: &estimate,
+		CreatedAt:       createdAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, &estimate, task.EstimateMinutes)
+
+	entries := []entity.TimeEntry{
+		{TaskID: task.ID, UserID: users[0].ID, Date: "2024-03-01", Minutes: 30, Note: "review"},
+		{TaskID: task.ID, UserID: users[0].ID, Date: "2024-03-02", Minutes: 45},
+		{TaskID: task.ID, UserID: users[1].ID, Date: "2024-03-02", Minutes: 60},
+	}
+
+	for i, e := range entries {
+		e.CreatedAt = createdAt
+
+		entries[i], err = timeRepo.CreateTimeEntry(eCtx, e)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, task.Name, entries[0].TaskName)
+	require.Equal(t, users[0].Name, entries[0].UserName)
+
+	task, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Equal(t, 135, task.SpentMinutes)
+
+	// Only one timer runs per user
+	startedAt := createdAt.Add(-90 * time.Second)
+
+	timer, err := timeRepo.CreateTimeEntry(eCtx, entity.TimeEntry{TaskID: task.ID, UserID: users[0].ID, Date: "2024-03-02", StartedAt: &startedAt, CreatedAt: startedAt})
+	require.NoError(t, err)
+	require.True(t, timer.Running)
+
+	_, err = timeRepo.CreateTimeEntry(eCtx, entity.TimeEntry{TaskID: task.ID, UserID: users[0].ID, Date: "2024-03-02", StartedAt: &startedAt, CreatedAt: startedAt})
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	running, err := timeRepo.RunningTimer(eCtx, users[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, timer.ID, running.ID)
+
+	f := entity.TimeFilter{DateRange: entity.DateRange{From: "2024-03-02", To: "2024-03-02"}, ProjectID: &project.ID}
+
+	totals, err := timeRepo.TimeTotals(eCtx, f, entity.TimeByUser)
+	require.NoError(t, err)
+	require.Equal(t, []entity.TimeTotal{
+		{ID: users[1].ID, Name: users[1].Name, Minutes: 60},
+		{ID: users[0].ID, Name: users[0].Name, Minutes: 45},
+	}, totals)
+
+	timer, err = timeRepo.StopTimer(eCtx, users[0].ID, createdAt, 24*60)
+	require.NoError(t, err)
+	require.False(t, timer.Running)
+	require.Equal(t, 2, timer.Minutes)
+
+	_, err = timeRepo.StopTimer(eCtx, users[0].ID, createdAt, 24*60)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	totals, err = timeRepo.TimeTotals(eCtx, f, entity.TimeByProject)
+	require.NoError(t, err)
+	require.Equal(t, []entity.TimeTotal{{ID: project.ID, Name: project.Name, Minutes: 107}}, totals)
+
+	userID := users[0].ID
+
+	list, err := timeRepo.TimeEntries(eCtx, entity.TimeFilter{ProjectID: &project.ID, UserID: &userID})
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.Equal(t, "2024-03-01", list[0].Date)
+
+	minutes := 50
+
+	entry, err := timeRepo.UpdateTimeEntry(eCtx, entries[1].ID, entity.TimeEntryToUpdate{Minutes: &minutes})
+	require.NoError(t, err)
+	require.Equal(t, 50, entry.Minutes)
+	require.Equal(t, "2024-03-02", entry.Date)
+
+	err = timeRepo.DeleteTimeEntry(eCtx, entries[1].ID)
+	require.NoError(t, err)
+
+	err = timeRepo.DeleteTimeEntry(eCtx, entries[1].ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	// A timer left running over a weekend logs no more than the cap
+	startedAt = createdAt.Add(-72 * time.Hour)
+
+	_, err = timeRepo.CreateTimeEntry(eCtx, entity.TimeEntry{TaskID: task.ID, UserID: users[1].ID, Date: "2024-03-01", StartedAt: &startedAt, CreatedAt: startedAt})
+	require.NoError(t, err)
+
+	timer, err = timeRepo.StopTimer(eCtx, users[1].ID, createdAt, 24*60)
+	require.NoError(t, err)
+	require.Equal(t, 24*60, timer.Minutes)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_Recurrences(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	recurrenceRepo := NewRecurrenceRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Round(time.Millisecond)
+
+	template, err := taskRepo.CreateTask(eCtx, entity.Task{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		ProjectID: project.ID,
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	// occurrences long in the past keep other recurrences of the database out of the claims
+	startAt := time.Date(1990, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	rec, err := recurrenceRepo.SetRecurrence(eCtx, entity.Recurrence{
+		TaskID:    template.ID,
+		Rule:      "FREQ=DAILY",
+		StartAt:   startAt,
+		TimeZone:  "UTC",
+		NextAt:    &startAt,
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	now := startAt.Add(time.Hour)
+
+	recs, err := recurrenceRepo.ClaimDueRecurrences(eCtx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Equal(t, []entity.Recurrence{rec}, recs)
+
+	// claimed recurrences are leased
+	recs, err = recurrenceRepo.ClaimDueRecurrences(eCtx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Empty(t, recs)
+
+	task := entity.Task{
+		Name:         template.Name,
+		UserID:       user.ID,
+		ProjectID:    project.ID,
+		RecurrenceID: &rec.ID,
+		OccurrenceAt: &startAt,
+		CreatedAt:    createdAt,
+	}
+
+	task, err = taskRepo.CreateTask(eCtx, task)
+	require.NoError(t, err)
+	require.Equal(t, &rec.ID, task.RecurrenceID)
+
+	_, err = taskRepo.CreateTask(eCtx, task)
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	nextAt := startAt.AddDate(0, 0, 1)
+
+	err = recurrenceRepo.AdvanceRecurrence(eCtx, rec.ID, startAt, &nextAt)
+	require.NoError(t, err)
+
+	// a late scheduler doesn't move the recurrence back
+	err = recurrenceRepo.AdvanceRecurrence(eCtx, rec.ID, startAt, &startAt)
+	require.NoError(t, err)
+
+	rec, err = recurrenceRepo.RecurrenceByTask(eCtx, template.ID)
+	require.NoError(t, err)
+	require.True(t, nextAt.Equal(*rec.NextAt))
+
+	recs, err = recurrenceRepo.ClaimDueRecurrences(eCtx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Empty(t, recs)
+
+	recs, err = recurrenceRepo.ClaimDueRecurrences(eCtx, nextAt, nextAt.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+
+	// deleting the template keeps its tasks
+	err = taskRepo.DeleteTask(eCtx, template.ID, "")
+	require.NoError(t, err)
+
+	_, err = recurrenceRepo.RecurrenceByTask(eCtx, template.ID)
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	task, err = taskRepo.TaskByID(eCtx, task.ID)
+	require.NoError(t, err)
+	require.Nil(t, task.RecurrenceID)
+
+	err = repo.DeleteProject(eCtx, project.ID)
+	require.NoError(t, err)
+}
+
+func TestRepository_CustomFields(t *testing.T) {
+	cfg := &bootstrap.Config{
+		DBHost:     "localhost",
+		DBPort:     "5433",
+		DBUser:     "postgres",
+		DBPassword: "postgres",
+		DBName:     "postgres",
+	}
+
+	db, err := bootstrap.DBConnect(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	repo := NewProjectRepository(db)
+	taskRepo := NewTaskRepository(db)
+	fieldRepo := NewCustomFieldRepository(db)
+
+	user, err := userRepo.CreateUser(eCtx, entity.User{
+		Name:      uuid.NewString(),
+		Password:  uuid.NewString(),
+		Email:     uuid.NewString(),
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	project, err := repo.CreateProject(eCtx, entity.Project{
+		Name:      uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: time.Now().UTC().Round(time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC().Round(time.Millisecond)
+
+	points, err := fieldRepo.CreateCustomField(eCtx, entity.CustomField{
+		ProjectID: project.ID,
+		Name:      "Points",
+		Type:      entity.FieldNumber,
+		Options:   []string{},
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	tags, err := fieldRepo.CreateCustomField(eCtx, entity.CustomField{
+		ProjectID: project.ID,
+		Name:      "Tags",
+		Type:      entity.FieldMultiSelect,
+		Options:   []string{"api", "ui", "db"},
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	_, err = fieldRepo.CreateCustomField(eCtx, entity.CustomField{
+		ProjectID: project.ID,
+		Name:      "points",
+		Type:      entity.FieldText,
+		Options:   []string{},
+		CreatedAt: createdAt,
+	})
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	fields, err := fieldRepo.ProjectCustomFields(eCtx, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, []entity.CustomField{points, tags}, fields)
+
+	var tasks []entity.Task
+
+	for _, values := range []entity.CustomFieldValues{
+		{"Points": json.RawMessage("3"), "Tags": json.RawMessage(`["api","ui"]`)},
+		{"Points": json.RawMessage("1.5")},
+		{"Tags": json.RawMessage(`["db"]`)},
+	} {
+		task, err := taskRepo.CreateTask(eCtx, entity.Task{
+			Name:         uuid.NewString(),
+			UserID:       user.ID,
+			ProjectID:    project.ID,
+			CustomFields: values,
+			CreatedAt:    createdAt,
+		})
+		require.NoError(t, err)
+
+		tasks = append(tasks, task)
+	}
+
+	require.JSONEq(t, "3", string(tasks[0].CustomFields["Points"]))
+	require.JSONEq(t, `["api","ui"]`, string(tasks[0].CustomFields["Tags"]))
+	require.NotContains(t, tasks[1].CustomFields, "Tags")
+
+	taskIDs := func(f entity.TaskFilter) []int64 {
+		page, err := taskRepo.ProjectTasks(eCtx, project.ID, f)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, task := range page.Items {
+			ids = append(ids, task.ID)
+		}
+
+		return ids
+	}
+
+	sortByID := entity.ListQuery{Sort: "id"}
+
+	require.Equal(t, []int64{tasks[0].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, CustomFields: []entity.CustomFieldCondition{
+		{Field: points, Op: ">=", Values: []string{"2"}},
+	}}))
+	require.Equal(t, []int64{tasks[0].ID, tasks[2].ID}, taskIDs(entity.TaskFilter{ListQuery: sortByID, CustomFields: []entity.CustomFieldCondition{
+		{Field: tags, Op: "=", Values: []string{"ui", "db"}},
+	}}))
+
+	// tasks without a number go last, pages continue after the cursor
+	sortByPoints := entity.TaskFilter{ListQuery: entity.ListQuery{Sort: "cf.Points", Limit: 2}, SortField: &points}
+
+	page, err := taskRepo.ProjectTasks(eCtx, project.ID, sortByPoints)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	require.Equal(t, tasks[1].ID, page.Items[0].ID)
+	require.Equal(t, tasks[0].ID, page.Items[1].ID)
+
+	sortByPoints.After = page.NextCursor
+	require.Equal(t, []int64{tasks[2].ID}, taskIDs(sortByPoints))
+
+	// null removes a value, other values are kept
+	task, err := taskRepo.UpdateTask(eCtx, tasks[0].ID, entity.TaskToUpdate{
+		CustomFields: entity.CustomFieldValues{"Points": json.RawMessage("null")},
+	}, createdAt.Add(time.Minute))
+	require.NoError(t, err)
+	require.NotContains(t, task.CustomFields, "Points")
+	require.JSONEq(t, `["api","ui"]`, string(task.CustomFields["Tags"]))
+
+	// dropped options are removed from tasks, renaming keeps the values
+	name := "Areas"
+	options := []string{"ui", "web"}
+
+	tags, err = fieldRepo.UpdateCustomField(eCtx, project.ID, tags.ID, entity.CustomFieldToUpdate{Name: &name, Options: &options}, createdAt.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "Areas", tags.Name)
+
+	task, err = taskRepo.TaskByID(eCtx, tasks[0].ID)
+	require.NoError(t, err)
+	require.JSONEq(t, `["ui"]`, string(task.CustomFields["Areas"]))
+	require.Equal(t, createdAt.Add(time.Hour), task.UpdatedAt.UTC())
+
+	task, err = taskRepo.TaskByID(eCtx, tasks[2].ID)
+	require.NoError(t, err)
+	require.NotContains(t, task.CustomFields, "Areas")
+
+	err = fieldRepo.DeleteCustomField(eCtx, project.ID, points.ID, createdAt.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	task, err = taskRepo.TaskByID(eCtx, tasks[1].ID)
+	require.NoError(t, err)
+	require.Empty(t, task.CustomFields)
+
+	err = fieldRepo.DeleteCustomField(eCtx, project.ID, points.ID, createdAt.Add(2*time.Hour))
+	require.ErrorIs(t, err, entity.ErrNotFound)
+}
+
+func TestRankBetween(t *testing.T) {
+	require.Equal(t, "V", rankBetween("", ""))
+	require.Equal(t, "W", rankBetween("V", ""))
+	require.Equal(t, "U", rankBetween("", "V"))
+	require.Equal(t, "0V", rankBetween("", "1"))
+	require.Equal(t, "VV", rankBetween("V", "W"))
+	require.Equal(t, "zzV", rankBetween("zz", ""))
+
+	// inserting at random places keeps keys ordered and never ending with the lowest digit
+	r := rand.New(rand.NewSource(1))
+	keys := []string{}
+
+	for i := 0; i < 1000; i++ {
+		pos := r.Intn(len(keys) + 1)
+
+		var prev, next string
+		if pos > 0 {
+			prev = keys[pos-1]
+		}
+		if pos < len(keys) {
+			next = keys[pos]
+		}
+
+		key := rankBetween(prev, next)
+		require.Less(t, prev, key)
+		if next != "" {
+			require.Less(t, key, next)
+		}
+		require.NotEqual(t, byte('0'), key[len(key)-1])
+
+		keys = append(keys[:pos], append([]string{key}, keys[pos:]...)...)
+	}
+}
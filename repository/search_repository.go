@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"restAPI/entity"
+)
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+const (
+	titleHeadline   = "StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE"
+	snippetHeadline = "StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=5, MaxFragments=2"
+)
+
+// Search returns tasks and projects of projects userID is a member of, best matches first.
+// Headlines are made only for the returned rows since ts_headline is expensive.
+func (r *SearchRepository) Search(ctx context.Context, userID int64, sq entity.SearchQuery) (results []entity.SearchResult, err error) {
+	q := fmt.Sprintf(`WITH q AS (
+		SELECT websearch_to_tsquery('english', $2) AS query
+	), matches AS (
+		SELECT 'task' AS type, t.id, t.project_id, t.name, t.description, ts_rank(t.search_vector, q.query) AS rank
+		FROM tasks t
+		    JOIN projects_users pu ON pu.project_id = t.project_id AND pu.user_id = $1
+		    CROSS JOIN q
+		WHERE t.search_vector @@ q.query AND $3 IN ('', 'task')
+		UNION ALL
+		SELECT 'project', p.id, p.id, p.name, p.description, ts_rank(p.search_vector, q.query)
+		FROM projects p
+		    JOIN projects_users pu ON pu.project_id = p.id AND pu.user_id = $1
+		    CROSS JOIN q
+		WHERE p.search_vector @@ q.query AND $3 IN ('', 'project')
+		ORDER BY rank DESC, type, id
+		LIMIT $4
+	)
+	SELECT m.type, m.id, m.project_id,
+	       ts_headline('english', %s, q.query, '%s'),
+	       ts_headline('english', %s, q.query, '%s'),
+	       m.rank
+	FROM matches m CROSS JOIN q
+	ORDER BY m.rank DESC, m.type, m.id`,
+		escapeHTML("m.name"), titleHeadline, escapeHTML("m.description"), snippetHeadline)
+
+	rows, err := r.db.QueryContext(ctx, q, userID, sq.Text, sq.Type, sq.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res entity.SearchResult
+
+		err = rows.Scan(&res.Type, &res.ID, &res.ProjectID, &res.Title, &res.Snippet, &res.Rank)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// escapeHTML returns SQL expression escaping HTML special characters of column,
+// so user text can't inject markup next to the <mark> tags of a headline.
+func escapeHTML(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;')`, column)
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+type SprintRepository struct {
+	db *sql.DB
+}
+
+func NewSprintRepository(db *sql.DB) *SprintRepository {
+	return &SprintRepository{db: db}
+}
+
+const sprintColumns = "id, project_id, name, goal, start_at, end_at, closed_at, created_at"
+
+func scanSprint(row scanner) (s entity.Sprint, err error) {
+	err = row.Scan(&s.ID, &s.ProjectID, &s.Name, &s.Goal, &s.StartAt, &s.EndAt, &s.ClosedAt, &s.CreatedAt)
+	return s, err
+}
+
+func (r *SprintRepository) CreateSprint(ctx context.Context, s entity.Sprint) (entity.Sprint, error) {
+	q := "INSERT INTO sprints(project_id, name, goal, start_at, end_at, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING " + sprintColumns
+
+	s, err := scanSprint(r.db.QueryRowContext(ctx, q, s.ProjectID, s.Name, s.Goal, s.StartAt, s.EndAt, s.CreatedAt))
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	return s, nil
+}
+
+// SprintByID returns sprint of project, sprints of other projects are not found.
+func (r *SprintRepository) SprintByID(ctx context.Context, projectID int64, id int64) (s entity.Sprint, err error) {
+	q := "SELECT " + sprintColumns + " FROM sprints WHERE id = $1 AND project_id = $2"
+
+	s, err = scanSprint(r.db.QueryRowContext(ctx, q, id, projectID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Sprint{}, fmt.Errorf("%w: sprint", entity.ErrNotFound)
+		}
+
+		return s, err
+	}
+
+	return s, nil
+}
+
+// ProjectSprints returns sprints of project in order of their start.
+func (r *SprintRepository) ProjectSprints(ctx context.Context, projectID int64) (sprints []entity.Sprint, err error) {
+	q := "SELECT " + sprintColumns + " FROM sprints WHERE project_id = $1 ORDER BY start_at, id"
+
+	rows, err := r.db.QueryContext(ctx, q, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s, err := scanSprint(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sprints = append(sprints, s)
+	}
+
+	return sprints, nil
+}
+
+func (r *SprintRepository) UpdateSprint(ctx context.Context, projectID int64, id int64, upd entity.SprintToUpdate) (s entity.Sprint, err error) {
+	q := `UPDATE sprints
+		SET name = COALESCE($3, name),
+		    goal = COALESCE($4, goal),
+		    start_at = COALESCE($5, start_at),
+		    end_at = COALESCE($6, end_at)
+		WHERE id = $1 AND project_id = $2
+		RETURNING ` + sprintColumns
+
+	s, err = scanSprint(r.db.QueryRowContext(ctx, q, id, projectID, upd.Name, upd.Goal, upd.StartAt, upd.EndAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Sprint{}, fmt.Errorf("%w: sprint", entity.ErrNotFound)
+		}
+
+		return s, err
+	}
+
+	return s, nil
+}
+
+// DeleteSprint deletes sprint of project, its tasks go back to the backlog. Tasks are moved out
+// before the delete, so their history records the move at updatedAt.
+func (r *SprintRepository) DeleteSprint(ctx context.Context, projectID int64, id int64, updatedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := "UPDATE tasks SET sprint_id = NULL, updated_at = $3 WHERE sprint_id = $1 AND project_id = $2"
+
+	_, err = tx.ExecContext(ctx, q, id, projectID, updatedAt)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM sprints WHERE id = $1 AND project_id = $2", id, projectID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: sprint", entity.ErrNotFound)
+	}
+
+	return tx.Commit()
+}
+
+// CloseSprint closes sprint of project and moves its unfinished tasks to nextSprintID,
+// nil nextSprintID moves them to the backlog. It returns the closed sprint and the number of moved tasks.
+func (r *SprintRepository) CloseSprint(ctx context.Context, projectID int64, id int64, nextSprintID *int64, closedAt time.Time) (s entity.Sprint, moved int64, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Sprint{}, 0, err
+	}
+	defer tx.Rollback()
+
+	// both sprints are locked in the order of ids, so closing them into each other can't deadlock
+	q := "SELECT " + sprintColumns + " FROM sprints WHERE id IN ($1, $3) AND project_id = $2 ORDER BY id FOR UPDATE"
+
+	rows, err := tx.QueryContext(ctx, q, id, projectID, nextSprintID)
+	if err != nil {
+		return entity.Sprint{}, 0, err
+	}
+	defer rows.Close()
+
+	var next *entity.Sprint
+
+	for rows.Next() {
+		sprint, err := scanSprint(rows)
+		if err != nil {
+			return entity.Sprint{}, 0, err
+		}
+
+		if sprint.ID == id {
+			s = sprint
+		} else {
+			next = &sprint
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return entity.Sprint{}, 0, err
+	}
+
+	if s.ID == 0 {
+		return entity.Sprint{}, 0, fmt.Errorf("%w: sprint", entity.ErrNotFound)
+	}
+
+	if s.ClosedAt != nil {
+		return entity.Sprint{}, 0, fmt.Errorf("%w: sprint is already closed", entity.ErrConflict)
+	}
+
+	if nextSprintID != nil {
+		if next == nil {
+			return entity.Sprint{}, 0, fmt.Errorf("%w: next sprint", entity.ErrNotFound)
+		}
+
+		if next.ClosedAt != nil {
+			return entity.Sprint{}, 0, fmt.Errorf("%w: next sprint is closed", entity.ErrConflict)
+		}
+	}
+
+	q = "UPDATE tasks SET sprint_id = $2, updated_at = $3 WHERE sprint_id = $1 AND completed_at IS NULL"
+
+	res, err := tx.ExecContext(ctx, q, id, nextSprintID, closedAt)
+	if err != nil {
+		return entity.Sprint{}, 0, err
+	}
+
+	moved, err = res.RowsAffected()
+	if err != nil {
+		return entity.Sprint{}, 0, err
+	}
+
+	q = "UPDATE sprints SET closed_at = $2 WHERE id = $1 RETURNING " + sprintColumns
+
+	s, err = scanSprint(tx.QueryRowContext(ctx, q, id, closedAt))
+	if err != nil {
+		return entity.Sprint{}, 0, err
+	}
+
+	return s, moved, tx.Commit()
+}
+
+// SprintHistory returns the whole history of tasks which have ever been in sprint,
+// ordered by task and time of change.
+func (r *SprintRepository) SprintHistory(ctx context.Context, sprintID int64) (changes []entity.TaskChange, err error) {
+	q := `SELECT task_id, sprint_id, completed, changed_at FROM task_history
+		WHERE task_id IN (SELECT task_id FROM task_history WHERE sprint_id = $1)
+		ORDER BY task_id, changed_at, id`
+
+	rows, err := r.db.QueryContext(ctx, q, sprintID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c entity.TaskChange
+
+		err = rows.Scan(&c.TaskID, &c.SprintID, &c.Completed, &c.ChangedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
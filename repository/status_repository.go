@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"restAPI/entity"
+	"time"
+)
+
+type StatusRepository struct {
+	db *sql.DB
+}
+
+func NewStatusRepository(db *sql.DB) *StatusRepository {
+	return &StatusRepository{db: db}
+}
+
+func (r *StatusRepository) Workflow(ctx context.Context, projectID int64) (w entity.Workflow, err error) {
+	q := "SELECT id, project_id, name, position, is_terminal FROM project_statuses WHERE project_id = $1 ORDER BY position, id"
+
+	rows, err := r.db.QueryContext(ctx, q, projectID)
+	if err != nil {
+		return entity.Workflow{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s entity.Status
+
+		err = rows.Scan(&s.ID, &s.ProjectID, &s.Name, &s.Position, &s.Terminal)
+		if err != nil {
+			return entity.Workflow{}, err
+		}
+
+		w.Statuses = append(w.Statuses, s)
+	}
+
+	q = "SELECT from_status_id, to_status_id FROM project_status_transitions WHERE project_id = $1"
+
+	rows, err = r.db.QueryContext(ctx, q, projectID)
+	if err != nil {
+		return entity.Workflow{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t entity.StatusTransition
+
+		err = rows.Scan(&t.FromStatusID, &t.ToStatusID)
+		if err != nil {
+			return entity.Workflow{}, err
+		}
+
+		w.Transitions = append(w.Transitions, t)
+	}
+
+	return w, nil
+}
+
+// CreateStatus adds status to project workflow, status without position goes last.
+func (r *StatusRepository) CreateStatus(ctx context.Context, s entity.Status) (entity.Status, error) {
+	q := `INSERT INTO project_statuses(project_id, name, position, is_terminal)
+	VALUES ($1, $2, COALESCE(NULLIF($3::int, 0), (SELECT COALESCE(MAX(position), 0) + 1 FROM project_statuses WHERE project_id = $1)), $4)
+	RETURNING id, position`
+
+	err := r.db.QueryRowContext(ctx, q, s.ProjectID, s.Name, s.Position, s.Terminal).Scan(&s.ID, &s.Position)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Status{}, fmt.Errorf("%w: status %q already exists", entity.ErrConflict, s.Name)
+		}
+
+		return entity.Status{}, err
+	}
+
+	return s, nil
+}
+
+// UpdateStatus changes status of project workflow. When it becomes terminal, or stops being one,
+// tasks in it are completed or reopened in the same transaction.
+func (r *StatusRepository) UpdateStatus(ctx context.Context, projectID int64, id int64, upd entity.StatusToUpdate, updatedAt time.Time) (s entity.Status, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Status{}, err
+	}
+	defer tx.Rollback()
+
+	var terminal bool
+
+	err = tx.QueryRowContext(ctx, "SELECT is_terminal FROM project_statuses WHERE project_id = $1 AND id = $2 FOR UPDATE", projectID, id).Scan(&terminal)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Status{}, entity.ErrNotFound
+		}
+
+		return entity.Status{}, err
+	}
+
+	q := `UPDATE project_statuses
+	SET name = COALESCE($3, name),
+	    position = COALESCE($4, position),
+	    is_terminal = COALESCE($5, is_terminal)
+	WHERE project_id = $1 AND id = $2
+	RETURNING id, project_id, name, position, is_terminal`
+
+	err = tx.QueryRowContext(ctx, q, projectID, id, upd.Name, upd.Position, upd.Terminal).Scan(&s.ID, &s.ProjectID, &s.Name, &s.Position, &s.Terminal)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Status{}, fmt.Errorf("%w: status %q already exists", entity.ErrConflict, *upd.Name)
+		}
+
+		return entity.Status{}, err
+	}
+
+	if s.Terminal != terminal {
+		q = "UPDATE tasks SET completed_at = $2, updated_at = $2 WHERE status_id = $1 AND completed_at IS NULL"
+		if !s.Terminal {
+			q = "UPDATE tasks SET completed_at = NULL, updated_at = $2 WHERE status_id = $1 AND completed_at IS NOT NULL"
+		}
+
+		_, err = tx.ExecContext(ctx, q, id, updatedAt)
+		if err != nil {
+			return entity.Status{}, err
+		}
+	}
+
+	return s, tx.Commit()
+}
+
+// DeleteStatus deletes status which no task is in.
+func (r *StatusRepository) DeleteStatus(ctx context.Context, projectID int64, id int64) error {
+	q := "DELETE FROM project_statuses WHERE project_id = $1 AND id = $2"
+
+	res, err := r.db.ExecContext(ctx, q, projectID, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return fmt.Errorf("%w: status has tasks", entity.ErrConflict)
+		}
+
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return nil
+}
+
+// ReplaceTransitions replaces allowed transitions of project workflow.
+func (r *StatusRepository) ReplaceTransitions(ctx context.Context, projectID int64, transitions []entity.StatusTransition) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := "DELETE FROM project_status_transitions WHERE project_id = $1"
+
+	_, err = tx.ExecContext(ctx, q, projectID)
+	if err != nil {
+		return err
+	}
+
+	from := make([]int64, 0, len(transitions))
+	to := make([]int64, 0, len(transitions))
+
+	for _, t := range transitions {
+		from = append(from, t.FromStatusID)
+		to = append(to, t.ToStatusID)
+	}
+
+	q = `INSERT INTO project_status_transitions(project_id, from_status_id, to_status_id)
+	SELECT $1, t.from_id, t.to_id FROM unnest($2::bigint[], $3::bigint[]) AS t(from_id, to_id)
+	ON CONFLICT DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, q, projectID, pq.Array(from), pq.Array(to))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
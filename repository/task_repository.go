@@ -3,8 +3,34 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"maps"
 	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+// taskColumns lists columns scanned by scanTask, t is a tasks row and ts is its status.
+const taskColumns = "t.id, t.name, t.project_id, t.parent_task_id, t.sprint_id, t.recurrence_id, t.occurrence_at, t.description, t.user_id, t.assignee_id, t.status_id, ts.name, t.rank, t.priority, t.start_at, t.due_at, t.completed_at, t.created_at, t.updated_at, " +
+	"(SELECT count(*) FROM task_comments c WHERE c.task_id = t.id), t.estimate_minutes, " +
+	"(SELECT COALESCE(sum(e.minutes), 0) FROM time_entries e WHERE e.task_id = t.id), " +
+	"(SELECT count(*) FILTER (WHERE s.completed_at IS NOT NULL) FROM tasks s WHERE s.parent_task_id = t.id), " +
+	"(SELECT count(*) FROM tasks s WHERE s.parent_task_id = t.id), " +
+	"(SELECT count(*) FILTER (WHERE ci.done) FROM task_checklist_items ci WHERE ci.task_id = t.id), " +
+	"(SELECT count(*) FROM task_checklist_items ci WHERE ci.task_id = t.id), " +
+	"EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blocker_id WHERE d.blocked_id = t.id AND b.completed_at IS NULL), " +
+	"(SELECT COALESCE(json_agg(json_build_object('id', l.id, 'project_id', l.project_id, 'name', l.name, 'color', l.color, 'created_at', l.created_at) ORDER BY lower(l.name)), '[]') " +
+	"FROM task_labels tl JOIN labels l ON l.id = tl.label_id WHERE tl.task_id = t.id), " +
+	"(SELECT COALESCE(jsonb_object_agg(f.name, t.custom_fields -> f.id::text), '{}') FROM custom_fields f " +
+	"WHERE f.project_id = t.project_id AND t.custom_fields -> f.id::text IS NOT NULL)"
+
+const (
+	selectTasks = "SELECT " + taskColumns + " FROM tasks t JOIN project_statuses ts ON ts.id = t.status_id"
+	// selectChangedTask selects task rows returned by a data-modifying CTE named t.
+	selectChangedTask = "SELECT " + taskColumns + " FROM t JOIN project_statuses ts ON ts.id = t.status_id"
 )
 
 type TaskRepository struct {
@@ -15,21 +41,88 @@ func NewTaskRepository(db *sql.DB) *TaskRepository {
 	return &TaskRepository{db: db}
 }
 
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row scanner) (t entity.Task, err error) {
+	var labels, customFields []byte
+
+	err = row.Scan(&t.ID, &t.Name, &t.ProjectID, &t.ParentTaskID, &t.SprintID, &t.RecurrenceID, &t.OccurrenceAt, &t.Description, &t.UserID, &t.AssigneeID, &t.StatusID, &t.Status, &t.Rank, &t.Priority, &t.StartAt, &t.DueAt, &t.CompletedAt, &t.CreatedAt, &t.UpdatedAt, &t.CommentCount,
+		&t.EstimateMinutes, &t.SpentMinutes, &t.SubtaskProgress.Done, &t.SubtaskProgress.Total, &t.ChecklistProgress.Done, &t.ChecklistProgress.Total, &t.Blocked, &labels, &customFields)
+	if err != nil {
+		return t, err
+	}
+
+	err = json.Unmarshal(labels, &t.Labels)
+	if err != nil {
+		return t, err
+	}
+
+	err = json.Unmarshal(customFields, &t.CustomFields)
+	return t, err
+}
+
+// CreateTask creates task at the bottom of its column, task without StatusID gets the first status of the project workflow.
+// A second task for the same occurrence of a recurrence fails with entity.ErrConflict.
 func (r *TaskRepository) CreateTask(ctx context.Context, t entity.Task) (entity.Task, error) {
-	q := "INSERT INTO tasks (name, project_id, description, user_id, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Task{}, err
+	}
+	defer tx.Rollback()
 
-	err := r.db.QueryRowContext(ctx, q, t.Name, t.ProjectID, t.Description, t.UserID, t.CreatedAt).Scan(&t.ID)
+	err = lockBoard(ctx, tx, t.ProjectID)
 	if err != nil {
 		return entity.Task{}, err
 	}
 
-	return t, nil
+	q := "SELECT COALESCE(NULLIF($2::bigint, 0), (SELECT id FROM project_statuses WHERE project_id = $1 ORDER BY position LIMIT 1))"
+
+	var statusID sql.NullInt64
+
+	err = tx.QueryRowContext(ctx, q, t.ProjectID, t.StatusID).Scan(&statusID)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	rank, err := columnEndRank(ctx, tx, statusID.Int64)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	q = `WITH t AS (
+		INSERT INTO tasks (name, project_id, description, user_id, assignee_id, status_id, priority, start_at, due_at, created_at, updated_at, parent_task_id, rank, estimate_minutes, recurrence_id, occurrence_at, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'none'), $8, $9, $10, $11, $12, $13, NULLIF($14::int, 0), $15, $16,
+		        ` + customFieldsByID("COALESCE($17::jsonb, '{}')", "$2") + `)
+		RETURNING *
+	) ` + selectChangedTask
+
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = t.CreatedAt
+	}
+
+	customFields, err := customFieldValues(t.CustomFields)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	t, err = scanTask(tx.QueryRowContext(ctx, q, t.Name, t.ProjectID, t.Description, t.UserID, t.AssigneeID, statusID, t.Priority, t.StartAt, t.DueAt, t.CreatedAt, t.UpdatedAt, t.ParentTaskID, rank, t.EstimateMinutes, t.RecurrenceID, t.OccurrenceAt, customFields))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.Task{}, fmt.Errorf("%w: the occurrence already has a task", entity.ErrConflict)
+		}
+
+		return entity.Task{}, err
+	}
+
+	return t, tx.Commit()
 }
 
 func (r *TaskRepository) TaskByID(ctx context.Context, id int64) (t entity.Task, err error) {
-	q := "SELECT id, name, project_id, description, user_id, created_at FROM tasks WHERE id = $1"
+	q := selectTasks + " WHERE t.id = $1"
 
-	err = r.db.QueryRowContext(ctx, q, id).Scan(&t.ID, &t.Name, &t.ProjectID, &t.Description, &t.UserID, &t.CreatedAt)
+	t, err = scanTask(r.db.QueryRowContext(ctx, q, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.Task{}, entity.ErrNotFound
@@ -41,42 +134,514 @@ func (r *TaskRepository) TaskByID(ctx context.Context, id int64) (t entity.Task,
 	return t, nil
 }
 
-func (r *TaskRepository) ProjectTasks(ctx context.Context, projectID int64) (tasks []entity.Task, err error) {
-	q := "SELECT id, name, project_id, description, user_id, created_at FROM tasks WHERE project_id = $1"
+// taskList lists fields tasks can be sorted by, tasks without a due date go last.
+var taskList = listSpec[entity.Task]{
+	id:      "t.id",
+	itemID:  func(t entity.Task) int64 { return t.ID },
+	created: "t.created_at",
+	name:    "t.name",
+	sorts: map[string]sortKey[entity.Task]{
+		"id":         {expr: "t.id", cast: "bigint", value: func(t entity.Task) any { return t.ID }},
+		"name":       {expr: "t.name", cast: "text", value: func(t entity.Task) any { return t.Name }},
+		"created_at": {expr: "t.created_at", cast: "timestamptz", value: func(t entity.Task) any { return t.CreatedAt }},
+		"updated_at": {expr: "t.updated_at", cast: "timestamptz", value: func(t entity.Task) any { return t.UpdatedAt }},
+		"due_at": {expr: "COALESCE(t.due_at, 'infinity')", cast: "timestamptz", value: func(t entity.Task) any {
+			if t.DueAt == nil {
+				return "infinity"
+			}
+			return *t.DueAt
+		}},
+		"priority": {
+			expr:  "array_position(ARRAY['none', 'low', 'medium', 'high', 'urgent'], t.priority) - 1",
+			cast:  "int",
+			value: func(t entity.Task) any { return t.Priority.Rank() },
+		},
+	},
+}
+
+func (r *TaskRepository) ProjectTasks(ctx context.Context, projectID int64, f entity.TaskFilter) (entity.Page[entity.Task], error) {
+	q := &listQuery{}
+	q.where("t.project_id = ?", projectID)
+
+	return r.taskPage(ctx, q, f)
+}
+
+// UserTasks returns tasks created by user in projects user is still a member of.
+func (r *TaskRepository) UserTasks(ctx context.Context, userID int64, f entity.TaskFilter) (entity.Page[entity.Task], error) {
+	q := &listQuery{}
+	q.where("t.user_id = ?", userID)
+	q.where("EXISTS (SELECT 1 FROM projects_users pu WHERE pu.project_id = t.project_id AND pu.user_id = ?)", userID)
+
+	return r.taskPage(ctx, q, f)
+}
+
+func (r *TaskRepository) AssignedTasks(ctx context.Context, userID int64, f entity.TaskFilter) (entity.Page[entity.Task], error) {
+	q := &listQuery{}
+	q.where("t.assignee_id = ?", userID)
+
+	return r.taskPage(ctx, q, f)
+}
+
+// taskPage applies f to q and returns a page of matching tasks.
+func (r *TaskRepository) taskPage(ctx context.Context, q *listQuery, f entity.TaskFilter) (entity.Page[entity.Task], error) {
+	if f.Open {
+		q.where("t.completed_at IS NULL")
+	}
+
+	if f.DueAfter != nil {
+		q.where("t.due_at >= ?", *f.DueAfter)
+	}
+
+	if f.DueBefore != nil {
+		q.where("t.due_at < ?", *f.DueBefore)
+	}
+
+	if len(f.Priorities) > 0 {
+		priorities := make([]string, 0, len(f.Priorities))
+		for _, p := range f.Priorities {
+			priorities = append(priorities, string(p))
+		}
+
+		q.where("t.priority = ANY(?)", pq.Array(priorities))
+	}
+
+	if f.Status != "" {
+		q.where("ts.name = ?", f.Status)
+	}
+
+	if f.AssigneeID != nil {
+		q.where("t.assignee_id = ?", *f.AssigneeID)
+	}
+
+	if f.Unassigned {
+		q.where("t.assignee_id IS NULL")
+	}
 
-	rows, err := r.db.QueryContext(ctx, q, projectID)
+	if f.SprintID != nil {
+		q.where("t.sprint_id = ?", *f.SprintID)
+	}
+
+	if f.Backlog {
+		q.where("t.sprint_id IS NULL")
+	}
+
+	if len(f.Labels) > 0 {
+		names := make([]string, 0, len(f.Labels))
+		for _, name := range f.Labels {
+			names = append(names, strings.ToLower(name))
+		}
+
+		tagged := "SELECT count(DISTINCT lower(l.name)) FROM task_labels tl JOIN labels l ON l.id = tl.label_id " +
+			"WHERE tl.task_id = t.id AND lower(l.name) = ANY(?)"
+
+		if f.LabelMatch == entity.LabelMatchAll {
+			q.where("("+tagged+") = cardinality(ARRAY(SELECT DISTINCT unnest(?::text[])))", pq.Array(names), pq.Array(names))
+		} else {
+			q.where("("+tagged+") > 0", pq.Array(names))
+		}
+	}
+
+	for _, c := range f.CustomFields {
+		err := customFieldCondition(q, c)
+		if err != nil {
+			return entity.Page[entity.Task]{}, err
+		}
+	}
+
+	spec := taskList
+
+	if f.SortField != nil {
+		key, err := customFieldSort(*f.SortField)
+		if err != nil {
+			return entity.Page[entity.Task]{}, err
+		}
+
+		spec.sorts = maps.Clone(taskList.sorts)
+		spec.sorts[strings.TrimPrefix(f.Sort, "-")] = key
+	}
+
+	p, err := paginate(q, spec, f.ListQuery)
 	if err != nil {
-		return nil, err
+		return entity.Page[entity.Task]{}, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var task entity.Task
+	tasks, err := r.tasks(ctx, selectTasks+q.sql()+p.orderBy(), q.args...)
+	if err != nil {
+		return entity.Page[entity.Task]{}, err
+	}
+
+	return p.page(tasks)
+}
+
+func (r *TaskRepository) UpdateTask(ctx context.Context, id int64, upd entity.TaskToUpdate, updatedAt time.Time) (t entity.Task, err error) {
+	q := `WITH t AS (
+		UPDATE tasks
+		SET name = COALESCE($2, name),
+		    description = COALESCE($3, description),
+		    priority = COALESCE($4, priority),
+		    start_at = CASE WHEN $10 THEN NULL ELSE COALESCE($5, start_at) END,
+		    due_at = CASE WHEN $11 THEN NULL ELSE COALESCE($6, due_at) END,
+		    updated_at = $7,
+		    estimate_minutes = CASE WHEN $8::int IS NULL THEN estimate_minutes ELSE NULLIF($8::int, 0) END,
+		    custom_fields = CASE WHEN $9::jsonb IS NULL THEN custom_fields
+		        ELSE (custom_fields - ` + customFieldsRemoved("$9::jsonb", "tasks.project_id") + `) || ` + customFieldsByID("$9::jsonb", "tasks.project_id") + `
+		    END
+		WHERE id = $1
+		RETURNING *
+	) ` + selectChangedTask
+
+	customFields, err := customFieldValues(upd.CustomFields)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	t, err = scanTask(r.db.QueryRowContext(ctx, q, id, upd.Name, upd.Description, upd.Priority, upd.StartAt, upd.DueAt, updatedAt, upd.EstimateMinutes, customFields, upd.ClearStartAt, upd.ClearDueAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Task{}, entity.ErrNotFound
+		}
+
+		return t, err
+	}
+
+	return t, nil
+}
+
+// AssignTask sets task assignee, nil assigneeID unassigns the task.
+func (r *TaskRepository) AssignTask(ctx context.Context, id int64, assigneeID *int64, updatedAt time.Time) (t entity.Task, err error) {
+	q := `WITH t AS (
+		UPDATE tasks SET assignee_id = $2, updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	) ` + selectChangedTask
+
+	t, err = scanTask(r.db.QueryRowContext(ctx, q, id, assigneeID, updatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Task{}, entity.ErrNotFound
+		}
+
+		return t, err
+	}
+
+	return t, nil
+}
+
+// SetTaskSprint plans task into sprint, nil sprintID moves it to the backlog.
+func (r *TaskRepository) SetTaskSprint(ctx context.Context, id int64, sprintID *int64, updatedAt time.Time) (t entity.Task, err error) {
+	q := `WITH t AS (
+		UPDATE tasks SET sprint_id = $2, updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	) ` + selectChangedTask
 
-		err = rows.Scan(&task.ID, &task.Name, &task.ProjectID, &task.Description, &task.UserID, &task.CreatedAt)
+	t, err = scanTask(r.db.QueryRowContext(ctx, q, id, sprintID, updatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Task{}, entity.ErrNotFound
+		}
+
+		return t, err
+	}
+
+	return t, nil
+}
+
+// SetTaskStatus moves task from one status to the bottom of another. It fails with entity.ErrConflict
+// if the task is no longer in fromStatusID, e.g. because of a concurrent move.
+func (r *TaskRepository) SetTaskStatus(ctx context.Context, id int64, fromStatusID int64, toStatusID int64, completedAt *time.Time, updatedAt time.Time) (t entity.Task, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Task{}, err
+	}
+	defer tx.Rollback()
+
+	err = lockTaskBoard(ctx, tx, id)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	rank, err := columnEndRank(ctx, tx, toStatusID)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	t, err = moveTask(ctx, tx, id, fromStatusID, toStatusID, rank, completedAt, updatedAt)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	return t, tx.Commit()
+}
+
+// MoveTask moves task from one status to another and puts it right after task afterID,
+// nil afterID puts it on top of the column. Only the moved task gets a new rank.
+func (r *TaskRepository) MoveTask(ctx context.Context, id int64, fromStatusID int64, toStatusID int64, afterID *int64, completedAt *time.Time, updatedAt time.Time) (t entity.Task, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Task{}, err
+	}
+	defer tx.Rollback()
+
+	err = lockTaskBoard(ctx, tx, id)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	var prev, next string
+
+	if afterID != nil {
+		q := "SELECT rank FROM tasks WHERE id = $1 AND status_id = $2 AND id <> $3"
+
+		err = tx.QueryRowContext(ctx, q, *afterID, toStatusID, id).Scan(&prev)
 		if err != nil {
-			return nil, err
+			if errors.Is(err, sql.ErrNoRows) {
+				return entity.Task{}, fmt.Errorf("%w: task %d is not in the target column", entity.ErrConflict, *afterID)
+			}
+
+			return entity.Task{}, err
 		}
+	}
 
-		tasks = append(tasks, task)
+	q := "SELECT rank FROM tasks WHERE status_id = $1 AND id <> $2 AND rank > $3 ORDER BY rank LIMIT 1"
+
+	err = tx.QueryRowContext(ctx, q, toStatusID, id, prev).Scan(&next)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return entity.Task{}, err
 	}
 
-	return tasks, nil
+	t, err = moveTask(ctx, tx, id, fromStatusID, toStatusID, rankBetween(prev, next), completedAt, updatedAt)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	return t, tx.Commit()
+}
+
+// BoardTasks returns all tasks of project ordered by rank.
+func (r *TaskRepository) BoardTasks(ctx context.Context, projectID int64) ([]entity.Task, error) {
+	return r.tasks(ctx, selectTasks+" WHERE t.project_id = $1 ORDER BY t.rank, t.id", projectID)
+}
+
+func moveTask(ctx context.Context, tx *sql.Tx, id int64, fromStatusID int64, toStatusID int64, rank string, completedAt *time.Time, updatedAt time.Time) (t entity.Task, err error) {
+	q := `WITH t AS (
+		UPDATE tasks SET status_id = $3, rank = $4, completed_at = $5, updated_at = $6
+		WHERE id = $1 AND status_id = $2
+		RETURNING *
+	) ` + selectChangedTask
+
+	t, err = scanTask(tx.QueryRowContext(ctx, q, id, fromStatusID, toStatusID, rank, completedAt, updatedAt))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Task{}, fmt.Errorf("%w: task status has changed", entity.ErrConflict)
+		}
+
+		return t, err
+	}
+
+	return t, nil
+}
+
+// lockBoard serializes rank changes within project until tx ends, so concurrent moves
+// can't give two tasks of a column the same rank.
+func lockBoard(ctx context.Context, tx *sql.Tx, projectID int64) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", projectID)
+	return err
+}
+
+// lockTaskBoard locks the board of the project task belongs to.
+func lockTaskBoard(ctx context.Context, tx *sql.Tx, taskID int64) error {
+	var projectID int64
+
+	err := tx.QueryRowContext(ctx, "SELECT project_id FROM tasks WHERE id = $1", taskID).Scan(&projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ErrNotFound
+		}
+
+		return err
+	}
+
+	return lockBoard(ctx, tx, projectID)
+}
+
+// columnEndRank returns a rank which puts a task at the bottom of status column.
+func columnEndRank(ctx context.Context, tx *sql.Tx, statusID int64) (string, error) {
+	var last string
+
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(max(rank), '') FROM tasks WHERE status_id = $1", statusID).Scan(&last)
+	if err != nil {
+		return "", err
+	}
+
+	return rankBetween(last, ""), nil
 }
 
-func (r *TaskRepository) UserTasks(ctx context.Context, userID int64) (tasks []entity.Task, err error) {
-	q := "	SELECT id, name, project_id, description, user_id, created_at FROM tasks WHERE user_id = $1"
+// DeleteTask deletes task, children tells what happens to its subtasks. With entity.SubtasksPromote
+// they move to the parent of the deleted task, with entity.SubtasksCascade they are deleted too.
+// Without children a task which has subtasks fails with entity.ErrConflict.
+func (r *TaskRepository) DeleteTask(ctx context.Context, id int64, children entity.SubtaskAction) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the lock keeps subtasks from being added to the task until it's deleted
+	q := "SELECT parent_task_id FROM tasks WHERE id = $1 FOR UPDATE"
+
+	var parentID *int64
+
+	err = tx.QueryRowContext(ctx, q, id).Scan(&parentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.ErrNotFound
+		}
+
+		return err
+	}
+
+	switch children {
+	case "":
+		var subtasks int
+
+		err = tx.QueryRowContext(ctx, "SELECT count(*) FROM tasks WHERE parent_task_id = $1", id).Scan(&subtasks)
+		if err != nil {
+			return err
+		}
+
+		if subtasks > 0 {
+			return fmt.Errorf("%w: task has %d subtasks, choose to %s or %s them",
+				entity.ErrConflict, subtasks, entity.SubtasksCascade, entity.SubtasksPromote)
+		}
+	case entity.SubtasksPromote:
+		_, err = tx.ExecContext(ctx, "UPDATE tasks SET parent_task_id = $2 WHERE parent_task_id = $1", id, parentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SetTaskParent makes task a subtask of parentID, nil parentID makes it a top-level task.
+// It fails with entity.ErrConflict if the task would become its own ancestor
+// and with entity.ErrBadRequest if the subtree would be nested deeper than maxDepth.
+func (r *TaskRepository) SetTaskParent(ctx context.Context, id int64, parentID *int64, maxDepth int, updatedAt time.Time) (t entity.Task, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entity.Task{}, err
+	}
+	defer tx.Rollback()
+
+	// moves within a project are serialized, so concurrent ones can't form a cycle together
+	q := "SELECT p.id FROM projects p JOIN tasks t ON t.project_id = p.id WHERE t.id = $1 FOR UPDATE OF p"
+
+	var projectID int64
+
+	err = tx.QueryRowContext(ctx, q, id).Scan(&projectID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.Task{}, entity.ErrNotFound
+		}
+
+		return entity.Task{}, err
+	}
+
+	if parentID != nil {
+		// ancestors lists the new parent and everything above it
+		q = `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_task_id, project_id FROM tasks WHERE id = $1
+			UNION ALL
+			SELECT t.id, t.parent_task_id, t.project_id FROM tasks t JOIN ancestors a ON t.id = a.parent_task_id
+		), subtree AS (
+			SELECT id, 1 AS depth FROM tasks WHERE id = $2
+			UNION ALL
+			SELECT t.id, s.depth + 1 FROM tasks t JOIN subtree s ON t.parent_task_id = s.id
+		)
+		SELECT (SELECT project_id FROM tasks WHERE id = $1),
+		       (SELECT count(*) FROM ancestors),
+		       EXISTS (SELECT 1 FROM ancestors WHERE id = $2),
+		       (SELECT max(depth) FROM subtree)`
+
+		var (
+			parentProjectID sql.NullInt64
+			parentDepth     int
+			cycle           bool
+			height          int
+		)
+
+		err = tx.QueryRowContext(ctx, q, *parentID, id).Scan(&parentProjectID, &parentDepth, &cycle, &height)
+		if err != nil {
+			return entity.Task{}, err
+		}
+
+		if !parentProjectID.Valid || parentProjectID.Int64 != projectID {
+			return entity.Task{}, fmt.Errorf("%w: parent task", entity.ErrNotFound)
+		}
+
+		if cycle {
+			return entity.Task{}, fmt.Errorf("%w: task can't become a subtask of itself or of its subtasks", entity.ErrConflict)
+		}
+
+		if parentDepth+height > maxDepth {
+			return entity.Task{}, fmt.Errorf("%w: subtasks can't be nested deeper than %d levels", entity.ErrBadRequest, maxDepth)
+		}
+	}
+
+	q = `WITH t AS (
+		UPDATE tasks SET parent_task_id = $2, updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	) ` + selectChangedTask
 
-	rows, err := r.db.QueryContext(ctx, q, userID)
+	t, err = scanTask(tx.QueryRowContext(ctx, q, id, parentID, updatedAt))
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	return t, tx.Commit()
+}
+
+// TaskDepth returns how deep task is nested, a top-level task is at depth 1.
+func (r *TaskRepository) TaskDepth(ctx context.Context, id int64) (depth int, err error) {
+	q := `WITH RECURSIVE ancestors AS (
+		SELECT id, parent_task_id FROM tasks WHERE id = $1
+		UNION ALL
+		SELECT t.id, t.parent_task_id FROM tasks t JOIN ancestors a ON t.id = a.parent_task_id
+	) SELECT count(*) FROM ancestors`
+
+	err = r.db.QueryRowContext(ctx, q, id).Scan(&depth)
+	if err != nil {
+		return 0, err
+	}
+
+	if depth == 0 {
+		return 0, entity.ErrNotFound
+	}
+
+	return depth, nil
+}
+
+// Subtasks returns direct subtasks of task, oldest first.
+func (r *TaskRepository) Subtasks(ctx context.Context, parentID int64) ([]entity.Task, error) {
+	return r.tasks(ctx, selectTasks+" WHERE t.parent_task_id = $1 ORDER BY t.id", parentID)
+}
+
+func (r *TaskRepository) tasks(ctx context.Context, q string, args ...any) (tasks []entity.Task, err error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var task entity.Task
-
-		err = rows.Scan(&task.ID, &task.Name, &task.ProjectID, &task.Description, &task.UserID, &task.CreatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
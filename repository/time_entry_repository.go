@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+type TimeEntryRepository struct {
+	db *sql.DB
+}
+
+func NewTimeEntryRepository(db *sql.DB) *TimeEntryRepository {
+	return &TimeEntryRepository{db: db}
+}
+
+// timeEntryColumns lists columns scanned by scanTimeEntry, e is a time_entries row, t is its task and u is its user.
+const timeEntryColumns = "e.id, e.task_id, t.name, e.user_id, u.name, to_char(e.spent_on, 'YYYY-MM-DD'), COALESCE(e.minutes, 0), e.note, e.started_at, e.minutes IS NULL, e.created_at"
+
+const (
+	timeEntryJoins = " JOIN tasks t ON t.id = e.task_id JOIN users u ON u.id = e.user_id"
+	// selectChangedTimeEntry selects time entry rows returned by a data-modifying CTE named e.
+	selectChangedTimeEntry = "SELECT " + timeEntryColumns + " FROM e" + timeEntryJoins
+)
+
+func scanTimeEntry(row scanner) (e entity.TimeEntry, err error) {
+	err = row.Scan(&e.ID, &e.TaskID, &e.TaskName, &e.UserID, &e.UserName, &e.Date, &e.Minutes, &e.Note, &e.StartedAt, &e.Running, &e.CreatedAt)
+	return e, err
+}
+
+// CreateTimeEntry logs time entry, entry with zero Minutes is a running timer.
+// A user can't have two running timers, the second one fails with entity.ErrConflict.
+func (r *TimeEntryRepository) CreateTimeEntry(ctx context.Context, e entity.TimeEntry) (entity.TimeEntry, error) {
+	q := `WITH e AS (
+		INSERT INTO time_entries(task_id, user_id, spent_on, minutes, note, started_at, created_at)
+		VALUES ($1, $2, $3::date, NULLIF($4, 0), $5, $6, $7)
+		RETURNING *
+	) ` + selectChangedTimeEntry
+
+	e, err := scanTimeEntry(r.db.QueryRowContext(ctx, q, e.TaskID, e.UserID, e.Date, e.Minutes, e.Note, e.StartedAt, e.CreatedAt))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return entity.TimeEntry{}, fmt.Errorf("%w: a timer is already running", entity.ErrConflict)
+		}
+
+		if isForeignKeyViolation(err) {
+			return entity.TimeEntry{}, entity.ErrNotFound
+		}
+
+		return entity.TimeEntry{}, err
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) TimeEntryByID(ctx context.Context, id int64) (e entity.TimeEntry, err error) {
+	q := "SELECT " + timeEntryColumns + " FROM time_entries e" + timeEntryJoins + " WHERE e.id = $1"
+
+	e, err = scanTimeEntry(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.TimeEntry{}, fmt.Errorf("%w: time entry", entity.ErrNotFound)
+		}
+
+		return e, err
+	}
+
+	return e, nil
+}
+
+// timeFilter applies f to a query of time entries e joined with their tasks t.
+func timeFilter(f entity.TimeFilter) *listQuery {
+	q := &listQuery{}
+
+	if f.ProjectID != nil {
+		q.where("t.project_id = ?", *f.ProjectID)
+	}
+
+	if f.TaskID != nil {
+		q.where("e.task_id = ?", *f.TaskID)
+	}
+
+	if f.UserID != nil {
+		q.where("e.user_id = ?", *f.UserID)
+	}
+
+	if f.From != "" {
+		q.where("e.spent_on >= ?::date", f.From)
+	}
+
+	if f.To != "" {
+		q.where("e.spent_on <= ?::date", f.To)
+	}
+
+	return q
+}
+
+// TimeEntries returns time entries matching f by date, running timers included.
+func (r *TimeEntryRepository) TimeEntries(ctx context.Context, f entity.TimeFilter) (entries []entity.TimeEntry, err error) {
+	q := timeFilter(f)
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+timeEntryColumns+" FROM time_entries e"+timeEntryJoins+q.sql()+" ORDER BY e.spent_on, e.id", q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// timeGroups holds the id and name columns time totals are grouped by.
+var timeGroups = map[entity.TimeGroup][2]string{
+	entity.TimeByTask:    {"t.id", "t.name"},
+	entity.TimeByUser:    {"u.id", "u.name"},
+	entity.TimeByProject: {"p.id", "p.name"},
+}
+
+// TimeTotals sums minutes of time entries matching f for each group, largest totals first.
+func (r *TimeEntryRepository) TimeTotals(ctx context.Context, f entity.TimeFilter, group entity.TimeGroup) (totals []entity.TimeTotal, err error) {
+	columns, ok := timeGroups[group]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown time group %q", entity.ErrBadRequest, group)
+	}
+
+	q := timeFilter(f)
+	q.where("e.minutes IS NOT NULL")
+
+	query := "SELECT " + columns[0] + ", " + columns[1] + ", sum(e.minutes) FROM time_entries e" + timeEntryJoins +
+		" JOIN projects p ON p.id = t.project_id" + q.sql() +
+		" GROUP BY " + columns[0] + ", " + columns[1] + " ORDER BY sum(e.minutes) DESC, " + columns[0]
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var total entity.TimeTotal
+
+		err = rows.Scan(&total.ID, &total.Name, &total.Minutes)
+		if err != nil {
+			return nil, err
+		}
+
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+func (r *TimeEntryRepository) UpdateTimeEntry(ctx context.Context, id int64, upd entity.TimeEntryToUpdate) (e entity.TimeEntry, err error) {
+	q := `WITH e AS (
+		UPDATE time_entries
+		SET spent_on = COALESCE($2::date, spent_on),
+		    minutes = COALESCE($3, minutes),
+		    note = COALESCE($4, note)
+		WHERE id = $1
+		RETURNING *
+	) ` + selectChangedTimeEntry
+
+	e, err = scanTimeEntry(r.db.QueryRowContext(ctx, q, id, upd.Date, upd.Minutes, upd.Note))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.TimeEntry{}, fmt.Errorf("%w: time entry", entity.ErrNotFound)
+		}
+
+		return e, err
+	}
+
+	return e, nil
+}
+
+func (r *TimeEntryRepository) DeleteTimeEntry(ctx context.Context, id int64) error {
+	q := "DELETE FROM time_entries WHERE id = $1"
+
+	res, err := r.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: time entry", entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *TimeEntryRepository) RunningTimer(ctx context.Context, userID int64) (e entity.TimeEntry, err error) {
+	q := "SELECT " + timeEntryColumns + " FROM time_entries e" + timeEntryJoins + " WHERE e.user_id = $1 AND e.minutes IS NULL"
+
+	e, err = scanTimeEntry(r.db.QueryRowContext(ctx, q, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.TimeEntry{}, fmt.Errorf("%w: no running timer", entity.ErrNotFound)
+		}
+
+		return e, err
+	}
+
+	return e, nil
+}
+
+// StopTimer stops the running timer of user, the time is rounded up to whole minutes
+// and capped at maxMinutes, so a forgotten timer logs no more than an entry can hold.
+func (r *TimeEntryRepository) StopTimer(ctx context.Context, userID int64, stoppedAt time.Time, maxMinutes int) (e entity.TimeEntry, err error) {
+	q := `WITH e AS (
+		UPDATE time_entries
+		SET minutes = LEAST($3::int, GREATEST(1, ceil(extract(epoch FROM $2::timestamptz - started_at) / 60))::int)
+		WHERE user_id = $1 AND minutes IS NULL
+		RETURNING *
+	) ` + selectChangedTimeEntry
+
+	e, err = scanTimeEntry(r.db.QueryRowContext(ctx, q, userID, stoppedAt, maxMinutes))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.TimeEntry{}, fmt.Errorf("%w: no running timer", entity.ErrNotFound)
+		}
+
+		return e, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/lib/pq"
+	"restAPI/entity"
+	"time"
+)
+
+type TokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) CreateAccessToken(ctx context.Context, t entity.PersonalAccessToken, tokenHash string) (entity.PersonalAccessToken, error) {
+	q := `INSERT INTO personal_access_tokens(user_id, name, token_hash, scopes, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err := r.db.QueryRowContext(ctx, q, t.UserID, t.Name, tokenHash, pq.Array(t.Scopes), t.CreatedAt, t.ExpiresAt).Scan(&t.ID)
+	if err != nil {
+		return entity.PersonalAccessToken{}, err
+	}
+
+	return t, nil
+}
+
+// UserByAccessToken returns owner of a not expired token and records the token usage.
+func (r *TokenRepository) UserByAccessToken(ctx context.Context, tokenHash string, usedAt time.Time) (u entity.User, t entity.PersonalAccessToken, err error) {
+	q := `WITH t AS (
+		UPDATE personal_access_tokens SET last_used_at = $2
+		WHERE token_hash = $1 AND (expires_at IS NULL OR expires_at > $2)
+		RETURNING id, user_id, name, scopes, created_at, expires_at, last_used_at
+	)
+	SELECT u.id, u.email, u.name, u.created_at, u.is_verified, u.time_zone,
+	       t.id, t.user_id, t.name, t.scopes, t.created_at, t.expires_at, t.last_used_at
+	FROM users u JOIN t ON u.id = t.user_id`
+
+	err = r.db.QueryRowContext(ctx, q, tokenHash, usedAt).Scan(
+		&u.ID, &u.Email, &u.Name, &u.CreatedAt, &u.IsVerified, &u.TimeZone,
+		&t.ID, &t.UserID, &t.Name, pq.Array(&t.Scopes), &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, entity.PersonalAccessToken{}, entity.ErrNotFound
+		}
+
+		return u, t, err
+	}
+
+	return u, t, nil
+}
+
+func (r *TokenRepository) UserAccessTokens(ctx context.Context, userID int64) (tokens []entity.PersonalAccessToken, err error) {
+	q := `SELECT id, user_id, name, scopes, created_at, expires_at, last_used_at
+	FROM personal_access_tokens
+	WHERE user_id = $1
+	ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t entity.PersonalAccessToken
+
+		err = rows.Scan(&t.ID, &t.UserID, &t.Name, pq.Array(&t.Scopes), &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, t)
+	}
+
+	return tokens, nil
+}
+
+func (r *TokenRepository) DeleteAccessToken(ctx context.Context, id int64, userID int64) error {
+	q := "DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2"
+
+	res, err := r.db.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return entity.ErrNotFound
+	}
+
+	return nil
+}
@@ -16,9 +16,9 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) CreateUser(ctx context.Context, u entity.User) (entity.User, error) {
-	q := "INSERT INTO users(name, password, email, created_at, is_verified) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	q := "INSERT INTO users(name, password, email, created_at, is_verified, time_zone) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
 
-	err := r.db.QueryRowContext(ctx, q, u.Name, u.Password, u.Email, u.CreatedAt, u.IsVerified).Scan(&u.ID)
+	err := r.db.QueryRowContext(ctx, q, u.Name, u.Password, u.Email, u.CreatedAt, u.IsVerified, u.TimeZone).Scan(&u.ID)
 	if err != nil {
 		return entity.User{}, err
 	}
@@ -38,9 +38,9 @@ func (r *UserRepository) DeleteUser(ctx context.Context, id int64) error {
 }
 
 func (r *UserRepository) UserByID(ctx context.Context, id int64) (u entity.User, err error) {
-	q := "SELECT id, name, email, created_at, is_verified FROM users WHERE id = $1"
+	q := "SELECT id, name, email, created_at, is_verified, time_zone FROM users WHERE id = $1"
 
-	err = r.db.QueryRowContext(ctx, q, id).Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.IsVerified)
+	err = r.db.QueryRowContext(ctx, q, id).Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.IsVerified, &u.TimeZone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.User{}, entity.ErrNotFound
@@ -53,9 +53,9 @@ func (r *UserRepository) UserByID(ctx context.Context, id int64) (u entity.User,
 }
 
 func (r *UserRepository) UserByEmail(ctx context.Context, email string) (u entity.User, err error) {
-	q := "SELECT id, name, email, created_at, is_verified FROM users WHERE email = $1"
+	q := "SELECT id, name, email, created_at, is_verified, time_zone FROM users WHERE email = $1"
 
-	err = r.db.QueryRowContext(ctx, q, email).Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.IsVerified)
+	err = r.db.QueryRowContext(ctx, q, email).Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.IsVerified, &u.TimeZone)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return entity.User{}, entity.ErrNotFound
@@ -67,31 +67,68 @@ func (r *UserRepository) UserByEmail(ctx context.Context, email string) (u entit
 	return u, nil
 }
 
-func (r *UserRepository) Users(ctx context.Context) (users []entity.User, err error) {
-	q := "SELECT id, name, email, created_at, is_verified FROM users"
+func (r *UserRepository) UpdateTimeZone(ctx context.Context, id int64, timeZone string) (u entity.User, err error) {
+	q := "UPDATE users SET time_zone = $2 WHERE id = $1 RETURNING id, name, email, created_at, is_verified, time_zone"
 
-	rows, err := r.db.QueryContext(ctx, q)
+	err = r.db.QueryRowContext(ctx, q, id, timeZone).Scan(&u.ID, &u.Name, &u.Email, &u.CreatedAt, &u.IsVerified, &u.TimeZone)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, sql.ErrNoRows) {
+			return entity.User{}, entity.ErrNotFound
+		}
+
+		return u, err
+	}
+
+	return u, nil
+}
+
+var userList = listSpec[entity.User]{
+	id:      "id",
+	itemID:  func(u entity.User) int64 { return u.ID },
+	created: "created_at",
+	name:    "name",
+	sorts: map[string]sortKey[entity.User]{
+		"id":         {expr: "id", cast: "bigint", value: func(u entity.User) any { return u.ID }},
+		"name":       {expr: "name", cast: "text", value: func(u entity.User) any { return u.Name }},
+		"email":      {expr: "email", cast: "text", value: func(u entity.User) any { return u.Email }},
+		"created_at": {expr: "created_at", cast: "timestamptz", value: func(u entity.User) any { return u.CreatedAt }},
+	},
+}
+
+func (r *UserRepository) Users(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.User], error) {
+	q := &listQuery{}
+
+	pg, err := paginate(q, userList, lq)
+	if err != nil {
+		return entity.Page[entity.User]{}, err
+	}
+
+	query := "SELECT id, name, email, created_at, is_verified, time_zone FROM users" + q.sql() + pg.orderBy()
+
+	rows, err := r.db.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return entity.Page[entity.User]{}, err
 	}
 	defer rows.Close()
 
+	var users []entity.User
+
 	for rows.Next() {
 		var user entity.User
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.IsVerified)
+		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.IsVerified, &user.TimeZone)
 		if err != nil {
-			return nil, err
+			return entity.Page[entity.User]{}, err
 		}
 
 		users = append(users, user)
 	}
 
-	return users, nil
+	return pg.page(users)
 }
 
 func (r *UserRepository) ProjectUsers(ctx context.Context, projectID int64) (users []entity.User, err error) {
-	q := `SELECT u.id, u.name, u.email, u.created_at, u.is_verified
+	q := `SELECT u.id, u.name, u.email, u.created_at, u.is_verified, u.time_zone
 	FROM users u
 	    JOIN projects_users pu ON pu.user_id = u.id
 	WHERE pu.project_id = $1`
@@ -105,7 +142,7 @@ func (r *UserRepository) ProjectUsers(ctx context.Context, projectID int64) (use
 	for rows.Next() {
 		var user entity.User
 
-		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.IsVerified)
+		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.IsVerified, &user.TimeZone)
 		if err != nil {
 			return nil, err
 		}
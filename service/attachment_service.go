@@ -0,0 +1,305 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"restAPI/entity"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	downloadURLTTL        = 5 * time.Minute
+	maxAttachmentNameSize = 255
+	blobSweepBatch        = 100
+)
+
+// BlobStore keeps attachment contents outside the database.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, a entity.Attachment, quota int64) (entity.Attachment, error)
+	ProjectStorage(ctx context.Context, projectID int64) (used int64, err error)
+	AttachmentByID(ctx context.Context, id int64) (a entity.Attachment, err error)
+	TaskAttachments(ctx context.Context, taskID int64) (attachments []entity.Attachment, err error)
+	DeleteAttachment(ctx context.Context, id int64) error
+	OrphanedBlobs(ctx context.Context, limit int) (keys []string, err error)
+	ForgetBlobs(ctx context.Context, keys []string) error
+}
+
+type AttachmentService struct {
+	attachment AttachmentRepository
+	task       TaskRepository
+	access     authorizer
+	blobs      BlobStore
+	secret     []byte
+	quota      int64
+}
+
+// NewAttachmentService returns service which allows at most quota bytes of attachments per project
+// and signs download links with secret.
+func NewAttachmentService(attachment AttachmentRepository, task TaskRepository, project ProjectRepository, blobs BlobStore, secret string, quota int64) *AttachmentService {
+	return &AttachmentService{
+		attachment: attachment,
+		task:       task,
+		access:     authorizer{project: project, task: task},
+		blobs:      blobs,
+		secret:     []byte(secret),
+		quota:      quota,
+	}
+}
+
+// Upload stores size bytes of r as an attachment of task, content type is detected from the contents.
+func (us *AttachmentService) Upload(ctx context.Context, taskID int64, name string, r io.Reader, size int64) (entity.Attachment, error) {
+	user := entity.AuthUser(ctx)
+
+	task, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	name, err = validateAttachmentName(name)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	used, err := us.attachment.ProjectStorage(ctx, task.ProjectID)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	// checked before the upload to not store a blob which can't be kept,
+	// CreateAttachment checks again in case of concurrent uploads
+	if used+size > us.quota {
+		return entity.Attachment{}, fmt.Errorf("%w: project storage quota of %d bytes exceeded", entity.ErrTooLarge, us.quota)
+	}
+
+	head := make([]byte, 512)
+
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return entity.Attachment{}, err
+	}
+
+	head = head[:n]
+
+	key, err := blobKey(task.ProjectID)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	attachment := entity.Attachment{
+		TaskID:      task.ID,
+		ProjectID:   task.ProjectID,
+		UserID:      user.ID,
+		BlobKey:     key,
+		Name:        name,
+		ContentType: http.DetectContentType(head),
+		Size:        size,
+		CreatedAt:   time.Now(),
+	}
+
+	err = us.blobs.Put(ctx, key, io.MultiReader(bytes.NewReader(head), r), size, attachment.ContentType)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	attachment, err = us.attachment.CreateAttachment(ctx, attachment, us.quota)
+	if err != nil {
+		delErr := us.blobs.Delete(context.WithoutCancel(ctx), key)
+		if delErr != nil {
+			log.Println("attachment blob cleanup:", delErr)
+		}
+
+		return entity.Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+func (us *AttachmentService) TaskAttachments(ctx context.Context, taskID int64) ([]entity.Attachment, error) {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := us.attachment.TaskAttachments(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachments == nil {
+		attachments = []entity.Attachment{}
+	}
+
+	return attachments, nil
+}
+
+// Attachment returns attachment with a download link valid for downloadURLTTL.
+func (us *AttachmentService) Attachment(ctx context.Context, taskID int64, id int64) (entity.Attachment, error) {
+	attachment, _, err := us.authorizeAttachment(ctx, taskID, id, actionRead)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	expires := time.Now().Add(downloadURLTTL).Unix()
+	attachment.DownloadURL = fmt.Sprintf("%s/attachments/%d/download?expires=%d&signature=%s",
+		appURL, attachment.ID, expires, us.sign(attachment.ID, expires))
+
+	return attachment, nil
+}
+
+// DeleteAttachment deletes attachment uploaded by the authenticated user, admins can delete any attachment.
+func (us *AttachmentService) DeleteAttachment(ctx context.Context, taskID int64, id int64) error {
+	user := entity.AuthUser(ctx)
+
+	attachment, role, err := us.authorizeAttachment(ctx, taskID, id, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	if attachment.UserID != user.ID && !role.AtLeast(requiredRoles[actionModerate]) {
+		return fmt.Errorf("%w: %s can't %s", entity.ErrForbidden, role, actionModerate)
+	}
+
+	return us.attachment.DeleteAttachment(ctx, id)
+}
+
+// Download returns contents of attachment id, the signed link replaces authentication.
+func (us *AttachmentService) Download(ctx context.Context, id int64, expires int64, signature string) (entity.Attachment, io.ReadCloser, error) {
+	if !hmac.Equal([]byte(signature), []byte(us.sign(id, expires))) {
+		return entity.Attachment{}, nil, fmt.Errorf("%w: invalid download link", entity.ErrForbidden)
+	}
+
+	if time.Now().Unix() > expires {
+		return entity.Attachment{}, nil, fmt.Errorf("%w: download link, request a new one", entity.ErrExpired)
+	}
+
+	attachment, err := us.attachment.AttachmentByID(ctx, id)
+	if err != nil {
+		return entity.Attachment{}, nil, err
+	}
+
+	body, err := us.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		return entity.Attachment{}, nil, err
+	}
+
+	return attachment, body, nil
+}
+
+// SweepBlobs deletes blobs of deleted attachments every interval until ctx is done.
+func (us *AttachmentService) SweepBlobs(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := us.sweepBlobs(ctx)
+		if err != nil {
+			log.Println("blob sweeper:", err)
+		} else if n > 0 {
+			log.Printf("blob sweeper: deleted %d orphaned blobs", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (us *AttachmentService) sweepBlobs(ctx context.Context) (int, error) {
+	deleted := 0
+
+	for {
+		keys, err := us.attachment.OrphanedBlobs(ctx, blobSweepBatch)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, key := range keys {
+			err = us.blobs.Delete(ctx, key)
+			if err != nil {
+				return deleted, err
+			}
+		}
+
+		err = us.attachment.ForgetBlobs(ctx, keys)
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += len(keys)
+
+		if len(keys) < blobSweepBatch {
+			return deleted, nil
+		}
+	}
+}
+
+// authorizeAttachment checks act on the task and that attachment id belongs to it.
+func (us *AttachmentService) authorizeAttachment(ctx context.Context, taskID int64, id int64, act action) (entity.Attachment, entity.Role, error) {
+	_, role, err := us.access.authorizeTask(ctx, taskID, act)
+	if err != nil {
+		return entity.Attachment{}, "", err
+	}
+
+	attachment, err := us.attachment.AttachmentByID(ctx, id)
+	if err != nil {
+		return entity.Attachment{}, "", err
+	}
+
+	if attachment.TaskID != taskID {
+		return entity.Attachment{}, "", entity.ErrNotFound
+	}
+
+	return attachment, role, nil
+}
+
+func (us *AttachmentService) sign(id int64, expires int64) string {
+	mac := hmac.New(sha256.New, us.secret)
+	fmt.Fprintf(mac, "attachment:%d:%d", id, expires)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateAttachmentName(name string) (string, error) {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("%w: file name can't be empty", entity.ErrBadRequest)
+	}
+
+	if !utf8.ValidString(name) || len(name) > maxAttachmentNameSize {
+		return "", fmt.Errorf("%w: file name must be valid UTF-8 of at most %d bytes", entity.ErrBadRequest, maxAttachmentNameSize)
+	}
+
+	return name, nil
+}
+
+// blobKey returns a new random key for a blob of project.
+func blobKey(projectID int64) (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("attachments/%d/%s", projectID, hex.EncodeToString(b)), nil
+}
@@ -2,43 +2,103 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
-	"github.com/segmentio/kafka-go"
+	"log"
 	"restAPI/entity"
 	"time"
 )
 
 type AuthRepository interface {
-	UserByEmailAndPassword(ctx context.Context, email string, password string) (u entity.User, err error)
-	CreateSession(ctx context.Context, sessionID uuid.UUID, userID int64, createdAt time.Time) error
-	UserBySessionID(ctx context.Context, sessionID string) (u entity.User, err error)
-	SaveVerificationCode(ctx context.Context, code string, userID int64) error
-	VerifyUser(ctx context.Context, code string) error
+	UserCredentials(ctx context.Context, email string) (u entity.User, err error)
+	ReplacePassword(ctx context.Context, userID int64, oldPassword string, newPassword string) error
+	UserPasswords(ctx context.Context) (users []entity.User, err error)
+	CreateSession(ctx context.Context, session entity.Session) error
+	TouchSession(ctx context.Context, sessionID string, seenAt time.Time, idleTTL time.Duration, maxTTL time.Duration) (u entity.User, s entity.Session, err error)
+	UserSessions(ctx context.Context, userID int64, now time.Time) (sessions []entity.Session, err error)
+	DeleteSession(ctx context.Context, sessionID string, userID int64) error
+	DeleteUserSessions(ctx context.Context, userID int64, keepID string) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) (int64, error)
+	SavePasswordResetToken(ctx context.Context, tokenHash string, userID int64, createdAt time.Time, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash string, password string, now time.Time) error
+	SaveVerificationCode(ctx context.Context, code string, userID int64, createdAt time.Time, expiresAt time.Time) error
+	ReplaceVerificationCode(ctx context.Context, code string, userID int64, createdAt time.Time, expiresAt time.Time, throttleSince time.Time) error
+	VerifyUser(ctx context.Context, code string, now time.Time) error
 }
 
+const (
+	// sessionIdleTTL is how long a session survives without requests.
+	sessionIdleTTL = 24 * time.Hour
+	// sessionMaxTTL is the absolute session lifetime regardless of activity.
+	sessionMaxTTL = 30 * 24 * time.Hour
+
+	passwordResetTTL = time.Hour
+
+	verificationCodeTTL = 24 * time.Hour
+	// verificationResendInterval is the minimal pause between two verification emails to one address.
+	verificationResendInterval = time.Minute
+
+	appURL = "http://localhost:8080"
+)
+
 type AuthService struct {
-	auth  AuthRepository
-	user  UserRepository
-	kafka *kafka.Conn
+	auth        AuthRepository
+	user        UserRepository
+	mailer      *Mailer
+	passwords   *PasswordHasher
+	invitations *InvitationService
 }
 
-func NewAuthService(auth AuthRepository, user UserRepository, kafkaConn *kafka.Conn) *AuthService {
+func NewAuthService(auth AuthRepository, user UserRepository, mailer *Mailer, passwords *PasswordHasher, invitations *InvitationService) *AuthService {
 	return &AuthService{
-		auth:  auth,
-		user:  user,
-		kafka: kafkaConn,
+		auth:        auth,
+		user:        user,
+		mailer:      mailer,
+		passwords:   passwords,
+		invitations: invitations,
 	}
 }
 
-func (us *AuthService) RegisterUser(ctx context.Context, user entity.User) (entity.User, error) {
+// RegisterUser creates a new user. With a valid inviteToken addressed to user's email
+// the user is verified right away and joins the inviting project.
+func (us *AuthService) RegisterUser(ctx context.Context, user entity.User, inviteToken string) (entity.User, error) {
 	_, err := us.user.UserByEmail(ctx, user.Email)
 	if err == nil {
 		return entity.User{}, fmt.Errorf("email %s already exist", user.Email)
 	}
 
+	err = validatePassword(user.Password)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	loc, err := loadTimeZone(user.TimeZone)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user.TimeZone = loc.String()
+
+	var invitation entity.Invitation
+
+	user.IsVerified = false
+
+	if inviteToken != "" {
+		invitation, err = us.invitations.invitationForEmail(ctx, inviteToken, user.Email)
+		if err != nil {
+			return entity.User{}, err
+		}
+
+		// the invitation link was delivered to this address, so it is proven
+		user.IsVerified = true
+	}
+
+	user.Password, err = us.passwords.Hash(user.Password)
+	if err != nil {
+		return entity.User{}, err
+	}
+
 	user.CreatedAt = time.Now()
 
 	user, err = us.user.CreateUser(ctx, user)
@@ -48,76 +108,347 @@ func (us *AuthService) RegisterUser(ctx context.Context, user entity.User) (enti
 
 	user.Password = ""
 
+	if user.IsVerified {
+		err = us.invitations.accept(ctx, invitation, user.ID)
+		if err != nil {
+			log.Println("invitation on registration:", err)
+		}
+
+		return user, nil
+	}
+
 	code := uuid.NewString()
 
-	err = us.auth.SaveVerificationCode(ctx, code, user.ID)
+	err = us.auth.SaveVerificationCode(ctx, code, user.ID, user.CreatedAt, user.CreatedAt.Add(verificationCodeTTL))
 	if err != nil {
 		return entity.User{}, err
 	}
 
+	// the user already exists at this point, a lost email can be requested again
 	err = us.SendVerificationLink(ctx, code, user.Email)
 	if err != nil {
-		return entity.User{}, err
+		log.Println("verification mail:", err)
 	}
 
 	return user, nil
 }
 
-func (us *AuthService) Login(ctx context.Context, email string, password string) (uuid.UUID, error) {
-	user, err := us.auth.UserByEmailAndPassword(ctx, email, password)
+func (us *AuthService) Login(ctx context.Context, email string, password string, ip string, userAgent string) (entity.Session, error) {
+	user, err := us.auth.UserCredentials(ctx, email)
 	if err != nil {
 		if errors.Is(err, entity.ErrNotFound) {
-			return uuid.UUID{}, entity.ErrUnauthorized
+			return entity.Session{}, entity.ErrUnauthorized
 		}
 
-		return uuid.UUID{}, err
+		return entity.Session{}, err
+	}
+
+	match, rehash, err := us.passwords.Verify(user.Password, password)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	if !match {
+		return entity.Session{}, entity.ErrUnauthorized
+	}
+
+	if rehash {
+		us.rehashPassword(ctx, user, password)
 	}
 
 	if !user.IsVerified {
-		return uuid.UUID{}, fmt.Errorf("%w: not verified, check your email", entity.ErrUnauthorized)
+		return entity.Session{}, fmt.Errorf("%w: not verified, check your email", entity.ErrUnauthorized)
+	}
+
+	now := time.Now()
+
+	session := entity.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(sessionIdleTTL),
+		Current:    true,
+	}
+
+	err = us.auth.CreateSession(ctx, session)
+	if err != nil {
+		return entity.Session{}, err
+	}
+
+	return session, nil
+}
+
+// rehashPassword upgrades stored password to the current algorithm.
+// Failure is not fatal for login, the upgrade is retried on the next sign-in.
+func (us *AuthService) rehashPassword(ctx context.Context, user entity.User, password string) {
+	hash, err := us.passwords.Hash(password)
+	if err != nil {
+		log.Println("password rehash:", err)
+		return
+	}
+
+	err = us.auth.ReplacePassword(ctx, user.ID, user.Password, hash)
+	if err != nil {
+		log.Println("password rehash:", err)
+	}
+}
+
+// HashPlaintextPasswords hashes every password still stored as plain text
+// and returns the number of upgraded users.
+func (us *AuthService) HashPlaintextPasswords(ctx context.Context) (int, error) {
+	users, err := us.auth.UserPasswords(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	for _, user := range users {
+		if us.passwords.IsHashed(user.Password) {
+			continue
+		}
+
+		hash, err := us.passwords.Hash(user.Password)
+		if err != nil {
+			return count, err
+		}
+
+		err = us.auth.ReplacePassword(ctx, user.ID, user.Password, hash)
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// UserBySessionID authenticates session and prolongs it.
+func (us *AuthService) UserBySessionID(ctx context.Context, sessionID string) (entity.User, entity.Session, error) {
+	_, err := uuid.Parse(sessionID)
+	if err != nil {
+		return entity.User{}, entity.Session{}, fmt.Errorf("%w: invalid session", entity.ErrUnauthorized)
+	}
+
+	user, session, err := us.auth.TouchSession(ctx, sessionID, time.Now(), sessionIdleTTL, sessionMaxTTL)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return entity.User{}, entity.Session{}, fmt.Errorf("%w: session expired", entity.ErrUnauthorized)
+		}
+
+		return entity.User{}, entity.Session{}, err
+	}
+
+	session.Current = true
+
+	return user, session, nil
+}
+
+func (us *AuthService) Logout(ctx context.Context) error {
+	user := entity.AuthUser(ctx)
+
+	sessionID := entity.AuthSessionID(ctx)
+	if sessionID == "" {
+		return fmt.Errorf("%w: not signed in with a session", entity.ErrBadRequest)
+	}
+
+	return us.auth.DeleteSession(ctx, sessionID, user.ID)
+}
+
+func (us *AuthService) Sessions(ctx context.Context) ([]entity.Session, error) {
+	user := entity.AuthUser(ctx)
+
+	sessions, err := us.auth.UserSessions(ctx, user.ID, time.Now())
+	if err != nil {
+		return nil, err
 	}
 
-	sessionID := uuid.New()
+	current := entity.AuthSessionID(ctx)
 
-	createdAt := time.Now()
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == current
+	}
+
+	return sessions, nil
+}
 
-	err = us.auth.CreateSession(ctx, sessionID, user.ID, createdAt)
+func (us *AuthService) RevokeSession(ctx context.Context, sessionID string) error {
+	user := entity.AuthUser(ctx)
+
+	_, err := uuid.Parse(sessionID)
 	if err != nil {
-		return uuid.UUID{}, err
+		return fmt.Errorf("%w: invalid session id", entity.ErrBadRequest)
 	}
 
-	return sessionID, nil
+	return us.auth.DeleteSession(ctx, sessionID, user.ID)
 }
 
-func (us *AuthService) UserBySessionID(ctx context.Context, sessionID string) (entity.User, error) {
-	return us.auth.UserBySessionID(ctx, sessionID)
+// RevokeOtherSessions signs user out everywhere except the current session.
+func (us *AuthService) RevokeOtherSessions(ctx context.Context) error {
+	user := entity.AuthUser(ctx)
+
+	// without a current session every session of user would be deleted
+	sessionID := entity.AuthSessionID(ctx)
+	if sessionID == "" {
+		return fmt.Errorf("%w: not signed in with a session", entity.ErrBadRequest)
+	}
+
+	return us.auth.DeleteUserSessions(ctx, user.ID, sessionID)
+}
+
+// SweepSessions deletes expired sessions every interval until ctx is done.
+func (us *AuthService) SweepSessions(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := us.auth.DeleteExpiredSessions(ctx, time.Now())
+		if err != nil {
+			log.Println("session sweeper:", err)
+		} else if n > 0 {
+			log.Printf("session sweeper: deleted %d expired sessions", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (us *AuthService) Verify(ctx context.Context, code string) error {
-	return us.auth.VerifyUser(ctx, code)
+	_, err := uuid.Parse(code)
+	if err != nil {
+		return fmt.Errorf("%w: verification code", entity.ErrNotFound)
+	}
+
+	err = us.auth.VerifyUser(ctx, code, time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrExpired) {
+			return fmt.Errorf("%w: verification code, request a new one", entity.ErrExpired)
+		}
+
+		return err
+	}
+
+	return nil
 }
 
-func (us *AuthService) SendVerificationLink(ctx context.Context, code string, email string) error {
-	message := map[string]string{
-		"subject":  "Verification",
-		"receiver": email,
-		"message":  fmt.Sprintf("Your Verification link is:http://localhost:8080/users/verify?code=%s", code),
+// ResendVerification mails a new verification code. Unknown and already verified
+// addresses are silently ignored, as are requests within verificationResendInterval
+// of the previous code. The code is issued off the request path, so neither the
+// response nor its timing reveals which addresses are registered.
+func (us *AuthService) ResendVerification(ctx context.Context, email string) error {
+	user, err := us.user.UserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if user.IsVerified {
+		return nil
 	}
 
-	b, err := json.Marshal(message)
+	go us.resendVerification(context.WithoutCancel(ctx), user)
+
+	return nil
+}
+
+func (us *AuthService) resendVerification(ctx context.Context, user entity.User) {
+	code := uuid.NewString()
+	now := time.Now()
+
+	err := us.auth.ReplaceVerificationCode(ctx, code, user.ID, now, now.Add(verificationCodeTTL), now.Add(-verificationResendInterval))
 	if err != nil {
+		if !errors.Is(err, entity.ErrTooManyRequests) {
+			log.Println("verification resend:", err)
+		}
+
+		return
+	}
+
+	err = us.SendVerificationLink(ctx, code, user.Email)
+	if err != nil {
+		log.Println("verification mail:", err)
+	}
+}
+
+// ForgotPassword mails a password reset link. It reports no error for unknown
+// emails, and the link is sent off the request path, so neither the response
+// nor its timing reveals which addresses are registered.
+func (us *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := us.user.UserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return nil
+		}
+
 		return err
 	}
 
-	msg := kafka.Message{
-		Key:   []byte(code),
-		Value: b,
+	go us.sendPasswordReset(context.WithoutCancel(ctx), user)
+
+	return nil
+}
+
+func (us *AuthService) sendPasswordReset(ctx context.Context, user entity.User) {
+	token, hash, err := newToken()
+	if err != nil {
+		log.Println("password reset:", err)
+		return
 	}
 
-	_, err = us.kafka.WriteMessages(msg)
+	now := time.Now()
+
+	err = us.auth.SavePasswordResetToken(ctx, hash, user.ID, now, now.Add(passwordResetTTL))
 	if err != nil {
+		log.Println("password reset:", err)
+		return
+	}
+
+	message := fmt.Sprintf("Your password reset token is: %s\nIt expires in %v. Send it with a new password to %s/password/reset",
+		token, passwordResetTTL, appURL)
+
+	err = us.mailer.Send(hash, "Password reset", user.Email, message)
+	if err != nil {
+		log.Println("password reset mail:", err)
+	}
+}
+
+// ResetPassword sets new password by reset token and signs the user out everywhere.
+func (us *AuthService) ResetPassword(ctx context.Context, token string, password string) error {
+	err := validatePassword(password)
+	if err != nil {
+		return err
+	}
+
+	hash, err := us.passwords.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	err = us.auth.ResetPassword(ctx, hashToken(token), hash, time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("%w: invalid or expired reset token", entity.ErrBadRequest)
+		}
+
 		return err
 	}
 
 	return nil
 }
+
+func (us *AuthService) SendVerificationLink(ctx context.Context, code string, email string) error {
+	message := fmt.Sprintf("Your Verification link is:%s/users/verify?code=%s", appURL, code)
+
+	return us.mailer.Send(code, "Verification", email, message)
+}
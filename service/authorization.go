@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+)
+
+// action is something a user does within a project.
+type action string
+
+const (
+	actionRead          action = "read the project"
+	actionEditTasks     action = "create and edit tasks"
+	actionAssignTasks   action = "assign tasks to other members"
+	actionComment       action = "comment on tasks"
+	actionModerate      action = "delete comments of other members"
+	actionEditProject   action = "edit the project"
+	actionManageMembers action = "manage project members"
+	actionManageProject action = "delete or transfer the project"
+)
+
+// requiredRoles holds the lowest project role allowed to perform each action.
+var requiredRoles = map[action]entity.Role{
+	actionRead:          entity.RoleViewer,
+	actionEditTasks:     entity.RoleMember,
+	actionAssignTasks:   entity.RoleAdmin,
+	actionComment:       entity.RoleViewer,
+	actionModerate:      entity.RoleAdmin,
+	actionEditProject:   entity.RoleAdmin,
+	actionManageMembers: entity.RoleAdmin,
+	actionManageProject: entity.RoleOwner,
+}
+
+// authorizer is the single place where project permissions are checked.
+// task is only needed by authorizeTask.
+type authorizer struct {
+	project ProjectRepository
+	task    TaskRepository
+}
+
+// authorize checks that authenticated user may perform act in project and
+// returns the project together with user's role in it.
+func (a authorizer) authorize(ctx context.Context, projectID int64, act action) (entity.Project, entity.Role, error) {
+	user := entity.AuthUser(ctx)
+
+	project, err := a.project.ProjectByID(ctx, projectID)
+	if err != nil {
+		return entity.Project{}, "", err
+	}
+
+	role, err := a.project.MemberRole(ctx, projectID, user.ID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return entity.Project{}, "", fmt.Errorf("%w: not a project member", entity.ErrForbidden)
+		}
+
+		return entity.Project{}, "", err
+	}
+
+	if !role.AtLeast(requiredRoles[act]) {
+		return entity.Project{}, "", fmt.Errorf("%w: %s can't %s", entity.ErrForbidden, role, act)
+	}
+
+	if project.Archived && (act == actionEditTasks || act == actionAssignTasks || act == actionComment) {
+		return entity.Project{}, "", fmt.Errorf("%w: project is archived", entity.ErrConflict)
+	}
+
+	return project, role, nil
+}
+
+// authorizeTask checks that authenticated user may perform act in the project of task
+// and returns the task together with user's role.
+func (a authorizer) authorizeTask(ctx context.Context, taskID int64, act action) (entity.Task, entity.Role, error) {
+	task, err := a.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return entity.Task{}, "", err
+	}
+
+	_, role, err := a.authorize(ctx, task.ProjectID, act)
+	if err != nil {
+		return entity.Task{}, "", err
+	}
+
+	return task, role, nil
+}
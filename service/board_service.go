@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+// Board returns project tasks grouped into columns of its workflow statuses.
+func (us *ProjectService) Board(ctx context.Context, projectID int64) (entity.Board, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return entity.Board{}, err
+	}
+
+	workflow, err := us.status.Workflow(ctx, projectID)
+	if err != nil {
+		return entity.Board{}, err
+	}
+
+	tasks, err := us.task.BoardTasks(ctx, projectID)
+	if err != nil {
+		return entity.Board{}, err
+	}
+
+	board := entity.Board{ProjectID: projectID, Columns: make([]entity.BoardColumn, 0, len(workflow.Statuses))}
+	columns := make(map[int64]int, len(workflow.Statuses))
+
+	for i, status := range workflow.Statuses {
+		board.Columns = append(board.Columns, entity.BoardColumn{Status: status, Tasks: []entity.Task{}})
+		columns[status.ID] = i
+	}
+
+	for _, task := range tasks {
+		i := columns[task.StatusID]
+		board.Columns[i].Tasks = append(board.Columns[i].Tasks, task)
+	}
+
+	return board, nil
+}
+
+// MoveTask puts task into statusID column right after task afterID, nil afterID puts it on top.
+// Moving to another column is a status transition and follows the project workflow,
+// zero statusID keeps the task in its column.
+func (us *ProjectService) MoveTask(ctx context.Context, taskID int64, statusID int64, afterID *int64, override bool) (entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if statusID == 0 {
+		statusID = task.StatusID
+	}
+
+	if afterID != nil && *afterID == taskID {
+		return entity.Task{}, fmt.Errorf("%w: task can't be moved after itself", entity.ErrBadRequest)
+	}
+
+	completedAt, err := us.checkTransition(ctx, task, statusID, override)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	return us.task.MoveTask(ctx, taskID, task.StatusID, statusID, afterID, completedAt, time.Now())
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+const maxChecklistItemLength = 500
+
+type ChecklistRepository interface {
+	CreateChecklistItem(ctx context.Context, i entity.ChecklistItem) (entity.ChecklistItem, error)
+	Checklist(ctx context.Context, taskID int64) (items []entity.ChecklistItem, err error)
+	UpdateChecklistItem(ctx context.Context, taskID int64, id int64, upd entity.ChecklistItemToUpdate) (i entity.ChecklistItem, err error)
+	DeleteChecklistItem(ctx context.Context, taskID int64, id int64) error
+}
+
+func (us *ProjectService) AddChecklistItem(ctx context.Context, taskID int64, text string) (entity.ChecklistItem, error) {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return entity.ChecklistItem{}, err
+	}
+
+	text, err = validateChecklistItem(text)
+	if err != nil {
+		return entity.ChecklistItem{}, err
+	}
+
+	return us.checklist.CreateChecklistItem(ctx, entity.ChecklistItem{
+		TaskID:    taskID,
+		Text:      text,
+		CreatedAt: time.Now(),
+	})
+}
+
+// UpdateChecklistItem renames item or toggles whether it is done.
+func (us *ProjectService) UpdateChecklistItem(ctx context.Context, taskID int64, id int64, upd entity.ChecklistItemToUpdate) (entity.ChecklistItem, error) {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return entity.ChecklistItem{}, err
+	}
+
+	if upd.Text != nil {
+		text, err := validateChecklistItem(*upd.Text)
+		if err != nil {
+			return entity.ChecklistItem{}, err
+		}
+
+		upd.Text = &text
+	}
+
+	return us.checklist.UpdateChecklistItem(ctx, taskID, id, upd)
+}
+
+func (us *ProjectService) DeleteChecklistItem(ctx context.Context, taskID int64, id int64) error {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	return us.checklist.DeleteChecklistItem(ctx, taskID, id)
+}
+
+func validateChecklistItem(text string) (string, error) {
+	text = strings.TrimSpace(text)
+
+	if text == "" {
+		return "", fmt.Errorf("%w: checklist item can't be empty", entity.ErrBadRequest)
+	}
+
+	if len(text) > maxChecklistItemLength {
+		return "", fmt.Errorf("%w: checklist item is longer than %d bytes", entity.ErrBadRequest, maxChecklistItemLength)
+	}
+
+	return text, nil
+}
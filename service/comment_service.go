@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+const maxCommentLength = 10000
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, c entity.Comment) (entity.Comment, error)
+	CommentByID(ctx context.Context, id int64) (c entity.Comment, err error)
+	TaskComments(ctx context.Context, taskID int64, lq entity.ListQuery) (entity.Page[entity.Comment], error)
+	UpdateComment(ctx context.Context, id int64, body string, editedAt time.Time) (c entity.Comment, err error)
+	DeleteComment(ctx context.Context, id int64) error
+	CommentVersions(ctx context.Context, commentID int64) (versions []entity.CommentVersion, err error)
+}
+
+type CommentService struct {
+	comment CommentRepository
+	task    TaskRepository
+	access  authorizer
+}
+
+func NewCommentService(comment CommentRepository, task TaskRepository, project ProjectRepository) *CommentService {
+	return &CommentService{
+		comment: comment,
+		task:    task,
+		access:  authorizer{project: project, task: task},
+	}
+}
+
+func (us *CommentService) CreateComment(ctx context.Context, taskID int64, body string) (entity.Comment, error) {
+	user := entity.AuthUser(ctx)
+
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionComment)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	body, err = validateComment(body)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	return us.comment.CreateComment(ctx, entity.Comment{
+		TaskID:    taskID,
+		UserID:    user.ID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (us *CommentService) TaskComments(ctx context.Context, taskID int64, lq entity.ListQuery) (entity.Page[entity.Comment], error) {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionRead)
+	if err != nil {
+		return entity.Page[entity.Comment]{}, err
+	}
+
+	return us.comment.TaskComments(ctx, taskID, lq)
+}
+
+// UpdateComment changes comment body, only the author can edit a comment.
+func (us *CommentService) UpdateComment(ctx context.Context, taskID int64, id int64, body string) (entity.Comment, error) {
+	user := entity.AuthUser(ctx)
+
+	comment, _, err := us.authorizeComment(ctx, taskID, id, actionComment)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	if comment.UserID != user.ID {
+		return entity.Comment{}, fmt.Errorf("%w: only the author can edit a comment", entity.ErrForbidden)
+	}
+
+	body, err = validateComment(body)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	if body == comment.Body {
+		return comment, nil
+	}
+
+	return us.comment.UpdateComment(ctx, id, body, time.Now())
+}
+
+// DeleteComment deletes comment of the authenticated user, admins can delete any comment.
+func (us *CommentService) DeleteComment(ctx context.Context, taskID int64, id int64) error {
+	user := entity.AuthUser(ctx)
+
+	comment, role, err := us.authorizeComment(ctx, taskID, id, actionComment)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != user.ID && !role.AtLeast(requiredRoles[actionModerate]) {
+		return fmt.Errorf("%w: %s can't %s", entity.ErrForbidden, role, actionModerate)
+	}
+
+	return us.comment.DeleteComment(ctx, id)
+}
+
+func (us *CommentService) CommentVersions(ctx context.Context, taskID int64, id int64) ([]entity.CommentVersion, error) {
+	_, _, err := us.authorizeComment(ctx, taskID, id, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := us.comment.CommentVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if versions == nil {
+		versions = []entity.CommentVersion{}
+	}
+
+	return versions, nil
+}
+
+// authorizeComment checks act on the task and that comment id belongs to it.
+func (us *CommentService) authorizeComment(ctx context.Context, taskID int64, id int64, act action) (entity.Comment, entity.Role, error) {
+	_, role, err := us.access.authorizeTask(ctx, taskID, act)
+	if err != nil {
+		return entity.Comment{}, "", err
+	}
+
+	comment, err := us.comment.CommentByID(ctx, id)
+	if err != nil {
+		return entity.Comment{}, "", err
+	}
+
+	if comment.TaskID != taskID {
+		return entity.Comment{}, "", entity.ErrNotFound
+	}
+
+	return comment, role, nil
+}
+
+func validateComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+
+	if body == "" {
+		return "", fmt.Errorf("%w: comment can't be empty", entity.ErrBadRequest)
+	}
+
+	if len(body) > maxCommentLength {
+		return "", fmt.Errorf("%w: comment is longer than %d bytes", entity.ErrBadRequest, maxCommentLength)
+	}
+
+	return body, nil
+}
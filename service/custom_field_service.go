@@ -0,0 +1,429 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"restAPI/entity"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxCustomFieldNameLength   = 50
+	maxCustomFieldOptions      = 100
+	maxCustomFieldOptionLength = 100
+	maxCustomFieldTextLength   = 1000
+)
+
+// customFieldNumber matches decimal numbers as Postgres reads them, unlike strconv.ParseFloat
+// which also takes hex, Inf and NaN.
+var customFieldNumber = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// customFieldSortPrefix starts a task Sort by a custom field, e.g. "-cf.Story points".
+const customFieldSortPrefix = "cf."
+
+type CustomFieldRepository interface {
+	CreateCustomField(ctx context.Context, f entity.CustomField) (entity.CustomField, error)
+	ProjectCustomFields(ctx context.Context, projectID int64) (fields []entity.CustomField, err error)
+	UpdateCustomField(ctx context.Context, projectID int64, id int64, upd entity.CustomFieldToUpdate, updatedAt time.Time) (f entity.CustomField, err error)
+	DeleteCustomField(ctx context.Context, projectID int64, id int64, updatedAt time.Time) error
+}
+
+func (us *ProjectService) ProjectCustomFields(ctx context.Context, projectID int64) ([]entity.CustomField, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := us.field.ProjectCustomFields(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fields == nil {
+		fields = []entity.CustomField{}
+	}
+
+	return fields, nil
+}
+
+func (us *ProjectService) CreateCustomField(ctx context.Context, f entity.CustomField) (entity.CustomField, error) {
+	_, _, err := us.access.authorize(ctx, f.ProjectID, actionEditProject)
+	if err != nil {
+		return entity.CustomField{}, err
+	}
+
+	f.Name, err = validateCustomFieldName(f.Name)
+	if err != nil {
+		return entity.CustomField{}, err
+	}
+
+	if !f.Type.Valid() {
+		return entity.CustomField{}, fmt.Errorf("%w: custom field type must be one of text, number, date, single_select, multi_select, user", entity.ErrBadRequest)
+	}
+
+	f.Options, err = validateCustomFieldOptions(f.Type, f.Options)
+	if err != nil {
+		return entity.CustomField{}, err
+	}
+
+	f.CreatedAt = time.Now()
+
+	return us.field.CreateCustomField(ctx, f)
+}
+
+// UpdateCustomField renames field or replaces its options, values of tasks that are no longer
+// among the options are removed.
+func (us *ProjectService) UpdateCustomField(ctx context.Context, projectID int64, id int64, upd entity.CustomFieldToUpdate) (entity.CustomField, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return entity.CustomField{}, err
+	}
+
+	if upd.Name != nil {
+		name, err := validateCustomFieldName(*upd.Name)
+		if err != nil {
+			return entity.CustomField{}, err
+		}
+
+		upd.Name = &name
+	}
+
+	if upd.Options != nil {
+		fields, err := us.field.ProjectCustomFields(ctx, projectID)
+		if err != nil {
+			return entity.CustomField{}, err
+		}
+
+		i := slices.IndexFunc(fields, func(f entity.CustomField) bool { return f.ID == id })
+		if i < 0 {
+			return entity.CustomField{}, fmt.Errorf("%w: custom field", entity.ErrNotFound)
+		}
+
+		options, err := validateCustomFieldOptions(fields[i].Type, *upd.Options)
+		if err != nil {
+			return entity.CustomField{}, err
+		}
+
+		upd.Options = &options
+	}
+
+	return us.field.UpdateCustomField(ctx, projectID, id, upd, time.Now())
+}
+
+// DeleteCustomField deletes field along with its values of all tasks.
+func (us *ProjectService) DeleteCustomField(ctx context.Context, projectID int64, id int64) error {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return err
+	}
+
+	return us.field.DeleteCustomField(ctx, projectID, id, time.Now())
+}
+
+// validateCustomFieldValues checks values of task custom fields of project against the field types.
+// Returned values are keyed by the field names as defined, nulls are kept to remove values.
+func (us *ProjectService) validateCustomFieldValues(ctx context.Context, projectID int64, values entity.CustomFieldValues) (entity.CustomFieldValues, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	fields, err := us.field.ProjectCustomFields(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make(entity.CustomFieldValues, len(values))
+
+	for name, raw := range values {
+		f, err := findCustomField(fields, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := valid[f.Name]; ok {
+			return nil, fmt.Errorf("%w: custom field %q is set more than once", entity.ErrBadRequest, f.Name)
+		}
+
+		value, err := us.customFieldValue(ctx, f, raw)
+		if err != nil {
+			return nil, err
+		}
+
+		valid[f.Name] = value
+	}
+
+	return valid, nil
+}
+
+var jsonNull = json.RawMessage("null")
+
+// customFieldValue returns raw value of field in its canonical form, empty values become null.
+func (us *ProjectService) customFieldValue(ctx context.Context, f entity.CustomField, raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 || bytes.Equal(raw, jsonNull) {
+		return jsonNull, nil
+	}
+
+	invalid := func(must string) error {
+		return fmt.Errorf("%w: value of custom field %q must be %s", entity.ErrBadRequest, f.Name, must)
+	}
+
+	var value any
+
+	switch f.Type {
+	case entity.FieldText:
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return nil, invalid("a string")
+		}
+
+		s = strings.TrimSpace(s)
+		if len(s) > maxCustomFieldTextLength {
+			return nil, invalid(fmt.Sprintf("at most %d bytes long", maxCustomFieldTextLength))
+		}
+
+		if s == "" {
+			return jsonNull, nil
+		}
+
+		value = s
+	case entity.FieldNumber:
+		var n float64
+		if json.Unmarshal(raw, &n) != nil {
+			return nil, invalid("a number")
+		}
+
+		value = n
+	case entity.FieldDate:
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return nil, invalid("a date like 2024-01-31")
+		}
+
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return nil, invalid("a date like 2024-01-31")
+		}
+
+		value = s
+	case entity.FieldSingleSelect:
+		var s string
+		if json.Unmarshal(raw, &s) != nil || !slices.Contains(f.Options, s) {
+			return nil, invalid("one of its options")
+		}
+
+		value = s
+	case entity.FieldMultiSelect:
+		var selected []string
+		if json.Unmarshal(raw, &selected) != nil {
+			return nil, invalid("a list of its options")
+		}
+
+		// kept in the order of options without duplicates
+		var options []string
+		for _, o := range f.Options {
+			if slices.Contains(selected, o) {
+				options = append(options, o)
+			}
+		}
+
+		for _, s := range selected {
+			if !slices.Contains(options, s) {
+				return nil, invalid("a list of its options")
+			}
+		}
+
+		if len(options) == 0 {
+			return jsonNull, nil
+		}
+
+		value = options
+	case entity.FieldUser:
+		var userID int64
+		if json.Unmarshal(raw, &userID) != nil {
+			return nil, invalid("a user id")
+		}
+
+		_, err := us.project.MemberRole(ctx, f.ProjectID, userID)
+		if err != nil {
+			if errors.Is(err, entity.ErrNotFound) {
+				return nil, invalid("a project member")
+			}
+
+			return nil, err
+		}
+
+		value = userID
+	default:
+		return nil, fmt.Errorf("unknown custom field type %q", f.Type)
+	}
+
+	return json.Marshal(value)
+}
+
+// resolveCustomFieldFilter turns custom field queries and sort of f into conditions on fields of project.
+func (us *ProjectService) resolveCustomFieldFilter(ctx context.Context, projectID int64, f entity.TaskFilter) (entity.TaskFilter, error) {
+	sortName, sortByField := strings.CutPrefix(strings.TrimPrefix(f.Sort, "-"), customFieldSortPrefix)
+
+	if len(f.CustomFieldQueries) == 0 && !sortByField {
+		return f, nil
+	}
+
+	fields, err := us.field.ProjectCustomFields(ctx, projectID)
+	if err != nil {
+		return entity.TaskFilter{}, err
+	}
+
+	f.CustomFields = nil
+
+	for name, query := range f.CustomFieldQueries {
+		field, err := findCustomField(fields, name)
+		if err != nil {
+			return entity.TaskFilter{}, err
+		}
+
+		c, err := customFieldCondition(ctx, field, query)
+		if err != nil {
+			return entity.TaskFilter{}, err
+		}
+
+		f.CustomFields = append(f.CustomFields, c)
+	}
+
+	f.CustomFieldQueries = nil
+
+	if sortByField {
+		field, err := findCustomField(fields, sortName)
+		if err != nil {
+			return entity.TaskFilter{}, err
+		}
+
+		switch field.Type {
+		case entity.FieldMultiSelect, entity.FieldUser:
+			return entity.TaskFilter{}, fmt.Errorf("%w: tasks can't be sorted by a %s field", entity.ErrBadRequest, field.Type)
+		}
+
+		f.SortField = &field
+	}
+
+	return f, nil
+}
+
+// customFieldCondition parses query of field. Numbers and dates may be compared with one of >=, <=, > or <,
+// otherwise query lists comma separated values to match, "me" stands for the authenticated user in a user field.
+func customFieldCondition(ctx context.Context, field entity.CustomField, query string) (entity.CustomFieldCondition, error) {
+	c := entity.CustomFieldCondition{Field: field, Op: "="}
+
+	invalid := func(must string) error {
+		return fmt.Errorf("%w: filter by custom field %q must be %s", entity.ErrBadRequest, field.Name, must)
+	}
+
+	if field.Type == entity.FieldNumber || field.Type == entity.FieldDate {
+		for _, op := range []string{">=", "<=", ">", "<"} {
+			if rest, ok := strings.CutPrefix(query, op); ok {
+				c.Op, query = op, rest
+				break
+			}
+		}
+	}
+
+	if field.Type == entity.FieldText {
+		c.Values = []string{strings.TrimSpace(query)}
+	} else {
+		for _, v := range strings.Split(query, ",") {
+			c.Values = append(c.Values, strings.TrimSpace(v))
+		}
+	}
+
+	if c.Op != "=" && len(c.Values) > 1 {
+		return entity.CustomFieldCondition{}, invalid("a single value when compared with " + c.Op)
+	}
+
+	for i, v := range c.Values {
+		switch field.Type {
+		case entity.FieldNumber:
+			if !customFieldNumber.MatchString(v) {
+				return entity.CustomFieldCondition{}, invalid("a number")
+			}
+		case entity.FieldDate:
+			if _, err := time.Parse(time.DateOnly, v); err != nil {
+				return entity.CustomFieldCondition{}, invalid("a date like 2024-01-31")
+			}
+		case entity.FieldSingleSelect, entity.FieldMultiSelect:
+			if !slices.Contains(field.Options, v) {
+				return entity.CustomFieldCondition{}, invalid("one of its options")
+			}
+		case entity.FieldUser:
+			if v == "me" {
+				c.Values[i] = strconv.FormatInt(entity.AuthUser(ctx).ID, 10)
+				continue
+			}
+
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				return entity.CustomFieldCondition{}, invalid("a user id or me")
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// findCustomField finds field by name regardless of case.
+func findCustomField(fields []entity.CustomField, name string) (entity.CustomField, error) {
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, strings.TrimSpace(name)) {
+			return f, nil
+		}
+	}
+
+	return entity.CustomField{}, fmt.Errorf("%w: unknown custom field %q", entity.ErrBadRequest, name)
+}
+
+func validateCustomFieldName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > maxCustomFieldNameLength {
+		return "", fmt.Errorf("%w: custom field name must be 1 to %d bytes long", entity.ErrBadRequest, maxCustomFieldNameLength)
+	}
+
+	return name, nil
+}
+
+// validateCustomFieldOptions checks options of a field of type t, only select fields have them.
+func validateCustomFieldOptions(t entity.CustomFieldType, options []string) ([]string, error) {
+	if t != entity.FieldSingleSelect && t != entity.FieldMultiSelect {
+		if len(options) > 0 {
+			return nil, fmt.Errorf("%w: only select fields have options", entity.ErrBadRequest)
+		}
+
+		return []string{}, nil
+	}
+
+	if len(options) == 0 || len(options) > maxCustomFieldOptions {
+		return nil, fmt.Errorf("%w: select fields must have 1 to %d options", entity.ErrBadRequest, maxCustomFieldOptions)
+	}
+
+	valid := make([]string, 0, len(options))
+
+	for _, o := range options {
+		o = strings.TrimSpace(o)
+
+		// commas separate values in filters
+		if o == "" || len(o) > maxCustomFieldOptionLength || strings.Contains(o, ",") {
+			return nil, fmt.Errorf("%w: options must be 1 to %d bytes long without commas", entity.ErrBadRequest, maxCustomFieldOptionLength)
+		}
+
+		if slices.Contains(valid, o) {
+			return nil, fmt.Errorf("%w: option %q is listed more than once", entity.ErrBadRequest, o)
+		}
+
+		valid = append(valid, o)
+	}
+
+	return valid, nil
+}
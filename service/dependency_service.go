@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"time"
+)
+
+// AddDependency declares that task blockerID blocks task blockedID of the same project.
+func (us *ProjectService) AddDependency(ctx context.Context, blockerID int64, blockedID int64) error {
+	if blockerID == blockedID {
+		return fmt.Errorf("%w: task can't block itself", entity.ErrBadRequest)
+	}
+
+	blocker, _, err := us.access.authorizeTask(ctx, blockerID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	blocked, err := us.task.TaskByID(ctx, blockedID)
+	if err != nil {
+		return err
+	}
+
+	if blocked.ProjectID != blocker.ProjectID {
+		return fmt.Errorf("%w: dependent tasks must belong to one project", entity.ErrBadRequest)
+	}
+
+	return us.task.AddDependency(ctx, blockerID, blockedID, time.Now())
+}
+
+func (us *ProjectService) RemoveDependency(ctx context.Context, blockerID int64, blockedID int64) error {
+	_, _, err := us.access.authorizeTask(ctx, blockerID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	return us.task.RemoveDependency(ctx, blockerID, blockedID)
+}
+
+func (us *ProjectService) TaskDependencies(ctx context.Context, id int64) (entity.TaskDependencies, error) {
+	_, _, err := us.access.authorizeTask(ctx, id, actionRead)
+	if err != nil {
+		return entity.TaskDependencies{}, err
+	}
+
+	deps, err := us.task.TaskDependencies(ctx, id)
+	if err != nil {
+		return entity.TaskDependencies{}, err
+	}
+
+	if deps.Blocks == nil {
+		deps.Blocks = []entity.Task{}
+	}
+
+	if deps.BlockedBy == nil {
+		deps.BlockedBy = []entity.Task{}
+	}
+
+	return deps, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"restAPI/entity"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type InvitationRepository interface {
+	CreateInvitation(ctx context.Context, inv entity.Invitation) (entity.Invitation, error)
+	InvitationByID(ctx context.Context, id int64) (inv entity.Invitation, err error)
+	PendingInvitations(ctx context.Context, projectID int64) (invitations []entity.Invitation, err error)
+	ExtendInvitation(ctx context.Context, id int64, expiresAt time.Time) error
+	RevokeInvitation(ctx context.Context, id int64, revokedAt time.Time) error
+	AcceptInvitation(ctx context.Context, id int64, userID int64, now time.Time) error
+}
+
+type InvitationService struct {
+	invitation InvitationRepository
+	access     authorizer
+	mailer     *Mailer
+	secret     []byte
+}
+
+// NewInvitationService returns service which signs invitation links with secret.
+func NewInvitationService(invitation InvitationRepository, project ProjectRepository, mailer *Mailer, secret string) *InvitationService {
+	return &InvitationService{
+		invitation: invitation,
+		access:     authorizer{project: project},
+		mailer:     mailer,
+		secret:     []byte(secret),
+	}
+}
+
+func (us *InvitationService) Invite(ctx context.Context, projectID int64, email string, role entity.Role) (entity.Invitation, error) {
+	user := entity.AuthUser(ctx)
+
+	project, requesterRole, err := us.access.authorize(ctx, projectID, actionManageMembers)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	err = validateGrantedRole(requesterRole, role)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !strings.Contains(email, "@") {
+		return entity.Invitation{}, fmt.Errorf("%w: invalid email", entity.ErrBadRequest)
+	}
+
+	now := time.Now()
+
+	inv := entity.Invitation{
+		ProjectID: projectID,
+		Email:     email,
+		Role:      role,
+		InvitedBy: user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(invitationTTL),
+	}
+
+	inv, err = us.invitation.CreateInvitation(ctx, inv)
+	if err != nil {
+		return entity.Invitation{}, err
+	}
+
+	// the invitation is stored, a lost email can be resent
+	err = us.sendInvitation(inv, project)
+	if err != nil {
+		log.Println("invitation mail:", err)
+	}
+
+	return inv, nil
+}
+
+func (us *InvitationService) Invitations(ctx context.Context, projectID int64) ([]entity.Invitation, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	return us.invitation.PendingInvitations(ctx, projectID)
+}
+
+// ResendInvitation prolongs invitation and mails a new link, links sent before stop working.
+func (us *InvitationService) ResendInvitation(ctx context.Context, projectID int64, invitationID int64) error {
+	project, inv, err := us.projectInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		return err
+	}
+
+	inv.ExpiresAt = time.Now().Add(invitationTTL)
+
+	err = us.invitation.ExtendInvitation(ctx, inv.ID, inv.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return us.sendInvitation(inv, project)
+}
+
+func (us *InvitationService) RevokeInvitation(ctx context.Context, projectID int64, invitationID int64) error {
+	_, inv, err := us.projectInvitation(ctx, projectID, invitationID)
+	if err != nil {
+		return err
+	}
+
+	return us.invitation.RevokeInvitation(ctx, inv.ID, time.Now())
+}
+
+// AcceptInvitation adds authenticated user to the project the invitation token was issued for.
+func (us *InvitationService) AcceptInvitation(ctx context.Context, token string) error {
+	user := entity.AuthUser(ctx)
+
+	inv, err := us.invitationForEmail(ctx, token, user.Email)
+	if err != nil {
+		return err
+	}
+
+	return us.accept(ctx, inv, user.ID)
+}
+
+func (us *InvitationService) projectInvitation(ctx context.Context, projectID int64, invitationID int64) (entity.Project, entity.Invitation, error) {
+	project, _, err := us.access.authorize(ctx, projectID, actionManageMembers)
+	if err != nil {
+		return entity.Project{}, entity.Invitation{}, err
+	}
+
+	inv, err := us.invitation.InvitationByID(ctx, invitationID)
+	if err != nil {
+		return entity.Project{}, entity.Invitation{}, err
+	}
+
+	if inv.ProjectID != projectID {
+		return entity.Project{}, entity.Invitation{}, entity.ErrNotFound
+	}
+
+	return project, inv, nil
+}
+
+// invitationForEmail verifies token and returns pending invitation it was issued for,
+// the invitation has to be addressed to email.
+func (us *InvitationService) invitationForEmail(ctx context.Context, token string, email string) (entity.Invitation, error) {
+	errInvalid := fmt.Errorf("%w: invalid invitation token", entity.ErrBadRequest)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return entity.Invitation{}, errInvalid
+	}
+
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return entity.Invitation{}, errInvalid
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return entity.Invitation{}, errInvalid
+	}
+
+	inv, err := us.invitation.InvitationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return entity.Invitation{}, errInvalid
+		}
+
+		return entity.Invitation{}, err
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(us.sign(inv.ID, expires, inv.Email))) || inv.ExpiresAt.Unix() != expires {
+		return entity.Invitation{}, errInvalid
+	}
+
+	if !inv.Pending(time.Now()) {
+		return entity.Invitation{}, fmt.Errorf("%w: invitation is no longer valid", entity.ErrExpired)
+	}
+
+	if !strings.EqualFold(inv.Email, strings.TrimSpace(email)) {
+		return entity.Invitation{}, fmt.Errorf("%w: invitation is addressed to another email", entity.ErrForbidden)
+	}
+
+	return inv, nil
+}
+
+func (us *InvitationService) accept(ctx context.Context, inv entity.Invitation, userID int64) error {
+	err := us.invitation.AcceptInvitation(ctx, inv.ID, userID, time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("%w: invitation is no longer valid", entity.ErrExpired)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (us *InvitationService) sendInvitation(inv entity.Invitation, project entity.Project) error {
+	token := fmt.Sprintf("%d.%d.%s", inv.ID, inv.ExpiresAt.Unix(), us.sign(inv.ID, inv.ExpiresAt.Unix(), inv.Email))
+
+	message := fmt.Sprintf("You are invited to join project %q as %s.\n"+
+		"Accept the invitation after signing in: %s/invitations/accept?token=%s\n"+
+		"New to us? Register with invite_token %s",
+		project.Name, inv.Role, appURL, token, token)
+
+	return us.mailer.Send(strconv.FormatInt(inv.ID, 10), "Project invitation", inv.Email, message)
+}
+
+func (us *InvitationService) sign(id int64, expires int64, email string) string {
+	mac := hmac.New(sha256.New, us.secret)
+	fmt.Fprintf(mac, "invitation:%d:%d:%s", id, expires, email)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+const (
+	maxLabelNameLength = 50
+	defaultLabelColor  = "#808080"
+)
+
+var labelColor = regexp.MustCompile("^#[0-9a-f]{6}$")
+
+type LabelRepository interface {
+	CreateLabel(ctx context.Context, l entity.Label) (entity.Label, error)
+	ProjectLabels(ctx context.Context, projectID int64) (labels []entity.Label, err error)
+	UpdateLabel(ctx context.Context, projectID int64, id int64, upd entity.LabelToUpdate, updatedAt time.Time) (l entity.Label, err error)
+	DeleteLabel(ctx context.Context, projectID int64, id int64, updatedAt time.Time) error
+	TagTask(ctx context.Context, taskID int64, labelID int64, updatedAt time.Time) error
+	UntagTask(ctx context.Context, taskID int64, labelID int64, updatedAt time.Time) error
+}
+
+func (us *ProjectService) ProjectLabels(ctx context.Context, projectID int64) ([]entity.Label, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	labels, err := us.label.ProjectLabels(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if labels == nil {
+		labels = []entity.Label{}
+	}
+
+	return labels, nil
+}
+
+// CreateLabel adds label to project, labels without a color are gray.
+func (us *ProjectService) CreateLabel(ctx context.Context, l entity.Label) (entity.Label, error) {
+	_, _, err := us.access.authorize(ctx, l.ProjectID, actionEditProject)
+	if err != nil {
+		return entity.Label{}, err
+	}
+
+	l.Name, err = validateLabelName(l.Name)
+	if err != nil {
+		return entity.Label{}, err
+	}
+
+	if l.Color == "" {
+		l.Color = defaultLabelColor
+	}
+
+	l.Color, err = validateLabelColor(l.Color)
+	if err != nil {
+		return entity.Label{}, err
+	}
+
+	l.CreatedAt = time.Now()
+
+	return us.label.CreateLabel(ctx, l)
+}
+
+func (us *ProjectService) UpdateLabel(ctx context.Context, projectID int64, id int64, upd entity.LabelToUpdate) (entity.Label, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return entity.Label{}, err
+	}
+
+	if upd.Name != nil {
+		name, err := validateLabelName(*upd.Name)
+		if err != nil {
+			return entity.Label{}, err
+		}
+
+		upd.Name = &name
+	}
+
+	if upd.Color != nil {
+		color, err := validateLabelColor(*upd.Color)
+		if err != nil {
+			return entity.Label{}, err
+		}
+
+		upd.Color = &color
+	}
+
+	return us.label.UpdateLabel(ctx, projectID, id, upd, time.Now())
+}
+
+func (us *ProjectService) DeleteLabel(ctx context.Context, projectID int64, id int64) error {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return err
+	}
+
+	return us.label.DeleteLabel(ctx, projectID, id, time.Now())
+}
+
+func (us *ProjectService) TagTask(ctx context.Context, taskID int64, labelID int64) error {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	return us.label.TagTask(ctx, taskID, labelID, time.Now())
+}
+
+func (us *ProjectService) UntagTask(ctx context.Context, taskID int64, labelID int64) error {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	return us.label.UntagTask(ctx, taskID, labelID, time.Now())
+}
+
+func validateLabelName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || len(name) > maxLabelNameLength || strings.Contains(name, ",") {
+		return "", fmt.Errorf("%w: label name must be 1 to %d bytes long without commas", entity.ErrBadRequest, maxLabelNameLength)
+	}
+
+	return name, nil
+}
+
+func validateLabelColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+
+	if !labelColor.MatchString(color) {
+		return "", fmt.Errorf("%w: label color must look like #1a2b3c", entity.ErrBadRequest)
+	}
+
+	return color, nil
+}
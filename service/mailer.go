@@ -0,0 +1,38 @@
+package service
+
+import (
+	"encoding/json"
+	"github.com/segmentio/kafka-go"
+)
+
+// Mailer publishes emails to the Kafka topic consumed by the mail sender.
+type Mailer struct {
+	kafka *kafka.Conn
+}
+
+func NewMailer(kafkaConn *kafka.Conn) *Mailer {
+	return &Mailer{kafka: kafkaConn}
+}
+
+func (m *Mailer) Send(key string, subject string, receiver string, message string) error {
+	b, err := json.Marshal(map[string]string{
+		"subject":  subject,
+		"receiver": receiver,
+		"message":  message,
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(key),
+		Value: b,
+	}
+
+	_, err = m.kafka.WriteMessages(msg)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
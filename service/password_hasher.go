@@ -0,0 +1,223 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"restAPI/entity"
+	"strings"
+)
+
+const minPasswordLength = 8
+
+// PasswordAlgorithm is a single hashing scheme. Hashes it produces must be
+// self-describing: the encoded string records the algorithm and its cost.
+type PasswordAlgorithm interface {
+	Hash(password string) (string, error)
+	// Compare reports whether password matches hash and whether hash was
+	// produced with weaker parameters than the algorithm currently uses.
+	Compare(hash string, password string) (match bool, weaker bool, err error)
+	Owns(hash string) bool
+}
+
+// PasswordHasher hashes new passwords with the current algorithm and verifies
+// stored ones with whichever known algorithm produced them.
+type PasswordHasher struct {
+	current    PasswordAlgorithm
+	algorithms []PasswordAlgorithm
+}
+
+// NewPasswordHasher returns hasher which creates hashes with current and still
+// accepts hashes created by legacy algorithms or stored as plain text.
+func NewPasswordHasher(current PasswordAlgorithm, legacy ...PasswordAlgorithm) *PasswordHasher {
+	algorithms := append([]PasswordAlgorithm{current}, legacy...)
+
+	return &PasswordHasher{
+		current:    current,
+		algorithms: append(algorithms, plaintext{}),
+	}
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Verify checks password against hash. rehash is true when the password matched
+// but hash should be replaced with a fresh one made by the current algorithm.
+func (h *PasswordHasher) Verify(hash string, password string) (match bool, rehash bool, err error) {
+	for _, alg := range h.algorithms {
+		if !alg.Owns(hash) {
+			continue
+		}
+
+		match, weaker, err := alg.Compare(hash, password)
+		if err != nil || !match {
+			return false, false, err
+		}
+
+		return true, weaker || alg != h.current, nil
+	}
+
+	return false, false, errors.New("unknown password hash format")
+}
+
+// IsHashed reports whether stored value was produced by one of the known algorithms.
+func (h *PasswordHasher) IsHashed(stored string) bool {
+	return !(plaintext{}).Owns(stored)
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", entity.ErrBadRequest, minPasswordLength)
+	}
+
+	return nil
+}
+
+// Argon2id hashes passwords with argon2id and encodes them in PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2id struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func NewArgon2id() *Argon2id {
+	return &Argon2id{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	hash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	return hash, nil
+}
+
+func (a *Argon2id) Compare(hash string, password string) (bool, bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return false, false, err
+	}
+
+	if version != argon2.Version {
+		return false, false, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism)
+	if err != nil {
+		return false, false, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, err
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(key, actual) != 1 {
+		return false, false, nil
+	}
+
+	weaker := memory < a.Memory || iterations < a.Iterations || parallelism < a.Parallelism ||
+		uint32(len(salt)) < a.SaltLength || uint32(len(key)) < a.KeyLength
+
+	return true, weaker, nil
+}
+
+func (a *Argon2id) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// Bcrypt hashes passwords with bcrypt, which records its cost in the hash itself.
+type Bcrypt struct {
+	Cost int
+}
+
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{Cost: cost}
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (b *Bcrypt) Compare(hash string, password string) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, cost < b.Cost, nil
+}
+
+func (b *Bcrypt) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// plaintext matches passwords stored before hashing was introduced.
+// Such rows always need a rehash.
+type plaintext struct{}
+
+func (plaintext) Hash(password string) (string, error) {
+	return "", errors.New("plaintext passwords must not be created")
+}
+
+func (plaintext) Compare(hash string, password string) (bool, bool, error) {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1, true, nil
+}
+
+func (plaintext) Owns(hash string) bool {
+	return !(&Argon2id{}).Owns(hash) && !(&Bcrypt{}).Owns(hash)
+}
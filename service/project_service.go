@@ -2,35 +2,66 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"restAPI/entity"
+	"strings"
 	"time"
 )
 
 type TaskRepository interface {
 	CreateTask(ctx context.Context, t entity.Task) (entity.Task, error)
 	TaskByID(ctx context.Context, id int64) (t entity.Task, err error)
-	ProjectTasks(ctx context.Context, projectID int64) (tasks []entity.Task, err error)
-	UserTasks(ctx context.Context, userID int64) (tasks []entity.Task, err error)
+	ProjectTasks(ctx context.Context, projectID int64, f entity.TaskFilter) (entity.Page[entity.Task], error)
+	UserTasks(ctx context.Context, userID int64, f entity.TaskFilter) (entity.Page[entity.Task], error)
+	AssignedTasks(ctx context.Context, userID int64, f entity.TaskFilter) (entity.Page[entity.Task], error)
+	AssignTask(ctx context.Context, id int64, assigneeID *int64, updatedAt time.Time) (t entity.Task, err error)
+	UpdateTask(ctx context.Context, id int64, upd entity.TaskToUpdate, updatedAt time.Time) (t entity.Task, err error)
+	DeleteTask(ctx context.Context, id int64, children entity.SubtaskAction) error
+	SetTaskParent(ctx context.Context, id int64, parentID *int64, maxDepth int, updatedAt time.Time) (t entity.Task, err error)
+	SetTaskSprint(ctx context.Context, id int64, sprintID *int64, updatedAt time.Time) (t entity.Task, err error)
+	TaskDepth(ctx context.Context, id int64) (depth int, err error)
+	Subtasks(ctx context.Context, parentID int64) ([]entity.Task, error)
+	AddDependency(ctx context.Context, blockerID int64, blockedID int64, createdAt time.Time) error
+	RemoveDependency(ctx context.Context, blockerID int64, blockedID int64) error
+	TaskDependencies(ctx context.Context, id int64) (deps entity.TaskDependencies, err error)
+	SetTaskStatus(ctx context.Context, id int64, fromStatusID int64, toStatusID int64, completedAt *time.Time, updatedAt time.Time) (t entity.Task, err error)
+	MoveTask(ctx context.Context, id int64, fromStatusID int64, toStatusID int64, afterID *int64, completedAt *time.Time, updatedAt time.Time) (t entity.Task, err error)
+	BoardTasks(ctx context.Context, projectID int64) ([]entity.Task, error)
 }
 
 type ProjectRepository interface {
 	CreateProject(ctx context.Context, project entity.Project) (entity.Project, error)
-	UserProjects(ctx context.Context, userID int64) (projects []entity.Project, err error)
+	UserProjects(ctx context.Context, userID int64, lq entity.ListQuery) (entity.Page[entity.Project], error)
 	ProjectByID(ctx context.Context, id int64) (p entity.Project, err error)
+	UpdateProject(ctx context.Context, id int64, upd entity.ProjectToUpdate) (p entity.Project, err error)
+	TransferProject(ctx context.Context, projectID int64, fromUserID int64, toUserID int64) error
 	DeleteProject(ctx context.Context, projectID int64) error
-	AddProjectMember(ctx context.Context, projectID int64, userID int64) error
+	AddProjectMember(ctx context.Context, projectID int64, userID int64, role entity.Role) error
+	MemberRole(ctx context.Context, projectID int64, userID int64) (role entity.Role, err error)
+	UpdateMemberRole(ctx context.Context, projectID int64, userID int64, role entity.Role) error
+	RemoveProjectMember(ctx context.Context, projectID int64, userID int64, reassignTo int64, updatedAt time.Time) error
 }
 
 type ProjectService struct {
-	project ProjectRepository
-	task    TaskRepository
+	project   ProjectRepository
+	task      TaskRepository
+	status    StatusRepository
+	checklist ChecklistRepository
+	label     LabelRepository
+	field     CustomFieldRepository
+	access    authorizer
 }
 
-func NewProjectRepository(project ProjectRepository, task TaskRepository) *ProjectService {
+func NewProjectRepository(project ProjectRepository, task TaskRepository, status StatusRepository, checklist ChecklistRepository, label LabelRepository, field CustomFieldRepository) *ProjectService {
 	return &ProjectService{
-		project: project,
-		task:    task,
+		project:   project,
+		task:      task,
+		status:    status,
+		checklist: checklist,
+		label:     label,
+		field:     field,
+		access:    authorizer{project: project, task: task},
 	}
 }
 
@@ -49,35 +80,57 @@ func (us *ProjectService) CreateProject(ctx context.Context, project entity.Proj
 }
 
 func (us *ProjectService) ProjectByID(ctx context.Context, id int64) (entity.Project, error) {
-	user := entity.AuthUser(ctx)
-
-	project, err := us.project.ProjectByID(ctx, id)
+	project, _, err := us.access.authorize(ctx, id, actionRead)
 	if err != nil {
 		return entity.Project{}, err
 	}
 
-	if user.ID != project.UserID {
-		return entity.Project{}, fmt.Errorf("%w: not your project", entity.ErrForbidden)
-	}
-
 	return project, nil
 }
 
-func (us *ProjectService) UserProjects(ctx context.Context) ([]entity.Project, error) {
+func (us *ProjectService) UserProjects(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.Project], error) {
 	user := entity.AuthUser(ctx)
-	return us.project.UserProjects(ctx, user.ID)
+	return us.project.UserProjects(ctx, user.ID, lq)
 }
 
-func (us *ProjectService) DeleteProject(ctx context.Context, projectID int64) error {
+func (us *ProjectService) UpdateProject(ctx context.Context, projectID int64, upd entity.ProjectToUpdate) (entity.Project, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return entity.Project{}, err
+	}
+
+	if upd.Name != nil {
+		name := strings.TrimSpace(*upd.Name)
+		if name == "" {
+			return entity.Project{}, fmt.Errorf("%w: project name can't be empty", entity.ErrBadRequest)
+		}
+
+		upd.Name = &name
+	}
+
+	return us.project.UpdateProject(ctx, projectID, upd)
+}
+
+// TransferProject hands the project over to another member, the previous owner stays as admin.
+func (us *ProjectService) TransferProject(ctx context.Context, projectID int64, userID int64) error {
 	user := entity.AuthUser(ctx)
 
-	project, err := us.project.ProjectByID(ctx, projectID)
+	_, _, err := us.access.authorize(ctx, projectID, actionManageProject)
 	if err != nil {
 		return err
 	}
 
-	if user.ID != project.UserID {
-		return fmt.Errorf("%w: not your project", entity.ErrForbidden)
+	if userID == user.ID {
+		return fmt.Errorf("%w: you already own the project", entity.ErrBadRequest)
+	}
+
+	return us.project.TransferProject(ctx, projectID, user.ID, userID)
+}
+
+func (us *ProjectService) DeleteProject(ctx context.Context, projectID int64) error {
+	_, _, err := us.access.authorize(ctx, projectID, actionManageProject)
+	if err != nil {
+		return err
 	}
 
 	err = us.project.DeleteProject(ctx, projectID)
@@ -89,90 +142,556 @@ func (us *ProjectService) DeleteProject(ctx context.Context, projectID int64) er
 }
 
 func (us *ProjectService) CreateTask(ctx context.Context, cTask entity.TaskToCreate) (entity.Task, error) {
-	project, err := us.project.ProjectByID(ctx, cTask.ProjectID)
+	_, role, err := us.access.authorize(ctx, cTask.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	err = validateTaskName(cTask.Name)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if cTask.Priority == "" {
+		cTask.Priority = entity.PriorityNone
+	}
+
+	err = validateSchedule(cTask.Priority, cTask.StartAt, cTask.DueAt)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	err = validateEstimate(cTask.EstimateMinutes)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	customFields, err := us.validateCustomFieldValues(ctx, cTask.ProjectID, cTask.CustomFields)
 	if err != nil {
 		return entity.Task{}, err
 	}
 
 	user := entity.AuthUser(ctx)
 
-	if project.UserID != user.ID {
-		return entity.Task{}, fmt.Errorf("%w: not your project", entity.ErrForbidden)
+	if cTask.AssigneeID != nil {
+		err = us.validateAssignee(ctx, cTask.ProjectID, role, *cTask.AssigneeID)
+		if err != nil {
+			return entity.Task{}, err
+		}
+	}
+
+	if cTask.ParentTaskID != nil {
+		err = us.validateParent(ctx, cTask.ProjectID, *cTask.ParentTaskID)
+		if err != nil {
+			return entity.Task{}, err
+		}
 	}
 
 	task := entity.Task{
-		Name:        cTask.Name,
-		UserID:      user.ID,
-		Description: cTask.Description,
-		ProjectID:   cTask.ProjectID,
-		CreatedAt:   time.Now(),
+		Name:            cTask.Name,
+		UserID:          user.ID,
+		Description:     cTask.Description,
+		ProjectID:       cTask.ProjectID,
+		AssigneeID:      cTask.AssigneeID,
+		Priority:        cTask.Priority,
+		StartAt:         cTask.StartAt,
+		DueAt:           cTask.DueAt,
+		ParentTaskID:    cTask.ParentTaskID,
+		EstimateMinutes: cTask.EstimateMinutes,
+		CustomFields:    customFields,
+		CreatedAt:       time.Now(),
 	}
 
 	return us.task.CreateTask(ctx, task)
 }
 
-func (us *ProjectService) TaskByID(ctx context.Context, id int64) (entity.Task, error) {
-	user := entity.AuthUser(ctx)
+// validateParent checks that a new task of project can be a subtask of parentID.
+func (us *ProjectService) validateParent(ctx context.Context, projectID int64, parentID int64) error {
+	parent, err := us.task.TaskByID(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("%w: parent task", entity.ErrNotFound)
+		}
+
+		return err
+	}
 
+	if parent.ProjectID != projectID {
+		return fmt.Errorf("%w: parent task", entity.ErrNotFound)
+	}
+
+	depth, err := us.task.TaskDepth(ctx, parentID)
+	if err != nil {
+		return err
+	}
+
+	if depth >= entity.MaxTaskDepth {
+		return fmt.Errorf("%w: subtasks can't be nested deeper than %d levels", entity.ErrBadRequest, entity.MaxTaskDepth)
+	}
+
+	return nil
+}
+
+// TaskByID returns task along with its checklist.
+func (us *ProjectService) TaskByID(ctx context.Context, id int64) (entity.Task, error) {
 	task, err := us.task.TaskByID(ctx, id)
 	if err != nil {
 		return entity.Task{}, err
 	}
 
-	if user.ID != task.UserID {
-		return entity.Task{}, fmt.Errorf("%w: not your task", entity.ErrForbidden)
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionRead)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	task.Checklist, err = us.checklist.Checklist(ctx, id)
+	if err != nil {
+		return entity.Task{}, err
 	}
 
 	return task, nil
 }
 
-func (us *ProjectService) ProjectTasks(ctx context.Context, projectID int64) ([]entity.Task, error) {
-	project, err := us.project.ProjectByID(ctx, projectID)
+func (us *ProjectService) UpdateTask(ctx context.Context, id int64, upd entity.TaskToUpdate) (entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return entity.Task{}, err
 	}
 
-	user := entity.AuthUser(ctx)
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if upd.Name != nil {
+		name := strings.TrimSpace(*upd.Name)
+
+		err = validateTaskName(name)
+		if err != nil {
+			return entity.Task{}, err
+		}
+
+		upd.Name = &name
+	}
+
+	priority, startAt, dueAt := task.Priority, task.StartAt, task.DueAt
+
+	if upd.Priority != nil {
+		priority = *upd.Priority
+	}
+
+	if (upd.ClearStartAt && upd.StartAt != nil) || (upd.ClearDueAt && upd.DueAt != nil) {
+		return entity.Task{}, fmt.Errorf("%w: a date can't be set and cleared at once", entity.ErrBadRequest)
+	}
+
+	if upd.StartAt != nil {
+		startAt = upd.StartAt
+	}
+
+	if upd.ClearStartAt {
+		startAt = nil
+	}
+
+	if upd.DueAt != nil {
+		dueAt = upd.DueAt
+	}
+
+	if upd.ClearDueAt {
+		dueAt = nil
+	}
 
-	if project.UserID != user.ID {
-		return nil, fmt.Errorf("%w: not your project", entity.ErrForbidden)
+	err = validateSchedule(priority, startAt, dueAt)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	err = validateEstimate(upd.EstimateMinutes)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	upd.CustomFields, err = us.validateCustomFieldValues(ctx, task.ProjectID, upd.CustomFields)
+	if err != nil {
+		return entity.Task{}, err
 	}
 
-	tasks, err := us.task.ProjectTasks(ctx, projectID)
+	return us.task.UpdateTask(ctx, id, upd, time.Now())
+}
+
+// DeleteTask deletes task, a task with subtasks needs children to tell what happens to them.
+func (us *ProjectService) DeleteTask(ctx context.Context, id int64, children entity.SubtaskAction) error {
+	task, err := us.task.TaskByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	if children != "" && children != entity.SubtasksCascade && children != entity.SubtasksPromote {
+		return fmt.Errorf("%w: children must be %s or %s", entity.ErrBadRequest, entity.SubtasksCascade, entity.SubtasksPromote)
+	}
+
+	return us.task.DeleteTask(ctx, id, children)
+}
+
+// SetTaskParent moves task under parentID, nil parentID makes it a top-level task.
+func (us *ProjectService) SetTaskParent(ctx context.Context, id int64, parentID *int64) (entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, id)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	return us.task.SetTaskParent(ctx, id, parentID, entity.MaxTaskDepth, time.Now())
+}
+
+func (us *ProjectService) Subtasks(ctx context.Context, id int64) ([]entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := us.task.Subtasks(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	if tasks == nil {
+		tasks = []entity.Task{}
+	}
+
 	return tasks, nil
 }
 
-func (us *ProjectService) UserTasks(ctx context.Context) ([]entity.Task, error) {
+// AssignTask assigns task to a project member, nil assigneeID unassigns it.
+// Members may only take tasks themselves or give up their own ones, admins hand out any task.
+func (us *ProjectService) AssignTask(ctx context.Context, id int64, assigneeID *int64) (entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, id)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	_, role, err := us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if assigneeID == nil {
+		user := entity.AuthUser(ctx)
+
+		if task.AssigneeID != nil && *task.AssigneeID != user.ID && !role.AtLeast(requiredRoles[actionAssignTasks]) {
+			return entity.Task{}, fmt.Errorf("%w: %s can't %s", entity.ErrForbidden, role, actionAssignTasks)
+		}
+	} else {
+		err = us.validateAssignee(ctx, task.ProjectID, role, *assigneeID)
+		if err != nil {
+			return entity.Task{}, err
+		}
+	}
+
+	return us.task.AssignTask(ctx, id, assigneeID, time.Now())
+}
+
+// validateAssignee checks that a member with requesterRole may assign a task to assigneeID.
+func (us *ProjectService) validateAssignee(ctx context.Context, projectID int64, requesterRole entity.Role, assigneeID int64) error {
 	user := entity.AuthUser(ctx)
 
-	tasks, err := us.task.UserTasks(ctx, user.ID)
+	if assigneeID != user.ID && !requesterRole.AtLeast(requiredRoles[actionAssignTasks]) {
+		return fmt.Errorf("%w: %s can't %s", entity.ErrForbidden, requesterRole, actionAssignTasks)
+	}
+
+	role, err := us.project.MemberRole(ctx, projectID, assigneeID)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("%w: tasks can only be assigned to a project member", entity.ErrBadRequest)
+		}
+
+		return err
 	}
 
-	return tasks, nil
+	if !role.AtLeast(entity.RoleMember) {
+		return fmt.Errorf("%w: tasks can't be assigned to %s", entity.ErrBadRequest, role)
+	}
+
+	return nil
+}
+
+func validateSchedule(priority entity.Priority, startAt *time.Time, dueAt *time.Time) error {
+	if !priority.Valid() {
+		return fmt.Errorf("%w: unknown priority %q", entity.ErrBadRequest, priority)
+	}
+
+	if startAt != nil && dueAt != nil && dueAt.Before(*startAt) {
+		return fmt.Errorf("%w: task can't be due before it starts", entity.ErrBadRequest)
+	}
+
+	return nil
+}
+
+const maxTaskNameLength = 256
+
+func validateTaskName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: task name can't be empty", entity.ErrBadRequest)
+	}
+
+	if len(name) > maxTaskNameLength {
+		return fmt.Errorf("%w: task name is longer than %d bytes", entity.ErrBadRequest, maxTaskNameLength)
+	}
+
+	return nil
 }
 
-func (us *ProjectService) AddProjectMember(ctx context.Context, projectID int64, userID int64) error {
-	requester := entity.AuthUser(ctx)
+// maxEstimateMinutes is a thousand hours.
+const maxEstimateMinutes = 1000 * 60
+
+// validateEstimate checks task estimate, 0 means no estimate.
+func validateEstimate(minutes *int) error {
+	if minutes != nil && (*minutes < 0 || *minutes > maxEstimateMinutes) {
+		return fmt.Errorf("%w: estimate must be 0 to %d minutes", entity.ErrBadRequest, maxEstimateMinutes)
+	}
+
+	return nil
+}
 
-	project, err := us.project.ProjectByID(ctx, projectID)
+func (us *ProjectService) ProjectTasks(ctx context.Context, projectID int64, f entity.TaskFilter) (entity.Page[entity.Task], error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return entity.Page[entity.Task]{}, err
+	}
+
+	f, err = resolveTaskFilter(ctx, f, time.Now())
+	if err != nil {
+		return entity.Page[entity.Task]{}, err
+	}
+
+	f, err = us.resolveCustomFieldFilter(ctx, projectID, f)
+	if err != nil {
+		return entity.Page[entity.Task]{}, err
+	}
+
+	return us.task.ProjectTasks(ctx, projectID, f)
+}
+
+// UserTasks returns tasks created by authenticated user, or tasks assigned to them
+// from all projects when f.AssignedToMe is set.
+func (us *ProjectService) UserTasks(ctx context.Context, f entity.TaskFilter) (entity.Page[entity.Task], error) {
+	user := entity.AuthUser(ctx)
+
+	assigned := f.AssignedToMe
+	f.AssignedToMe = false
+
+	// custom fields are defined per project
+	if len(f.CustomFieldQueries) > 0 || strings.HasPrefix(strings.TrimPrefix(f.Sort, "-"), customFieldSortPrefix) {
+		return entity.Page[entity.Task]{}, fmt.Errorf("%w: custom fields can only filter and sort tasks of a project", entity.ErrBadRequest)
+	}
+
+	f, err := resolveTaskFilter(ctx, f, time.Now())
+	if err != nil {
+		return entity.Page[entity.Task]{}, err
+	}
+
+	if assigned {
+		return us.task.AssignedTasks(ctx, user.ID, f)
+	}
+
+	return us.task.UserTasks(ctx, user.ID, f)
+}
+
+func (us *ProjectService) AddProjectMember(ctx context.Context, projectID int64, userID int64, role entity.Role) error {
+	_, requesterRole, err := us.access.authorize(ctx, projectID, actionManageMembers)
 	if err != nil {
 		return err
 	}
 
-	if requester.ID != project.UserID {
-		return fmt.Errorf("%w: not your project", entity.ErrForbidden)
+	err = validateGrantedRole(requesterRole, role)
+	if err != nil {
+		return err
 	}
 
-	err = us.project.AddProjectMember(ctx, projectID, userID)
+	err = us.project.AddProjectMember(ctx, projectID, userID, role)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (us *ProjectService) SetMemberRole(ctx context.Context, projectID int64, userID int64, role entity.Role) error {
+	_, requesterRole, err := us.access.authorize(ctx, projectID, actionManageMembers)
+	if err != nil {
+		return err
+	}
+
+	err = validateGrantedRole(requesterRole, role)
+	if err != nil {
+		return err
+	}
+
+	current, err := us.project.MemberRole(ctx, projectID, userID)
+	if err != nil {
+		return err
+	}
+
+	if current == entity.RoleOwner {
+		return fmt.Errorf("%w: owner's role changes only by transferring the project", entity.ErrConflict)
+	}
+
+	if !requesterRole.AtLeast(current) {
+		return fmt.Errorf("%w: can't change role of %s", entity.ErrForbidden, current)
+	}
+
+	return us.project.UpdateMemberRole(ctx, projectID, userID, role)
+}
+
+// RemoveProjectMember removes user from project. Tasks assigned to user go to reassignTo member,
+// or are left unassigned when reassignTo is 0.
+func (us *ProjectService) RemoveProjectMember(ctx context.Context, projectID int64, userID int64, reassignTo int64) error {
+	project, requesterRole, err := us.access.authorize(ctx, projectID, actionManageMembers)
+	if err != nil {
+		return err
+	}
+
+	role, err := us.project.MemberRole(ctx, projectID, userID)
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return fmt.Errorf("%w: not a project member", entity.ErrNotFound)
+		}
+
+		return err
+	}
+
+	if role == entity.RoleOwner {
+		return fmt.Errorf("%w: project owner can't be removed, transfer the project first", entity.ErrConflict)
+	}
+
+	if !requesterRole.AtLeast(role) {
+		return fmt.Errorf("%w: can't remove %s", entity.ErrForbidden, role)
+	}
+
+	return us.removeMember(ctx, project, userID, reassignTo)
+}
+
+// LeaveProject removes authenticated user from project, see RemoveProjectMember for reassignTo.
+func (us *ProjectService) LeaveProject(ctx context.Context, projectID int64, reassignTo int64) error {
+	user := entity.AuthUser(ctx)
+
+	project, role, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return err
+	}
+
+	if role == entity.RoleOwner {
+		return fmt.Errorf("%w: owner can't leave, transfer the project first", entity.ErrConflict)
+	}
+
+	return us.removeMember(ctx, project, user.ID, reassignTo)
+}
+
+func (us *ProjectService) removeMember(ctx context.Context, project entity.Project, userID int64, reassignTo int64) error {
+	if reassignTo == userID {
+		return fmt.Errorf("%w: can't reassign tasks to the removed member", entity.ErrBadRequest)
+	}
+
+	return us.project.RemoveProjectMember(ctx, project.ID, userID, reassignTo, time.Now())
+}
+
+// validateGrantedRole checks that role can be given by a member with requesterRole.
+// Nobody can grant the owner role or a role above their own.
+func validateGrantedRole(requesterRole entity.Role, role entity.Role) error {
+	if !role.Valid() {
+		return fmt.Errorf("%w: unknown role %q", entity.ErrBadRequest, role)
+	}
+
+	if role == entity.RoleOwner {
+		return fmt.Errorf("%w: ownership can only be transferred", entity.ErrBadRequest)
+	}
+
+	if !requesterRole.AtLeast(role) {
+		return fmt.Errorf("%w: can't grant %s role", entity.ErrForbidden, role)
+	}
+
+	return nil
+}
+
+// resolveTaskFilter fills the filter fields which depend on the authenticated user.
+// f.Due turns into a range of due dates, days and weeks follow the calendar
+// of f.TimeZone, or of the user's time zone when it's not set.
+func resolveTaskFilter(ctx context.Context, f entity.TaskFilter, now time.Time) (entity.TaskFilter, error) {
+	if f.AssignedToMe {
+		user := entity.AuthUser(ctx)
+
+		f.AssigneeID = &user.ID
+		f.AssignedToMe = false
+	}
+
+	if f.Due == "" {
+		return f, nil
+	}
+
+	tz := f.TimeZone
+	if tz == "" {
+		tz = entity.AuthUser(ctx).TimeZone
+	}
+
+	loc, err := loadTimeZone(tz)
+	if err != nil {
+		return entity.TaskFilter{}, err
+	}
+
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	var from, to time.Time
+
+	switch f.Due {
+	case entity.DueOverdue:
+		f.Open = true
+		f.DueBefore = &now
+
+		return f, nil
+	case entity.DueToday:
+		from, to = today, today.AddDate(0, 0, 1)
+	case entity.DueWeek:
+		// weeks start on Monday
+		from = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		to = from.AddDate(0, 0, 7)
+	default:
+		return entity.TaskFilter{}, fmt.Errorf("%w: 'due' must be one of overdue, today, week", entity.ErrBadRequest)
+	}
+
+	f.DueAfter, f.DueBefore = &from, &to
+
+	return f, nil
+}
+
+// loadTimeZone loads IANA time zone, empty name means UTC. "Local" is refused as it is
+// the zone of whichever host serves the request.
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: unknown time zone %q", entity.ErrBadRequest, name)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", entity.ErrBadRequest, name)
+	}
+
+	return loc, nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"fmt"
+	"restAPI/entity"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxRecurrenceInterval = 366
+	maxRecurrenceCount    = 10000
+	// maxRecurrencePeriods stops rules which never produce another occurrence.
+	maxRecurrencePeriods = 100000
+)
+
+const (
+	freqDaily   = "DAILY"
+	freqWeekly  = "WEEKLY"
+	freqMonthly = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// recurrenceRule is a parsed entity.Recurrence rule, see there for the supported subset.
+type recurrenceRule struct {
+	freq     string
+	interval int
+	// byDay is ordered from Monday, weeks start on Monday.
+	byDay []time.Weekday
+	until *time.Time
+	count int
+}
+
+// parseRecurrenceRule parses rule, a date-only UNTIL includes the whole day in loc.
+func parseRecurrenceRule(rule string, loc *time.Location) (recurrenceRule, error) {
+	r := recurrenceRule{interval: 1}
+
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return recurrenceRule{}, fmt.Errorf("%w: malformed rule part %q", entity.ErrBadRequest, part)
+		}
+
+		var err error
+
+		switch key {
+		case "FREQ":
+			if value != freqDaily && value != freqWeekly && value != freqMonthly {
+				return recurrenceRule{}, fmt.Errorf("%w: FREQ must be %s, %s or %s", entity.ErrBadRequest, freqDaily, freqWeekly, freqMonthly)
+			}
+
+			r.freq = value
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err != nil || r.interval < 1 || r.interval > maxRecurrenceInterval {
+				return recurrenceRule{}, fmt.Errorf("%w: INTERVAL must be 1 to %d", entity.ErrBadRequest, maxRecurrenceInterval)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				wd, ok := weekdays[day]
+				if !ok {
+					return recurrenceRule{}, fmt.Errorf("%w: unknown BYDAY day %q", entity.ErrBadRequest, day)
+				}
+
+				if !slices.Contains(r.byDay, wd) {
+					r.byDay = append(r.byDay, wd)
+				}
+			}
+
+			slices.SortFunc(r.byDay, func(a, b time.Weekday) int { return daysFromMonday(a) - daysFromMonday(b) })
+		case "UNTIL":
+			until, err := time.Parse("20060102T150405Z", value)
+			if err != nil {
+				day, err := time.ParseInLocation("20060102", value, loc)
+				if err != nil {
+					return recurrenceRule{}, fmt.Errorf("%w: UNTIL must be a date or a UTC time, e.g. 20240131 or 20240131T235959Z", entity.ErrBadRequest)
+				}
+
+				until = day.AddDate(0, 0, 1).Add(-time.Second)
+			}
+
+			r.until = &until
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err != nil || r.count < 1 || r.count > maxRecurrenceCount {
+				return recurrenceRule{}, fmt.Errorf("%w: COUNT must be 1 to %d", entity.ErrBadRequest, maxRecurrenceCount)
+			}
+		default:
+			return recurrenceRule{}, fmt.Errorf("%w: unsupported rule part %s", entity.ErrBadRequest, key)
+		}
+	}
+
+	if r.freq == "" {
+		return recurrenceRule{}, fmt.Errorf("%w: FREQ is required", entity.ErrBadRequest)
+	}
+
+	if len(r.byDay) > 0 && r.freq == freqMonthly {
+		return recurrenceRule{}, fmt.Errorf("%w: BYDAY is supported with %s and %s only", entity.ErrBadRequest, freqDaily, freqWeekly)
+	}
+
+	if r.until != nil && r.count > 0 {
+		return recurrenceRule{}, fmt.Errorf("%w: UNTIL and COUNT can't be used together", entity.ErrBadRequest)
+	}
+
+	return r, nil
+}
+
+// String returns the rule in a normalized form.
+func (r recurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.freq}
+
+	if r.interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.interval))
+	}
+
+	if len(r.byDay) > 0 {
+		days := make([]string, 0, len(r.byDay))
+		for _, wd := range r.byDay {
+			days = append(days, strings.ToUpper(wd.String()[:2]))
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if r.until != nil {
+		parts = append(parts, "UNTIL="+r.until.UTC().Format("20060102T150405Z"))
+	}
+
+	if r.count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.count))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// next returns the first occurrence after moment after of the rule starting at start,
+// ok is false once the rule has ended. Occurrences before start don't count.
+func (r recurrenceRule) next(start time.Time, loc *time.Location, after time.Time) (occurrence time.Time, ok bool) {
+	start = start.In(loc)
+	n := 0
+
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, occurrence := range r.period(start, loc, period) {
+			if occurrence.Before(start) {
+				continue
+			}
+
+			n++
+
+			if (r.count > 0 && n > r.count) || (r.until != nil && occurrence.After(*r.until)) {
+				return time.Time{}, false
+			}
+
+			if occurrence.After(after) {
+				return occurrence, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// period returns occurrences within the period-th day, week or month of the rule, in order.
+func (r recurrenceRule) period(start time.Time, loc *time.Location, period int) []time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, loc)
+	}
+
+	switch r.freq {
+	case freqDaily:
+		day := at(start.Year(), start.Month(), start.Day()+period*r.interval)
+		if len(r.byDay) > 0 && !slices.Contains(r.byDay, day.Weekday()) {
+			return nil
+		}
+
+		return []time.Time{day}
+	case freqWeekly:
+		monday := start.Day() - daysFromMonday(start.Weekday()) + period*r.interval*7
+
+		days := r.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+
+		occurrences := make([]time.Time, 0, len(days))
+		for _, wd := range days {
+			occurrences = append(occurrences, at(start.Year(), start.Month(), monday+daysFromMonday(wd)))
+		}
+
+		return occurrences
+	default:
+		month := at(start.Year(), start.Month()+time.Month(period*r.interval), 1)
+
+		// months without the day of start are skipped
+		day := at(month.Year(), month.Month(), start.Day())
+		if day.Month() != month.Month() {
+			return nil
+		}
+
+		return []time.Time{day}
+	}
+}
+
+func daysFromMonday(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
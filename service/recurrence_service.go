@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"restAPI/entity"
+	"time"
+)
+
+const (
+	recurrenceBatch = 50
+	// recurrenceLease is how long a claimed recurrence is hidden from other schedulers.
+	recurrenceLease = 5 * time.Minute
+)
+
+type RecurrenceRepository interface {
+	SetRecurrence(ctx context.Context, rec entity.Recurrence) (entity.Recurrence, error)
+	RecurrenceByTask(ctx context.Context, taskID int64) (rec entity.Recurrence, err error)
+	DeleteRecurrence(ctx context.Context, taskID int64) error
+	ClaimDueRecurrences(ctx context.Context, now time.Time, leaseUntil time.Time, limit int) (recs []entity.Recurrence, err error)
+	AdvanceRecurrence(ctx context.Context, id int64, from time.Time, next *time.Time) error
+}
+
+type RecurrenceService struct {
+	recurrence RecurrenceRepository
+	task       TaskRepository
+	access     authorizer
+}
+
+func NewRecurrenceService(recurrence RecurrenceRepository, task TaskRepository, project ProjectRepository) *RecurrenceService {
+	return &RecurrenceService{
+		recurrence: recurrence,
+		task:       task,
+		access:     authorizer{project: project},
+	}
+}
+
+// SetRecurrence makes task a template repeated by set.Rule, the first task is created
+// at the first occurrence from now on.
+func (us *RecurrenceService) SetRecurrence(ctx context.Context, taskID int64, set entity.RecurrenceToSet) (entity.Recurrence, error) {
+	task, err := us.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return entity.Recurrence{}, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Recurrence{}, err
+	}
+
+	if task.RecurrenceID != nil {
+		return entity.Recurrence{}, fmt.Errorf("%w: task was created by a recurrence, set it on the template", entity.ErrConflict)
+	}
+
+	if set.TimeZone == "" {
+		set.TimeZone = entity.AuthUser(ctx).TimeZone
+	}
+
+	loc, err := loadTimeZone(set.TimeZone)
+	if err != nil {
+		return entity.Recurrence{}, err
+	}
+
+	rule, err := parseRecurrenceRule(set.Rule, loc)
+	if err != nil {
+		return entity.Recurrence{}, err
+	}
+
+	now := time.Now()
+
+	if set.StartAt.IsZero() {
+		set.StartAt = now
+		if task.StartAt != nil {
+			set.StartAt = *task.StartAt
+		}
+	}
+
+	next, ok := rule.next(set.StartAt, loc, now)
+	if !ok {
+		return entity.Recurrence{}, fmt.Errorf("%w: rule has no occurrences left", entity.ErrBadRequest)
+	}
+
+	return us.recurrence.SetRecurrence(ctx, entity.Recurrence{
+		TaskID:    taskID,
+		Rule:      rule.String(),
+		StartAt:   set.StartAt,
+		TimeZone:  loc.String(),
+		NextAt:    &next,
+		CreatedAt: now,
+	})
+}
+
+func (us *RecurrenceService) Recurrence(ctx context.Context, taskID int64) (entity.Recurrence, error) {
+	task, err := us.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return entity.Recurrence{}, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionRead)
+	if err != nil {
+		return entity.Recurrence{}, err
+	}
+
+	return us.recurrence.RecurrenceByTask(ctx, taskID)
+}
+
+func (us *RecurrenceService) DeleteRecurrence(ctx context.Context, taskID int64) error {
+	task, err := us.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	return us.recurrence.DeleteRecurrence(ctx, taskID)
+}
+
+// RunScheduler creates tasks of due recurrences every interval until ctx is done.
+// Any number of schedulers may run at once, each occurrence gets a single task.
+func (us *RecurrenceService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := us.createDueTasks(ctx, time.Now())
+		if err != nil {
+			log.Println("recurrence scheduler:", err)
+		} else if n > 0 {
+			log.Printf("recurrence scheduler: created %d tasks", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// createDueTasks creates a task for every occurrence due at now, including ones missed while no scheduler ran.
+func (us *RecurrenceService) createDueTasks(ctx context.Context, now time.Time) (int, error) {
+	created := 0
+
+	for {
+		recs, err := us.recurrence.ClaimDueRecurrences(ctx, now, now.Add(recurrenceLease), recurrenceBatch)
+		if err != nil {
+			return created, err
+		}
+
+		for _, rec := range recs {
+			ok, err := us.createOccurrence(ctx, rec)
+			if err != nil {
+				// the lease runs out and the occurrence is retried later
+				log.Printf("recurrence scheduler: recurrence %d: %v", rec.ID, err)
+				continue
+			}
+
+			if ok {
+				created++
+			}
+		}
+
+		if len(recs) < recurrenceBatch {
+			return created, nil
+		}
+	}
+}
+
+// createOccurrence creates the task of the next occurrence of rec and moves rec to the following one.
+// The task may already exist if an earlier run failed to move rec, ok tells whether it was created now.
+func (us *RecurrenceService) createOccurrence(ctx context.Context, rec entity.Recurrence) (ok bool, err error) {
+	loc, err := loadTimeZone(rec.TimeZone)
+	if err != nil {
+		return false, err
+	}
+
+	rule, err := parseRecurrenceRule(rec.Rule, loc)
+	if err != nil {
+		return false, err
+	}
+
+	template, err := us.task.TaskByID(ctx, rec.TaskID)
+	if err != nil {
+		return false, err
+	}
+
+	occurrence := *rec.NextAt
+
+	task := entity.Task{
+		Name:            template.Name,
+		Description:     template.Description,
+		UserID:          template.UserID,
+		AssigneeID:      template.AssigneeID,
+		ProjectID:       template.ProjectID,
+		ParentTaskID:    template.ParentTaskID,
+		Priority:        template.Priority,
+		EstimateMinutes: template.EstimateMinutes,
+		CustomFields:    template.CustomFields,
+		StartAt:         &occurrence,
+		RecurrenceID:    &rec.ID,
+		OccurrenceAt:    &occurrence,
+		CreatedAt:       time.Now(),
+	}
+
+	// tasks keep the time the template has between its start and due date
+	if template.StartAt != nil && template.DueAt != nil {
+		dueAt := occurrence.Add(template.DueAt.Sub(*template.StartAt))
+		task.DueAt = &dueAt
+	}
+
+	_, err = us.task.CreateTask(ctx, task)
+	if err != nil && !errors.Is(err, entity.ErrConflict) {
+		return false, err
+	}
+
+	ok = err == nil
+
+	var next *time.Time
+	if t, more := rule.next(rec.StartAt, loc, occurrence); more {
+		next = &t
+	}
+
+	return ok, us.recurrence.AdvanceRecurrence(ctx, rec.ID, occurrence, next)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchRepository interface {
+	Search(ctx context.Context, userID int64, sq entity.SearchQuery) (results []entity.SearchResult, err error)
+}
+
+type SearchService struct {
+	search SearchRepository
+}
+
+func NewSearchService(search SearchRepository) *SearchService {
+	return &SearchService{search: search}
+}
+
+// Search finds tasks and projects of the projects authenticated user is a member of.
+func (us *SearchService) Search(ctx context.Context, sq entity.SearchQuery) ([]entity.SearchResult, error) {
+	user := entity.AuthUser(ctx)
+
+	sq.Text = strings.TrimSpace(sq.Text)
+	if sq.Text == "" {
+		return nil, fmt.Errorf("%w: search query can't be empty", entity.ErrBadRequest)
+	}
+
+	if sq.Type != "" && sq.Type != entity.SearchTypeTask && sq.Type != entity.SearchTypeProject {
+		return nil, fmt.Errorf("%w: unknown search type %q", entity.ErrBadRequest, sq.Type)
+	}
+
+	if sq.Limit == 0 {
+		sq.Limit = defaultSearchLimit
+	}
+
+	if sq.Limit < 0 || sq.Limit > maxSearchLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", entity.ErrBadRequest, maxSearchLimit)
+	}
+
+	results, err := us.search.Search(ctx, user.ID, sq)
+	if err != nil {
+		return nil, err
+	}
+
+	if results == nil {
+		results = []entity.SearchResult{}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,462 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"restAPI/entity"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	r, err := parseRecurrenceRule("rrule:freq=weekly;byday=fr,mo,mo;interval=2", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", r.String())
+
+	r, err = parseRecurrenceRule("FREQ=DAILY;UNTIL=20240131T120000Z", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "FREQ=DAILY;UNTIL=20240131T120000Z", r.String())
+
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=367",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240131",
+		"FREQ=DAILY;UNTIL=2024-01-31",
+		"FREQ=DAILY;BYMONTH=1",
+		"FREQ=DAILY;COUNT",
+	} {
+		_, err = parseRecurrenceRule(rule, time.UTC)
+		require.ErrorIs(t, err, entity.ErrBadRequest, rule)
+	}
+}
+
+func TestRecurrenceRule_Next(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	date := func(loc *time.Location, year int, month time.Month, day int, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		loc   *time.Location
+		start time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "daily with interval",
+			rule:  "FREQ=DAILY;INTERVAL=3;COUNT=3",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 30, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 30, 9), date(time.UTC, 2024, 2, 2, 9), date(time.UTC, 2024, 2, 5, 9)},
+		},
+		{
+			name:  "weekly by day from a mid-week start",
+			rule:  "FREQ=WEEKLY;BYDAY=FR,MO,WE",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 3, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 3, 9), date(time.UTC, 2024, 1, 5, 9), date(time.UTC, 2024, 1, 8, 9), date(time.UTC, 2024, 1, 10, 9)},
+		},
+		{
+			name:  "every other week",
+			rule:  "FREQ=WEEKLY;INTERVAL=2",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 1, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 1, 9), date(time.UTC, 2024, 1, 15, 9), date(time.UTC, 2024, 1, 29, 9), date(time.UTC, 2024, 2, 12, 9)},
+		},
+		{
+			name:  "monthly on the 31st skips shorter months",
+			rule:  "FREQ=MONTHLY",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 31, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 31, 9), date(time.UTC, 2024, 3, 31, 9), date(time.UTC, 2024, 5, 31, 9), date(time.UTC, 2024, 7, 31, 9)},
+		},
+		{
+			name:  "count ends the rule",
+			rule:  "FREQ=DAILY;COUNT=2",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 1, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 1, 9), date(time.UTC, 2024, 1, 2, 9)},
+		},
+		{
+			name:  "count skips occurrences before start",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=2",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 3, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 5, 9), date(time.UTC, 2024, 1, 8, 9)},
+		},
+		{
+			name:  "until date includes the whole day in the time zone",
+			rule:  "FREQ=DAILY;UNTIL=20240102",
+			loc:   newYork,
+			start: date(newYork, 2024, 1, 1, 22),
+			want:  []time.Time{date(newYork, 2024, 1, 1, 22), date(newYork, 2024, 1, 2, 22)},
+		},
+		{
+			name:  "until time is in UTC and inclusive",
+			rule:  "FREQ=DAILY;UNTIL=20240102T090000Z",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 1, 9),
+			want:  []time.Time{date(time.UTC, 2024, 1, 1, 9), date(time.UTC, 2024, 1, 2, 9)},
+		},
+		{
+			name:  "wall clock time is kept across DST",
+			rule:  "FREQ=DAILY;COUNT=3",
+			loc:   newYork,
+			start: date(newYork, 2024, 3, 9, 9),
+			want:  []time.Time{date(newYork, 2024, 3, 9, 9), date(newYork, 2024, 3, 10, 9), date(newYork, 2024, 3, 11, 9)},
+		},
+		{
+			name:  "rule without occurrences ends",
+			rule:  "FREQ=DAILY;INTERVAL=7;BYDAY=TU",
+			loc:   time.UTC,
+			start: date(time.UTC, 2024, 1, 1, 9),
+			want:  nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := parseRecurrenceRule(tc.rule, tc.loc)
+			require.NoError(t, err)
+
+			// endless rules are checked by their first four occurrences
+			var got []time.Time
+
+			after := tc.start.Add(-time.Second)
+			for len(got) < 4 {
+				occurrence, ok := r.next(tc.start, tc.loc, after)
+				if !ok {
+					break
+				}
+
+				got = append(got, occurrence)
+				after = occurrence
+			}
+
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	// occurrences around DST keep 9:00 local time, so they are 23 hours apart in UTC
+	r, err := parseRecurrenceRule("FREQ=DAILY", newYork)
+	require.NoError(t, err)
+
+	first, ok := r.next(date(newYork, 2024, 3, 9, 9), newYork, date(newYork, 2024, 3, 9, 8))
+	require.True(t, ok)
+
+	second, ok := r.next(date(newYork, 2024, 3, 9, 9), newYork, first)
+	require.True(t, ok)
+	require.Equal(t, 23*time.Hour, second.Sub(first))
+}
+
+func TestPasswordHasher_Verify(t *testing.T) {
+	argon := &Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	strongerArgon := &Argon2id{Memory: 2048, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	bcryptAlg := NewBcrypt(bcrypt.MinCost)
+	strongerBcrypt := NewBcrypt(bcrypt.MinCost + 1)
+
+	argonHash, err := argon.Hash("password123")
+	require.NoError(t, err)
+
+	bcryptHash, err := bcryptAlg.Hash("password123")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		hasher     *PasswordHasher
+		hash       string
+		password   string
+		wantMatch  bool
+		wantRehash bool
+		wantErr    bool
+	}{
+		{
+			name:      "argon2id match",
+			hasher:    NewPasswordHasher(argon),
+			hash:      argonHash,
+			password:  "password123",
+			wantMatch: true,
+		},
+		{
+			name:     "argon2id mismatch",
+			hasher:   NewPasswordHasher(argon),
+			hash:     argonHash,
+			password: "password124",
+		},
+		{
+			name:       "argon2id with weaker parameters",
+			hasher:     NewPasswordHasher(strongerArgon),
+			hash:       argonHash,
+			password:   "password123",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:      "bcrypt match",
+			hasher:    NewPasswordHasher(bcryptAlg),
+			hash:      bcryptHash,
+			password:  "password123",
+			wantMatch: true,
+		},
+		{
+			name:     "bcrypt mismatch",
+			hasher:   NewPasswordHasher(bcryptAlg),
+			hash:     bcryptHash,
+			password: "password124",
+		},
+		{
+			name:       "bcrypt with lower cost",
+			hasher:     NewPasswordHasher(strongerBcrypt),
+			hash:       bcryptHash,
+			password:   "password123",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:       "legacy algorithm",
+			hasher:     NewPasswordHasher(argon, bcryptAlg),
+			hash:       bcryptHash,
+			password:   "password123",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:     "legacy algorithm mismatch",
+			hasher:   NewPasswordHasher(argon, bcryptAlg),
+			hash:     bcryptHash,
+			password: "password124",
+		},
+		{
+			name:       "plaintext",
+			hasher:     NewPasswordHasher(argon),
+			hash:       "password123",
+			password:   "password123",
+			wantMatch:  true,
+			wantRehash: true,
+		},
+		{
+			name:     "plaintext mismatch",
+			hasher:   NewPasswordHasher(argon),
+			hash:     "password123",
+			password: "password12",
+		},
+		{
+			name:     "bcrypt hash without bcrypt algorithm",
+			hasher:   NewPasswordHasher(argon),
+			hash:     bcryptHash,
+			password: "password123",
+			wantErr:  true,
+		},
+		{
+			name:     "malformed argon2id hash",
+			hasher:   NewPasswordHasher(argon),
+			hash:     "$argon2id$v=19$m=1024",
+			password: "password123",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			match, rehash, err := tc.hasher.Verify(tc.hash, tc.password)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.wantMatch, match)
+			require.Equal(t, tc.wantRehash, rehash)
+		})
+	}
+}
+
+func TestPasswordHasher_Hash(t *testing.T) {
+	argon := &Argon2id{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	hasher := NewPasswordHasher(argon, NewBcrypt(bcrypt.MinCost))
+
+	first, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	require.True(t, argon.Owns(first))
+	require.True(t, hasher.IsHashed(first))
+
+	// every hash gets its own salt
+	second, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	bcryptHash, err := NewBcrypt(bcrypt.MinCost).Hash("password123")
+	require.NoError(t, err)
+	require.True(t, hasher.IsHashed(bcryptHash))
+
+	require.False(t, hasher.IsHashed("password123"))
+	require.True(t, (plaintext{}).Owns("password123"))
+	require.False(t, (plaintext{}).Owns(first))
+	require.False(t, (plaintext{}).Owns(bcryptHash))
+
+	_, err = (plaintext{}).Hash("password123")
+	require.Error(t, err)
+}
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{password: "", wantErr: true},
+		{password: "1234567", wantErr: true},
+		{password: "12345678"},
+		{password: "a much longer passphrase"},
+	}
+
+	for _, tc := range tests {
+		err := validatePassword(tc.password)
+		if tc.wantErr {
+			require.ErrorIs(t, err, entity.ErrBadRequest, tc.password)
+		} else {
+			require.NoError(t, err, tc.password)
+		}
+	}
+}
+
+func TestSprintReport(t *testing.T) {
+	sprintID, nextSprintID := int64(1), int64(2)
+
+	date := func(day int, hour int) time.Time {
+		return time.Date(2024, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	change := func(taskID int64, sprintID *int64, completed bool, at time.Time) entity.TaskChange {
+		return entity.TaskChange{TaskID: taskID, SprintID: sprintID, Completed: completed, ChangedAt: at}
+	}
+
+	sprint := entity.Sprint{ID: sprintID, StartAt: date(1, 0), EndAt: date(4, 0)}
+
+	closedAt := date(3, 12)
+	closedSprint := sprint
+	closedSprint.ClosedAt = &closedAt
+
+	tests := []struct {
+		name    string
+		sprint  entity.Sprint
+		history []entity.TaskChange
+		now     time.Time
+		want    entity.SprintReport
+	}{
+		{
+			name:   "task added mid-sprint",
+			sprint: sprint,
+			history: []entity.TaskChange{
+				change(1, &sprintID, false, date(0, 12)),
+				change(1, &sprintID, true, date(2, 12)),
+				change(2, nil, false, date(0, 12)),
+				change(2, &sprintID, false, date(2, 6)),
+			},
+			now: date(10, 0),
+			want: entity.SprintReport{
+				Committed: 1,
+				Added:     1,
+				Completed: 1,
+				Remaining: 1,
+				Burndown: []entity.BurndownPoint{
+					{Date: "2024-01-01", Remaining: 1, Ideal: 0.67},
+					{Date: "2024-01-02", Remaining: 1, Ideal: 0.33},
+					{Date: "2024-01-03", Remaining: 1, Ideal: 0},
+				},
+			},
+		},
+		{
+			name:   "task removed and added back",
+			sprint: sprint,
+			history: []entity.TaskChange{
+				change(1, &sprintID, false, date(0, 12)),
+				change(1, nil, false, date(1, 12)),
+				change(1, &sprintID, false, date(2, 12)),
+				change(2, &sprintID, false, date(1, 12)),
+				change(2, &nextSprintID, false, date(2, 0)),
+			},
+			now: date(10, 0),
+			want: entity.SprintReport{
+				Committed: 1,
+				Remaining: 1,
+				Burndown: []entity.BurndownPoint{
+					{Date: "2024-01-01", Remaining: 1, Ideal: 0.67},
+					{Date: "2024-01-02", Remaining: 1, Ideal: 0.33},
+					{Date: "2024-01-03", Remaining: 1, Ideal: 0},
+				},
+			},
+		},
+		{
+			name:   "task completed and reopened",
+			sprint: sprint,
+			history: []entity.TaskChange{
+				change(1, &sprintID, false, date(0, 12)),
+				change(1, &sprintID, true, date(1, 12)),
+				change(1, &sprintID, false, date(2, 12)),
+			},
+			now: date(10, 0),
+			want: entity.SprintReport{
+				Committed: 1,
+				Remaining: 1,
+				Burndown: []entity.BurndownPoint{
+					{Date: "2024-01-01", Remaining: 0, Ideal: 0.67},
+					{Date: "2024-01-02", Remaining: 1, Ideal: 0.33},
+					{Date: "2024-01-03", Remaining: 1, Ideal: 0},
+				},
+			},
+		},
+		{
+			name:   "closed sprint is reported before the rollover",
+			sprint: closedSprint,
+			history: []entity.TaskChange{
+				change(1, &sprintID, false, date(0, 12)),
+				change(1, &sprintID, true, date(2, 0)),
+				change(2, &sprintID, false, date(0, 12)),
+				change(2, &nextSprintID, false, closedAt),
+			},
+			now: date(20, 0),
+			want: entity.SprintReport{
+				Committed: 2,
+				Completed: 1,
+				Remaining: 1,
+				Burndown: []entity.BurndownPoint{
+					{Date: "2024-01-01", Remaining: 2, Ideal: 1.33},
+					{Date: "2024-01-02", Remaining: 1, Ideal: 0.67},
+					{Date: "2024-01-03", Remaining: 1, Ideal: 0.33},
+				},
+			},
+		},
+		{
+			name:   "sprint not started yet",
+			sprint: sprint,
+			history: []entity.TaskChange{
+				change(1, &sprintID, false, date(0, 6)),
+				change(2, &sprintID, false, date(0, 6)),
+				change(2, nil, false, date(0, 9)),
+			},
+			now: date(0, 12),
+			want: entity.SprintReport{
+				Committed: 1,
+				Remaining: 1,
+				Burndown:  []entity.BurndownPoint{},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.want.Sprint = tc.sprint
+
+			require.Equal(t, tc.want, sprintReport(tc.sprint, tc.history, time.UTC, tc.now))
+		})
+	}
+}
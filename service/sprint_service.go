@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+const (
+	maxSprintNameLength = 100
+	maxSprintLength     = 365 * 24 * time.Hour
+)
+
+type SprintRepository interface {
+	CreateSprint(ctx context.Context, s entity.Sprint) (entity.Sprint, error)
+	SprintByID(ctx context.Context, projectID int64, id int64) (s entity.Sprint, err error)
+	ProjectSprints(ctx context.Context, projectID int64) (sprints []entity.Sprint, err error)
+	UpdateSprint(ctx context.Context, projectID int64, id int64, upd entity.SprintToUpdate) (s entity.Sprint, err error)
+	DeleteSprint(ctx context.Context, projectID int64, id int64, updatedAt time.Time) error
+	CloseSprint(ctx context.Context, projectID int64, id int64, nextSprintID *int64, closedAt time.Time) (s entity.Sprint, moved int64, err error)
+	SprintHistory(ctx context.Context, sprintID int64) (changes []entity.TaskChange, err error)
+}
+
+type SprintService struct {
+	sprint SprintRepository
+	task   TaskRepository
+	access authorizer
+}
+
+func NewSprintService(sprint SprintRepository, task TaskRepository, project ProjectRepository) *SprintService {
+	return &SprintService{
+		sprint: sprint,
+		task:   task,
+		access: authorizer{project: project},
+	}
+}
+
+func (us *SprintService) CreateSprint(ctx context.Context, s entity.Sprint) (entity.Sprint, error) {
+	_, _, err := us.access.authorize(ctx, s.ProjectID, actionEditProject)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	s.Name = strings.TrimSpace(s.Name)
+	s.Goal = strings.TrimSpace(s.Goal)
+
+	err = validateSprint(s.Name, s.StartAt, s.EndAt)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	s.CreatedAt = time.Now()
+
+	return us.sprint.CreateSprint(ctx, s)
+}
+
+func (us *SprintService) ProjectSprints(ctx context.Context, projectID int64) ([]entity.Sprint, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	sprints, err := us.sprint.ProjectSprints(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sprints == nil {
+		sprints = []entity.Sprint{}
+	}
+
+	return sprints, nil
+}
+
+func (us *SprintService) Sprint(ctx context.Context, projectID int64, id int64) (entity.Sprint, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	return us.sprint.SprintByID(ctx, projectID, id)
+}
+
+// UpdateSprint changes sprint which is not closed yet.
+func (us *SprintService) UpdateSprint(ctx context.Context, projectID int64, id int64, upd entity.SprintToUpdate) (entity.Sprint, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	sprint, err := us.sprint.SprintByID(ctx, projectID, id)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	if sprint.ClosedAt != nil {
+		return entity.Sprint{}, fmt.Errorf("%w: sprint is closed", entity.ErrConflict)
+	}
+
+	if upd.Name != nil {
+		name := strings.TrimSpace(*upd.Name)
+		upd.Name = &name
+		sprint.Name = name
+	}
+
+	if upd.Goal != nil {
+		goal := strings.TrimSpace(*upd.Goal)
+		upd.Goal = &goal
+	}
+
+	if upd.StartAt != nil {
+		sprint.StartAt = *upd.StartAt
+	}
+
+	if upd.EndAt != nil {
+		sprint.EndAt = *upd.EndAt
+	}
+
+	err = validateSprint(sprint.Name, sprint.StartAt, sprint.EndAt)
+	if err != nil {
+		return entity.Sprint{}, err
+	}
+
+	return us.sprint.UpdateSprint(ctx, projectID, id, upd)
+}
+
+// DeleteSprint deletes sprint, its tasks go back to the backlog.
+func (us *SprintService) DeleteSprint(ctx context.Context, projectID int64, id int64) error {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return err
+	}
+
+	return us.sprint.DeleteSprint(ctx, projectID, id, time.Now())
+}
+
+// CloseSprint closes sprint and rolls its unfinished tasks into nextSprintID. Without nextSprintID
+// they go to the earliest open sprint starting after the closed one, or to the backlog if there is none.
+func (us *SprintService) CloseSprint(ctx context.Context, projectID int64, id int64, nextSprintID *int64) (entity.CloseSprintResult, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return entity.CloseSprintResult{}, err
+	}
+
+	sprints, err := us.sprint.ProjectSprints(ctx, projectID)
+	if err != nil {
+		return entity.CloseSprintResult{}, err
+	}
+
+	var (
+		current entity.Sprint
+		next    *entity.Sprint
+		found   bool
+	)
+
+	for i, s := range sprints {
+		if s.ID == id {
+			current, found = s, true
+		}
+
+		if nextSprintID != nil && s.ID == *nextSprintID {
+			next = &sprints[i]
+		}
+	}
+
+	if !found {
+		return entity.CloseSprintResult{}, fmt.Errorf("%w: sprint", entity.ErrNotFound)
+	}
+
+	if nextSprintID != nil {
+		if next == nil || next.ID == id {
+			return entity.CloseSprintResult{}, fmt.Errorf("%w: next sprint must be another sprint of the project", entity.ErrBadRequest)
+		}
+	} else {
+		for i, s := range sprints {
+			if s.ID != id && s.ClosedAt == nil && s.StartAt.After(current.StartAt) {
+				next = &sprints[i]
+				break
+			}
+		}
+	}
+
+	if next != nil && next.ClosedAt != nil {
+		return entity.CloseSprintResult{}, fmt.Errorf("%w: next sprint is closed", entity.ErrConflict)
+	}
+
+	result := entity.CloseSprintResult{}
+	if next != nil {
+		result.NextSprintID = &next.ID
+	}
+
+	closed, moved, err := us.sprint.CloseSprint(ctx, projectID, id, result.NextSprintID, time.Now())
+	if err != nil {
+		return entity.CloseSprintResult{}, err
+	}
+
+	result.Sprint = closed
+	result.RolledOver = int(moved)
+
+	return result, nil
+}
+
+// SprintReport returns committed and completed work of sprint with its daily burndown.
+// Days follow the calendar of time zone tz, or of the user's time zone when it's empty.
+func (us *SprintService) SprintReport(ctx context.Context, projectID int64, id int64, tz string) (entity.SprintReport, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return entity.SprintReport{}, err
+	}
+
+	if tz == "" {
+		tz = entity.AuthUser(ctx).TimeZone
+	}
+
+	loc, err := loadTimeZone(tz)
+	if err != nil {
+		return entity.SprintReport{}, err
+	}
+
+	sprint, err := us.sprint.SprintByID(ctx, projectID, id)
+	if err != nil {
+		return entity.SprintReport{}, err
+	}
+
+	history, err := us.sprint.SprintHistory(ctx, id)
+	if err != nil {
+		return entity.SprintReport{}, err
+	}
+
+	return sprintReport(sprint, history, loc, time.Now()), nil
+}
+
+// SetTaskSprint plans task into an open sprint of its project, nil sprintID moves it to the backlog.
+func (us *SprintService) SetTaskSprint(ctx context.Context, taskID int64, sprintID *int64) (entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if sprintID != nil {
+		sprint, err := us.sprint.SprintByID(ctx, task.ProjectID, *sprintID)
+		if err != nil {
+			return entity.Task{}, err
+		}
+
+		if sprint.ClosedAt != nil {
+			return entity.Task{}, fmt.Errorf("%w: sprint is closed", entity.ErrConflict)
+		}
+	}
+
+	return us.task.SetTaskSprint(ctx, taskID, sprintID, time.Now())
+}
+
+// sprintReport replays history of sprint tasks. A task counts for a moment if its
+// latest change until then left it in the sprint. Closed sprints are reported as they
+// were right before closing, before unfinished tasks rolled over.
+func sprintReport(sprint entity.Sprint, history []entity.TaskChange, loc *time.Location, now time.Time) entity.SprintReport {
+	reportAt := now
+	if sprint.ClosedAt != nil {
+		reportAt = sprint.ClosedAt.Add(-time.Nanosecond)
+	}
+
+	if reportAt.After(sprint.EndAt) {
+		reportAt = sprint.EndAt
+	}
+
+	// tasks holds history of each task, history is ordered by task
+	var tasks [][]entity.TaskChange
+
+	for i, c := range history {
+		if i == 0 || history[i-1].TaskID != c.TaskID {
+			tasks = append(tasks, nil)
+		}
+
+		tasks[len(tasks)-1] = append(tasks[len(tasks)-1], c)
+	}
+
+	// state returns whether task is in the sprint at moment t and whether it is completed
+	state := func(changes []entity.TaskChange, t time.Time) (inSprint bool, completed bool) {
+		for _, c := range changes {
+			if c.ChangedAt.After(t) {
+				break
+			}
+
+			inSprint = c.SprintID != nil && *c.SprintID == sprint.ID
+			completed = c.Completed
+		}
+
+		return inSprint, completed
+	}
+
+	report := entity.SprintReport{Sprint: sprint, Burndown: []entity.BurndownPoint{}}
+
+	for _, changes := range tasks {
+		committed, _ := state(changes, sprint.StartAt)
+		inSprint, completed := state(changes, reportAt)
+
+		if committed {
+			report.Committed++
+		}
+
+		if !inSprint {
+			continue
+		}
+
+		if !committed {
+			report.Added++
+		}
+
+		if completed {
+			report.Completed++
+		} else {
+			report.Remaining++
+		}
+	}
+
+	start := sprint.StartAt.In(loc)
+	length := sprint.EndAt.Sub(sprint.StartAt)
+
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(reportAt); day = day.AddDate(0, 0, 1) {
+		dayEnd := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		if dayEnd.After(reportAt) {
+			dayEnd = reportAt
+		}
+
+		point := entity.BurndownPoint{Date: day.Format(time.DateOnly)}
+
+		for _, changes := range tasks {
+			if inSprint, completed := state(changes, dayEnd); inSprint && !completed {
+				point.Remaining++
+			}
+		}
+
+		elapsed := math.Min(math.Max(float64(dayEnd.Sub(sprint.StartAt))/float64(length), 0), 1)
+		point.Ideal = math.Round(float64(report.Committed)*(1-elapsed)*100) / 100
+
+		report.Burndown = append(report.Burndown, point)
+	}
+
+	return report
+}
+
+func validateSprint(name string, startAt time.Time, endAt time.Time) error {
+	if name == "" || len(name) > maxSprintNameLength {
+		return fmt.Errorf("%w: sprint name must be 1 to %d bytes long", entity.ErrBadRequest, maxSprintNameLength)
+	}
+
+	if startAt.IsZero() || !endAt.After(startAt) {
+		return fmt.Errorf("%w: sprint must end after it starts", entity.ErrBadRequest)
+	}
+
+	if endAt.Sub(startAt) > maxSprintLength {
+		return fmt.Errorf("%w: sprint can't be longer than %d days", entity.ErrBadRequest, maxSprintLength/(24*time.Hour))
+	}
+
+	return nil
+}
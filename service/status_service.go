@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+type StatusRepository interface {
+	Workflow(ctx context.Context, projectID int64) (w entity.Workflow, err error)
+	CreateStatus(ctx context.Context, s entity.Status) (entity.Status, error)
+	UpdateStatus(ctx context.Context, projectID int64, id int64, upd entity.StatusToUpdate, updatedAt time.Time) (s entity.Status, err error)
+	DeleteStatus(ctx context.Context, projectID int64, id int64) error
+	ReplaceTransitions(ctx context.Context, projectID int64, transitions []entity.StatusTransition) error
+}
+
+func (us *ProjectService) Workflow(ctx context.Context, projectID int64) (entity.Workflow, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return entity.Workflow{}, err
+	}
+
+	return us.status.Workflow(ctx, projectID)
+}
+
+func (us *ProjectService) CreateStatus(ctx context.Context, s entity.Status) (entity.Status, error) {
+	_, _, err := us.access.authorize(ctx, s.ProjectID, actionEditProject)
+	if err != nil {
+		return entity.Status{}, err
+	}
+
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return entity.Status{}, fmt.Errorf("%w: status name can't be empty", entity.ErrBadRequest)
+	}
+
+	if s.Position < 0 {
+		return entity.Status{}, fmt.Errorf("%w: position can't be negative", entity.ErrBadRequest)
+	}
+
+	return us.status.CreateStatus(ctx, s)
+}
+
+// UpdateStatus changes a workflow status, tasks in it are completed when it becomes terminal and reopened when it stops being one.
+func (us *ProjectService) UpdateStatus(ctx context.Context, projectID int64, id int64, upd entity.StatusToUpdate) (entity.Status, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return entity.Status{}, err
+	}
+
+	if upd.Name != nil {
+		name := strings.TrimSpace(*upd.Name)
+		if name == "" {
+			return entity.Status{}, fmt.Errorf("%w: status name can't be empty", entity.ErrBadRequest)
+		}
+
+		upd.Name = &name
+	}
+
+	if upd.Position != nil && *upd.Position < 0 {
+		return entity.Status{}, fmt.Errorf("%w: position can't be negative", entity.ErrBadRequest)
+	}
+
+	return us.status.UpdateStatus(ctx, projectID, id, upd, time.Now())
+}
+
+// DeleteStatus deletes a status no task is in. The last status of a project can't be deleted.
+func (us *ProjectService) DeleteStatus(ctx context.Context, projectID int64, id int64) error {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return err
+	}
+
+	workflow, err := us.status.Workflow(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := workflow.Status(id); !ok {
+		return entity.ErrNotFound
+	}
+
+	if len(workflow.Statuses) == 1 {
+		return fmt.Errorf("%w: project must have at least one status", entity.ErrConflict)
+	}
+
+	return us.status.DeleteStatus(ctx, projectID, id)
+}
+
+// SetTransitions replaces the allowed status transitions, empty transitions allow any move.
+func (us *ProjectService) SetTransitions(ctx context.Context, projectID int64, transitions []entity.StatusTransition) error {
+	_, _, err := us.access.authorize(ctx, projectID, actionEditProject)
+	if err != nil {
+		return err
+	}
+
+	workflow, err := us.status.Workflow(ctx, projectID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range transitions {
+		_, fromOK := workflow.Status(t.FromStatusID)
+		_, toOK := workflow.Status(t.ToStatusID)
+
+		if !fromOK || !toOK {
+			return fmt.Errorf("%w: transition %d -> %d refers to an unknown status", entity.ErrBadRequest, t.FromStatusID, t.ToStatusID)
+		}
+
+		if t.FromStatusID == t.ToStatusID {
+			return fmt.Errorf("%w: transition can't start and end in the same status", entity.ErrBadRequest)
+		}
+	}
+
+	return us.status.ReplaceTransitions(ctx, projectID, transitions)
+}
+
+// TransitionTask moves task to the bottom of statusID column if the project workflow allows it.
+func (us *ProjectService) TransitionTask(ctx context.Context, taskID int64, statusID int64, override bool) (entity.Task, error) {
+	task, err := us.task.TaskByID(ctx, taskID)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	_, _, err = us.access.authorize(ctx, task.ProjectID, actionEditTasks)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	completedAt, err := us.checkTransition(ctx, task, statusID, override)
+	if err != nil {
+		return entity.Task{}, err
+	}
+
+	if task.StatusID == statusID {
+		return task, nil
+	}
+
+	return us.task.SetTaskStatus(ctx, taskID, task.StatusID, statusID, completedAt, time.Now())
+}
+
+// checkTransition checks that the project workflow allows moving task to statusID and returns
+// its completion time afterwards. Moving into a terminal status records completion time,
+// moving out of it clears it. A blocked task can't be completed unless override is set.
+func (us *ProjectService) checkTransition(ctx context.Context, task entity.Task, statusID int64, override bool) (*time.Time, error) {
+	workflow, err := us.status.Workflow(ctx, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, ok := workflow.Status(statusID)
+	if !ok {
+		return nil, fmt.Errorf("%w: status %d doesn't belong to the project", entity.ErrBadRequest, statusID)
+	}
+
+	if task.StatusID == statusID {
+		return task.CompletedAt, nil
+	}
+
+	if !workflow.Allows(task.StatusID, statusID) {
+		return nil, fmt.Errorf("%w: moving from %q to %q is not allowed", entity.ErrConflict, task.Status, to.Name)
+	}
+
+	if to.Terminal && task.Blocked && !override {
+		return nil, fmt.Errorf("%w: task is blocked by open tasks, complete them or override", entity.ErrConflict)
+	}
+
+	now := time.Now()
+
+	completedAt := task.CompletedAt
+	if !to.Terminal {
+		completedAt = nil
+	} else if from, _ := workflow.Status(task.StatusID); !from.Terminal || completedAt == nil {
+		completedAt = &now
+	}
+
+	return completedAt, nil
+}
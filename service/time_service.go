@@ -0,0 +1,415 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"restAPI/entity"
+	"strings"
+	"time"
+)
+
+const (
+	maxTimeEntryMinutes = 24 * 60
+	maxTimeNoteLength   = 1000
+	// defaultTimeRangeDays is the length of a summary range when it has no start.
+	defaultTimeRangeDays = 30
+	maxTimeRangeDays     = 366
+)
+
+type TimeEntryRepository interface {
+	CreateTimeEntry(ctx context.Context, e entity.TimeEntry) (entity.TimeEntry, error)
+	TimeEntryByID(ctx context.Context, id int64) (e entity.TimeEntry, err error)
+	TimeEntries(ctx context.Context, f entity.TimeFilter) (entries []entity.TimeEntry, err error)
+	TimeTotals(ctx context.Context, f entity.TimeFilter, group entity.TimeGroup) (totals []entity.TimeTotal, err error)
+	UpdateTimeEntry(ctx context.Context, id int64, upd entity.TimeEntryToUpdate) (e entity.TimeEntry, err error)
+	DeleteTimeEntry(ctx context.Context, id int64) error
+	RunningTimer(ctx context.Context, userID int64) (e entity.TimeEntry, err error)
+	StopTimer(ctx context.Context, userID int64, stoppedAt time.Time, maxMinutes int) (e entity.TimeEntry, err error)
+}
+
+type TimeService struct {
+	entry  TimeEntryRepository
+	task   TaskRepository
+	access authorizer
+}
+
+func NewTimeService(entry TimeEntryRepository, task TaskRepository, project ProjectRepository) *TimeService {
+	return &TimeService{
+		entry:  entry,
+		task:   task,
+		access: authorizer{project: project, task: task},
+	}
+}
+
+// LogTime logs time of the authenticated user spent on task.
+func (us *TimeService) LogTime(ctx context.Context, taskID int64, c entity.TimeEntryToCreate) (entity.TimeEntry, error) {
+	user := entity.AuthUser(ctx)
+
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	if c.Date == "" {
+		c.Date, err = today(ctx)
+		if err != nil {
+			return entity.TimeEntry{}, err
+		}
+	}
+
+	err = validateDate(c.Date)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	err = validateTimeMinutes(c.Minutes)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	note, err := validateTimeNote(c.Note)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	return us.entry.CreateTimeEntry(ctx, entity.TimeEntry{
+		TaskID:    taskID,
+		UserID:    user.ID,
+		Date:      c.Date,
+		Minutes:   c.Minutes,
+		Note:      note,
+		CreatedAt: time.Now(),
+	})
+}
+
+// StartTimer starts a timer on task, it fails with entity.ErrConflict while another timer of the user runs.
+// Time of the timer counts for the day it was started in the user's time zone.
+func (us *TimeService) StartTimer(ctx context.Context, taskID int64, note string) (entity.TimeEntry, error) {
+	user := entity.AuthUser(ctx)
+
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionEditTasks)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	note, err = validateTimeNote(note)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	date, err := today(ctx)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	now := time.Now()
+
+	return us.entry.CreateTimeEntry(ctx, entity.TimeEntry{
+		TaskID:    taskID,
+		UserID:    user.ID,
+		Date:      date,
+		Note:      note,
+		StartedAt: &now,
+		CreatedAt: now,
+	})
+}
+
+func (us *TimeService) RunningTimer(ctx context.Context) (entity.TimeEntry, error) {
+	user := entity.AuthUser(ctx)
+
+	return us.entry.RunningTimer(ctx, user.ID)
+}
+
+// StopTimer stops the running timer of the authenticated user and logs its time,
+// at most a day of it. The user must still be allowed to log time on the task.
+func (us *TimeService) StopTimer(ctx context.Context) (entity.TimeEntry, error) {
+	user := entity.AuthUser(ctx)
+
+	entry, err := us.entry.RunningTimer(ctx, user.ID)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	_, _, err = us.access.authorizeTask(ctx, entry.TaskID, actionEditTasks)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	return us.entry.StopTimer(ctx, user.ID, time.Now(), maxTimeEntryMinutes)
+}
+
+// TaskTimeEntries returns time entries of task within r, empty bounds are open.
+func (us *TimeService) TaskTimeEntries(ctx context.Context, taskID int64, r entity.DateRange) ([]entity.TimeEntry, error) {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, date := range []string{r.From, r.To} {
+		if date != "" {
+			err = validateDate(date)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	entries, err := us.entry.TimeEntries(ctx, entity.TimeFilter{DateRange: r, TaskID: &taskID})
+	if err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		entries = []entity.TimeEntry{}
+	}
+
+	return entries, nil
+}
+
+// UpdateTimeEntry changes time entry, only its author can edit it.
+func (us *TimeService) UpdateTimeEntry(ctx context.Context, taskID int64, id int64, upd entity.TimeEntryToUpdate) (entity.TimeEntry, error) {
+	user := entity.AuthUser(ctx)
+
+	entry, _, err := us.authorizeTimeEntry(ctx, taskID, id, actionEditTasks)
+	if err != nil {
+		return entity.TimeEntry{}, err
+	}
+
+	if entry.UserID != user.ID {
+		return entity.TimeEntry{}, fmt.Errorf("%w: only the author can edit a time entry", entity.ErrForbidden)
+	}
+
+	if upd.Date != nil {
+		err = validateDate(*upd.Date)
+		if err != nil {
+			return entity.TimeEntry{}, err
+		}
+	}
+
+	if upd.Minutes != nil {
+		if entry.Running {
+			return entity.TimeEntry{}, fmt.Errorf("%w: stop the timer first", entity.ErrConflict)
+		}
+
+		err = validateTimeMinutes(*upd.Minutes)
+		if err != nil {
+			return entity.TimeEntry{}, err
+		}
+	}
+
+	if upd.Note != nil {
+		note, err := validateTimeNote(*upd.Note)
+		if err != nil {
+			return entity.TimeEntry{}, err
+		}
+
+		upd.Note = &note
+	}
+
+	return us.entry.UpdateTimeEntry(ctx, id, upd)
+}
+
+// DeleteTimeEntry deletes time entry of the authenticated user, admins can delete any entry.
+func (us *TimeService) DeleteTimeEntry(ctx context.Context, taskID int64, id int64) error {
+	user := entity.AuthUser(ctx)
+
+	entry, role, err := us.authorizeTimeEntry(ctx, taskID, id, actionEditTasks)
+	if err != nil {
+		return err
+	}
+
+	if entry.UserID != user.ID && !role.AtLeast(requiredRoles[actionModerate]) {
+		return fmt.Errorf("%w: %s can't delete time of other members", entity.ErrForbidden, role)
+	}
+
+	return us.entry.DeleteTimeEntry(ctx, id)
+}
+
+// TaskTimeSummary returns time logged on task within r by each user.
+func (us *TimeService) TaskTimeSummary(ctx context.Context, taskID int64, r entity.DateRange) (entity.TimeSummary, error) {
+	_, _, err := us.access.authorizeTask(ctx, taskID, actionRead)
+	if err != nil {
+		return entity.TimeSummary{}, err
+	}
+
+	return us.timeSummary(ctx, entity.TimeFilter{DateRange: r, TaskID: &taskID}, entity.TimeByUser)
+}
+
+// ProjectTimeSummary returns time logged in project within r by each task or user.
+func (us *TimeService) ProjectTimeSummary(ctx context.Context, projectID int64, r entity.DateRange, group entity.TimeGroup) (entity.TimeSummary, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return entity.TimeSummary{}, err
+	}
+
+	if group == "" {
+		group = entity.TimeByTask
+	}
+
+	if group != entity.TimeByTask && group != entity.TimeByUser {
+		return entity.TimeSummary{}, fmt.Errorf("%w: 'group' must be %s or %s", entity.ErrBadRequest, entity.TimeByTask, entity.TimeByUser)
+	}
+
+	return us.timeSummary(ctx, entity.TimeFilter{DateRange: r, ProjectID: &projectID}, group)
+}
+
+// UserTimeSummary returns time the authenticated user logged within r on each task or in each project.
+func (us *TimeService) UserTimeSummary(ctx context.Context, r entity.DateRange, group entity.TimeGroup) (entity.TimeSummary, error) {
+	user := entity.AuthUser(ctx)
+
+	if group == "" {
+		group = entity.TimeByProject
+	}
+
+	if group != entity.TimeByTask && group != entity.TimeByProject {
+		return entity.TimeSummary{}, fmt.Errorf("%w: 'group' must be %s or %s", entity.ErrBadRequest, entity.TimeByTask, entity.TimeByProject)
+	}
+
+	return us.timeSummary(ctx, entity.TimeFilter{DateRange: r, UserID: &user.ID}, group)
+}
+
+// ExportTimeEntries returns logged time of project within r for invoicing, running timers are left out.
+// Non-nil userID keeps time of a single member.
+func (us *TimeService) ExportTimeEntries(ctx context.Context, projectID int64, r entity.DateRange, userID *int64) ([]entity.TimeEntry, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err = timeRange(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := us.entry.TimeEntries(ctx, entity.TimeFilter{DateRange: r, ProjectID: &projectID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	finished := make([]entity.TimeEntry, 0, len(entries))
+	for _, e := range entries {
+		if !e.Running {
+			finished = append(finished, e)
+		}
+	}
+
+	return finished, nil
+}
+
+func (us *TimeService) timeSummary(ctx context.Context, f entity.TimeFilter, group entity.TimeGroup) (entity.TimeSummary, error) {
+	r, err := timeRange(ctx, f.DateRange)
+	if err != nil {
+		return entity.TimeSummary{}, err
+	}
+
+	f.DateRange = r
+
+	totals, err := us.entry.TimeTotals(ctx, f, group)
+	if err != nil {
+		return entity.TimeSummary{}, err
+	}
+
+	summary := entity.TimeSummary{DateRange: r, Group: group, Totals: totals}
+	if summary.Totals == nil {
+		summary.Totals = []entity.TimeTotal{}
+	}
+
+	for _, total := range totals {
+		summary.Minutes += total.Minutes
+	}
+
+	return summary, nil
+}
+
+// authorizeTimeEntry checks act on the task and that time entry id belongs to it.
+func (us *TimeService) authorizeTimeEntry(ctx context.Context, taskID int64, id int64, act action) (entity.TimeEntry, entity.Role, error) {
+	_, role, err := us.access.authorizeTask(ctx, taskID, act)
+	if err != nil {
+		return entity.TimeEntry{}, "", err
+	}
+
+	entry, err := us.entry.TimeEntryByID(ctx, id)
+	if err != nil {
+		return entity.TimeEntry{}, "", err
+	}
+
+	if entry.TaskID != taskID {
+		return entity.TimeEntry{}, "", fmt.Errorf("%w: time entry", entity.ErrNotFound)
+	}
+
+	return entry, role, nil
+}
+
+// timeRange fills in missing bounds of r, it ends today in the user's time zone
+// and starts defaultTimeRangeDays before its end.
+func timeRange(ctx context.Context, r entity.DateRange) (entity.DateRange, error) {
+	var err error
+
+	if r.To == "" {
+		r.To, err = today(ctx)
+		if err != nil {
+			return entity.DateRange{}, err
+		}
+	}
+
+	to, err := time.Parse(time.DateOnly, r.To)
+	if err != nil {
+		return entity.DateRange{}, fmt.Errorf("%w: date %q must be in the YYYY-MM-DD format", entity.ErrBadRequest, r.To)
+	}
+
+	if r.From == "" {
+		r.From = to.AddDate(0, 0, 1-defaultTimeRangeDays).Format(time.DateOnly)
+	}
+
+	from, err := time.Parse(time.DateOnly, r.From)
+	if err != nil {
+		return entity.DateRange{}, fmt.Errorf("%w: date %q must be in the YYYY-MM-DD format", entity.ErrBadRequest, r.From)
+	}
+
+	if to.Before(from) {
+		return entity.DateRange{}, fmt.Errorf("%w: range can't end before it starts", entity.ErrBadRequest)
+	}
+
+	if to.Sub(from) >= maxTimeRangeDays*24*time.Hour {
+		return entity.DateRange{}, fmt.Errorf("%w: range can't be longer than %d days", entity.ErrBadRequest, maxTimeRangeDays)
+	}
+
+	return r, nil
+}
+
+// today returns the current date in the time zone of the authenticated user.
+func today(ctx context.Context) (string, error) {
+	loc, err := loadTimeZone(entity.AuthUser(ctx).TimeZone)
+	if err != nil {
+		return "", err
+	}
+
+	return time.Now().In(loc).Format(time.DateOnly), nil
+}
+
+func validateDate(date string) error {
+	_, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return fmt.Errorf("%w: date %q must be in the YYYY-MM-DD format", entity.ErrBadRequest, date)
+	}
+
+	return nil
+}
+
+func validateTimeMinutes(minutes int) error {
+	if minutes < 1 || minutes > maxTimeEntryMinutes {
+		return fmt.Errorf("%w: time entry must be 1 to %d minutes long", entity.ErrBadRequest, maxTimeEntryMinutes)
+	}
+
+	return nil
+}
+
+func validateTimeNote(note string) (string, error) {
+	note = strings.TrimSpace(note)
+
+	if len(note) > maxTimeNoteLength {
+		return "", fmt.Errorf("%w: note is longer than %d bytes", entity.ErrBadRequest, maxTimeNoteLength)
+	}
+
+	return note, nil
+}
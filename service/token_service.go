@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"restAPI/entity"
+	"slices"
+	"strings"
+	"time"
+)
+
+// accessTokenPrefix makes personal access tokens recognizable, e.g. by secret scanners.
+const accessTokenPrefix = "pat_"
+
+type TokenRepository interface {
+	CreateAccessToken(ctx context.Context, t entity.PersonalAccessToken, tokenHash string) (entity.PersonalAccessToken, error)
+	UserByAccessToken(ctx context.Context, tokenHash string, usedAt time.Time) (u entity.User, t entity.PersonalAccessToken, err error)
+	UserAccessTokens(ctx context.Context, userID int64) (tokens []entity.PersonalAccessToken, err error)
+	DeleteAccessToken(ctx context.Context, id int64, userID int64) error
+}
+
+type TokenService struct {
+	token TokenRepository
+}
+
+func NewTokenService(token TokenRepository) *TokenService {
+	return &TokenService{token: token}
+}
+
+func (us *TokenService) CreateAccessToken(ctx context.Context, t entity.PersonalAccessToken) (entity.PersonalAccessToken, error) {
+	user := entity.AuthUser(ctx)
+
+	if strings.TrimSpace(t.Name) == "" {
+		return entity.PersonalAccessToken{}, fmt.Errorf("%w: token name is required", entity.ErrBadRequest)
+	}
+
+	for _, scope := range t.Scopes {
+		if !slices.Contains(entity.Scopes, scope) {
+			return entity.PersonalAccessToken{}, fmt.Errorf("%w: unknown scope %q", entity.ErrBadRequest, scope)
+		}
+	}
+
+	now := time.Now()
+
+	if t.ExpiresAt != nil && !t.ExpiresAt.After(now) {
+		return entity.PersonalAccessToken{}, fmt.Errorf("%w: expiry must be in the future", entity.ErrBadRequest)
+	}
+
+	secret, _, err := newToken()
+	if err != nil {
+		return entity.PersonalAccessToken{}, err
+	}
+
+	t.Token = accessTokenPrefix + secret
+	t.UserID = user.ID
+	t.CreatedAt = now
+	t.LastUsedAt = nil
+
+	if t.Scopes == nil {
+		t.Scopes = []string{}
+	}
+
+	return us.token.CreateAccessToken(ctx, t, hashToken(t.Token))
+}
+
+func (us *TokenService) AccessTokens(ctx context.Context) ([]entity.PersonalAccessToken, error) {
+	user := entity.AuthUser(ctx)
+
+	return us.token.UserAccessTokens(ctx, user.ID)
+}
+
+func (us *TokenService) RevokeAccessToken(ctx context.Context, id int64) error {
+	user := entity.AuthUser(ctx)
+
+	return us.token.DeleteAccessToken(ctx, id, user.ID)
+}
+
+// UserByAccessToken authenticates a bearer token and checks it grants scope.
+func (us *TokenService) UserByAccessToken(ctx context.Context, token string, scope string) (entity.User, error) {
+	if !strings.HasPrefix(token, accessTokenPrefix) {
+		return entity.User{}, fmt.Errorf("%w: invalid access token", entity.ErrUnauthorized)
+	}
+
+	user, t, err := us.token.UserByAccessToken(ctx, hashToken(token), time.Now())
+	if err != nil {
+		if errors.Is(err, entity.ErrNotFound) {
+			return entity.User{}, fmt.Errorf("%w: invalid or expired access token", entity.ErrUnauthorized)
+		}
+
+		return entity.User{}, err
+	}
+
+	if !t.Allows(scope) {
+		return entity.User{}, fmt.Errorf("%w: access token has no %q scope", entity.ErrForbidden, scope)
+	}
+
+	return user, nil
+}
@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"restAPI/entity"
 )
 
@@ -11,7 +10,8 @@ type UserRepository interface {
 	DeleteUser(ctx context.Context, id int64) error
 	UserByID(ctx context.Context, id int64) (u entity.User, err error)
 	UserByEmail(ctx context.Context, email string) (u entity.User, err error)
-	Users(ctx context.Context) (users []entity.User, err error)
+	UpdateTimeZone(ctx context.Context, id int64, timeZone string) (entity.User, error)
+	Users(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.User], error)
 	ProjectUsers(ctx context.Context, projectID int64) (users []entity.User, err error)
 }
 
@@ -19,6 +19,7 @@ type UserService struct {
 	user    UserRepository
 	auth    AuthRepository
 	project ProjectRepository
+	access  authorizer
 }
 
 func NewUserService(user UserRepository, auth AuthRepository, project ProjectRepository) *UserService {
@@ -26,6 +27,7 @@ func NewUserService(user UserRepository, auth AuthRepository, project ProjectRep
 		user:    user,
 		auth:    auth,
 		project: project,
+		access:  authorizer{project: project},
 	}
 }
 
@@ -52,34 +54,31 @@ func (us *UserService) DeleteUser(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (us *UserService) Users(ctx context.Context) ([]entity.User, error) {
-	users, err := us.user.Users(ctx)
+// SetTimeZone changes the time zone which decides the authenticated user's calendar days.
+func (us *UserService) SetTimeZone(ctx context.Context, timeZone string) (entity.User, error) {
+	user := entity.AuthUser(ctx)
+
+	loc, err := loadTimeZone(timeZone)
 	if err != nil {
-		return nil, err
+		return entity.User{}, err
 	}
 
-	return users, nil
+	return us.user.UpdateTimeZone(ctx, user.ID, loc.String())
 }
 
-func (us *UserService) ProjectUsers(ctx context.Context, projectID int64) ([]entity.User, error) {
-	user := entity.AuthUser(ctx)
-
-	projects, err := us.project.UserProjects(ctx, user.ID)
+func (us *UserService) Users(ctx context.Context, lq entity.ListQuery) (entity.Page[entity.User], error) {
+	users, err := us.user.Users(ctx, lq)
 	if err != nil {
-		return nil, err
+		return entity.Page[entity.User]{}, err
 	}
 
-	result := false
-
-	for _, v := range projects {
-		if projectID == v.ID {
-			result = true
-			break
-		}
-	}
+	return users, nil
+}
 
-	if !result {
-		return nil, fmt.Errorf("%w: not your project", entity.ErrForbidden)
+func (us *UserService) ProjectUsers(ctx context.Context, projectID int64) ([]entity.User, error) {
+	_, _, err := us.access.authorize(ctx, projectID, actionRead)
+	if err != nil {
+		return nil, err
 	}
 
 	users, err := us.user.ProjectUsers(ctx, projectID)